@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/bluekiri/kafka-client/internal/handlers"
+	"github.com/bluekiri/kafka-client/internal/kafkautils"
 	"github.com/bluekiri/kafka-client/internal/sliceutils"
 	"github.com/bluekiri/kafka-client/internal/timeutils"
 
@@ -45,27 +46,95 @@ func init() {
 
 	bridgeCmd.Flags().DurationP(period, "p", 0, "time to wait between producing two messages.")
 	viper.BindPFlag(period, bridgeCmd.Flags().Lookup(period))
+
+	addStartFlags(bridgeCmd)
+	addGroupFlags(bridgeCmd)
+	addProducerFlags(bridgeCmd)
+	bridgeCmd.Flags().Bool(exactlyOnce, false, "bridge every message exactly once, producing the messages in transactions that commit the offsets of the consumer group. Requires --group, --transactional-id, Kafka 2.5.0 or later and the source and destination topics in the same cluster, so it can't mirror topics between clusters.")
+	bridgeCmd.Flags().Duration(commitInterval, 100*time.Millisecond, "time between two transactions in --exactly-once mode.")
+	bridgeCmd.Flags().String(transactionalID, "", "transactional ID of the producer in --exactly-once mode, which must be unique per bridge of the consumer group and stable across restarts to fence off the transactions left open by the previous instance.")
+
+	addFilterFlags(bridgeCmd)
+	addTransformFlags(bridgeCmd)
+	addRedactFlags(bridgeCmd)
+	bridgeCmd.Flags().String(keyFormat, "text", "format of the key in the --filter and --set-key expressions and of the key set by --set-key: "+codecFormats+".")
+	bridgeCmd.Flags().String(valueFormat, "text", "format of the value in the --filter and --set-key expressions and of the transformed values: "+codecFormats+".")
+	bridgeCmd.RegisterFlagCompletionFunc(keyFormat, wrapCompletion(completeCodecFormat, bindFlags))
+	bridgeCmd.RegisterFlagCompletionFunc(valueFormat, wrapCompletion(completeCodecFormat, bindFlags))
+	addSchemaFlags(bridgeCmd)
 }
 
 func bridge(cmd *cobra.Command, args []string) error {
 	cmd.SilenceUsage = true
 
 	// Get the command arguments and flags
-	inputKafkaBrokers := strings.Split(resolveCluster(args[0]), ",")
+	inputCluster, err := getClusterConfig(args[0])
+	if err != nil {
+		return err
+	}
+	inputKafkaBrokers := inputCluster.brokerList()
 	inputKafkaTopic := args[1]
-	outputKafkaBrokers := strings.Split(resolveCluster(args[2]), ",")
+	outputCluster, err := getClusterConfig(args[2])
+	if err != nil {
+		return err
+	}
+	outputKafkaBrokers := outputCluster.brokerList()
 	outputKafkaTopic := args[3]
-	kafkaClientID := viper.GetString(clientID)
+	if err := checkSecurityFlags(cmd, inputCluster, outputCluster); err != nil {
+		return err
+	}
 	pacerPeriod := viper.GetDuration(period)
 	duration := viper.GetDuration(duration)
 	reportingPeriod := time.Duration(1) * time.Second
 	if viper.GetBool(quiet) {
 		reportingPeriod = -1
 	}
+	startPositions, err := getStartPositions(cmd, kafkautils.Latest)
+	if err != nil {
+		return err
+	}
+	partitions, err := getPartitions(cmd)
+	if err != nil {
+		return err
+	}
+	groupID, _ := cmd.Flags().GetString(group)
+	isExactlyOnce, _ := cmd.Flags().GetBool(exactlyOnce)
+	if isExactlyOnce && groupID == "" {
+		return fmt.Errorf("--%s requires --%s", exactlyOnce, group)
+	}
+	if cmd.Flags().Changed(transactionalID) && !isExactlyOnce {
+		return fmt.Errorf("--%s requires --%s", transactionalID, exactlyOnce)
+	}
+	if isExactlyOnce && !cmd.Flags().Changed(transactionalID) {
+		return fmt.Errorf("--%s requires --%s", exactlyOnce, transactionalID)
+	}
+	transactionInterval, _ := cmd.Flags().GetDuration(commitInterval)
+
+	// Get the filter
+	messageFilter, err := getFilter(cmd, inputKafkaTopic)
+	if err != nil {
+		return err
+	}
+
+	// Get the transform
+	messageTransform, err := getTransform(cmd, inputKafkaTopic, outputKafkaTopic)
+	if err != nil {
+		return err
+	}
+
 	// input Kafka configuration
-	inputConfig := sarama.NewConfig()
-	inputConfig.ClientID = kafkaClientID
+	inputConfig, err := newSaramaConfig(cmd, inputCluster)
+	if err != nil {
+		return err
+	}
 	inputConfig.Consumer.Return.Errors = true
+	configureGroup(cmd, inputConfig)
+	if isExactlyOnce {
+		// The offsets are committed by the transactions, which must be
+		// committed to make their messages visible downstream
+		inputConfig.Consumer.Offsets.AutoCommit.Enable = false
+		inputConfig.Consumer.IsolationLevel = sarama.ReadCommitted
+	}
 
 	// Get the input Kafka client
 	inputClient, err := sarama.NewClient(inputKafkaBrokers, inputConfig)
@@ -82,10 +151,20 @@ func bridge(cmd *cobra.Command, args []string) error {
 	}
 
 	// input Kafka configuration
-	outputConfig := sarama.NewConfig()
-	outputConfig.ClientID = kafkaClientID
+	outputConfig, err := newSaramaConfig(cmd, outputCluster)
+	if err != nil {
+		return err
+	}
 	outputConfig.Producer.Return.Successes = true
 	outputConfig.Producer.Return.Errors = true
+	if err := configureProducer(cmd, outputConfig, outputCluster); err != nil {
+		return err
+	}
+	if isExactlyOnce {
+		if err := configureTransactions(cmd, outputConfig, outputCluster); err != nil {
+			return err
+		}
+	}
 
 	// Get the input Kafka client
 	outputClient, err := sarama.NewClient(outputKafkaBrokers, outputConfig)
@@ -101,18 +180,44 @@ func bridge(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("kafka: topic %s does not exist in destination cluster", outputKafkaTopic)
 	}
 
+	// The transactions commit the offsets in the destination cluster
+	if isExactlyOnce && !sameCluster(inputClient, outputClient) {
+		return fmt.Errorf("--%s requires the source and destination topics to be in the same cluster", exactlyOnce)
+	}
+
 	// Create the pacer
 	pacer, stopPacer := timeutils.NewPacer(pacerPeriod)
 	defer stopPacer()
 
 	// Create the handlers
-	inputHandler, err := handlers.NewKafkaInputHandler(inputClient, inputKafkaTopic)
+	inputHandler, err := newKafkaInputHandler(cmd, inputClient, inputKafkaTopic, handlers.KafkaInputOptions{
+		Start:      startPositions,
+		Partitions: partitions,
+		WaitDone:   isExactlyOnce,
+	})
+	if err != nil {
+		return err
+	}
+	filterHandler, err := handlers.NewFilterHandler(inputHandler.Messages(), messageFilter)
+	if err != nil {
+		return err
+	}
+	transformHandler, err := handlers.NewTransformHandler(filterHandler.Messages(), messageTransform)
 	if err != nil {
-		return nil
+		return err
+	}
+	var outputHandler handlers.OutputHandler
+	if isExactlyOnce {
+		outputHandler, err = handlers.NewKafkaTransactionalOutputHandler(transformHandler.Messages(), pacer, outputClient, outputKafkaTopic, getKafkaOutputOptions(cmd), handlers.KafkaTransactionOptions{
+			Group:    groupID,
+			Topic:    inputKafkaTopic,
+			Interval: transactionInterval,
+		})
+	} else {
+		outputHandler, err = handlers.NewKafkaOutputHandler(transformHandler.Messages(), pacer, outputClient, outputKafkaTopic, getKafkaOutputOptions(cmd))
 	}
-	outputHandler, err := handlers.NewKafkaOutputHandler(inputHandler.Messages(), pacer, outputClient, outputKafkaTopic)
 	if err != nil {
-		return nil
+		return err
 	}
 	reportingHandler := handlers.NewReportingHandler(logger, reportingPeriod)
 
@@ -123,11 +228,17 @@ func bridge(cmd *cobra.Command, args []string) error {
 	g, ctx := errgroup.WithContext(ctx)
 
 	// Start reporting goroutine
-	g.Go(reportingHandler.Start(inputHandler.Progress(), outputHandler.Progress()))
+	g.Go(reportingHandler.Start(inputHandler.Progress(), filterHandler.Progress(), transformHandler.Progress(), outputHandler.Progress()))
 
 	// Start the output goroutine
 	g.Go(outputHandler.Run)
 
+	// Start the transform goroutine
+	g.Go(transformHandler.Start(ctx))
+
+	// Start the filter goroutine
+	g.Go(filterHandler.Start(ctx))
+
 	// Start the input goroutine
 	g.Go(inputHandler.Start(ctx))
 
@@ -137,13 +248,56 @@ func bridge(cmd *cobra.Command, args []string) error {
 		logEvery = fmt.Sprintf(" every %v", pacerPeriod)
 	}
 	logger.Printf(
-		"bridging messages from cluster %s topic %s to cluster %s topic %s%s",
-		strings.Join(inputKafkaBrokers, ","), inputKafkaTopic,
+		"bridging messages from cluster %s topic %s %s to cluster %s topic %s%s",
+		strings.Join(inputKafkaBrokers, ","), inputKafkaTopic, describeStart(cmd, startPositions),
 		strings.Join(outputKafkaBrokers, ","), outputKafkaTopic,
 		logEvery,
 	)
+	if isExactlyOnce {
+		logger.Printf("committing a transaction every %v", transactionInterval)
+	}
 	logger.Printf("press ctrl-c to exit")
 
 	// Return the error group error
 	return adaptError(g.Wait())
 }
+
+// configureTransactions configures the producer to produce the messages in
+// transactions with the transactional ID given by --transactional-id.
+//
+// The ID must be stable across restarts, so a restarted bridge fences off the
+// transactions left open by its previous instance, which would otherwise block
+// the consumers reading committed messages until they time out. The producer
+// doesn't fence them off by the consumer group generation (KIP-447), so
+// nothing else does. Kafka 2.5.0 is required for the producer to recover from
+// the transactions aborted by errors by bumping its epoch (KIP-360).
+func configureTransactions(cmd *cobra.Command, config *sarama.Config, settings *clusterConfig) error {
+	if !config.Version.IsAtLeast(sarama.V2_5_0_0) {
+		return fmt.Errorf("--%s requires Kafka 2.5.0 or later, given by --%s", exactlyOnce, kafkaVersion)
+	}
+	if settings.isSet(cmd, acks) && config.Producer.RequiredAcks != sarama.WaitForAll {
+		return fmt.Errorf("--%s requires --%s all", exactlyOnce, acks)
+	}
+	id, _ := cmd.Flags().GetString(transactionalID)
+	if id == "" {
+		return fmt.Errorf("invalid --%s: empty transactional ID", transactionalID)
+	}
+	config.Producer.Idempotent = true
+	config.Producer.RequiredAcks = sarama.WaitForAll
+	config.Producer.Transaction.ID = id
+	config.Net.MaxOpenRequests = 1
+	return nil
+}
+
+// sameCluster reports whether both clients are connected to the same cluster,
+// that is, whether they share any broker.
+func sameCluster(client1 sarama.Client, client2 sarama.Client) bool {
+	for _, broker1 := range client1.Brokers() {
+		for _, broker2 := range client2.Brokers() {
+			if broker1.Addr() == broker2.Addr() {
+				return true
+			}
+		}
+	}
+	return false
+}
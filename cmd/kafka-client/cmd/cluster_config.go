@@ -0,0 +1,247 @@
+/*
+Copyright © 2023 VECI Group Tech S.L.
+This file is part of kafka-client.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bluekiri/kafka-client/internal/kafkautils"
+
+	"github.com/IBM/sarama"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	// clusterSettings are the flags that can be configured for a cluster.
+	clusterSettings = []string{
+		clientID, kafkaVersion,
+		partitioner, compression, acks, idempotent, maxMessageBytes, batchBytes, batchMessages, linger,
+		tlsEnable, tlsCA, tlsCert, tlsKey, tlsInsecure,
+		saslMechanism, saslUsername, saslPassword, saslTokenFile, saslTokenCommand,
+	}
+
+	// consumerSettings are the settings tuning the consumers of a cluster,
+	// which have no flags.
+	consumerSettings = []string{fetchMinBytes, fetchMaxBytes, maxPartitionFetchBytes, fetchMaxWait, sessionTimeout, heartbeatInterval, rebalanceStrategy}
+
+	// secretSettings are the settings hidden by the config show command.
+	secretSettings = []string{saslPassword}
+)
+
+// clusterConfig is the profile of a cluster given by the clusters section of
+// the configuration file. Every entry is either the bootstrap servers of the
+// cluster or its brokers, settings and topics. For example:
+//
+//	clusters:
+//	  local: localhost:9092
+//	  prod:
+//	    brokers: broker1:9092,broker2:9092
+//	    kafka-version: 3.6.0
+//	    compression: zstd
+//	    acks: all
+//	    tls: true
+//	    sasl-mechanism: SCRAM-SHA-512
+//	    session-timeout: 30s
+//	    topics:
+//	      - topic: orders
+//	        proto: acme.orders.v1.Order
+type clusterConfig struct {
+	name     string
+	brokers  string
+	settings map[string]any
+	topics   []map[string]any
+}
+
+// getClusterConfig returns the profile of the given cluster. Clusters not
+// configured are bootstrap servers without settings.
+func getClusterConfig(cluster string) (*clusterConfig, error) {
+	config := &clusterConfig{name: cluster, brokers: cluster}
+
+	switch entry := viper.GetStringMap(clusters)[cluster].(type) {
+	case nil:
+	case string:
+		if entry != "" {
+			config.brokers = entry
+		}
+	case map[string]any:
+		for setting, value := range entry {
+			switch {
+			case setting == brokers:
+				// Brokers are given as a comma separated string or a list
+				if values, ok := value.([]any); ok {
+					brokers := make([]string, 0, len(values))
+					for _, broker := range values {
+						brokers = append(brokers, fmt.Sprint(broker))
+					}
+					config.brokers = strings.Join(brokers, ",")
+				} else {
+					config.brokers = fmt.Sprint(value)
+				}
+			case setting == topicsConfig:
+				// Topics are configured like the topics section
+				values, ok := value.([]any)
+				if !ok {
+					return nil, fmt.Errorf("invalid %s configuration: cluster '%s': %s: expected a list of topics", clusters, cluster, setting)
+				}
+				for _, value := range values {
+					topic, ok := value.(map[string]any)
+					if !ok {
+						return nil, fmt.Errorf("invalid %s configuration: cluster '%s': %s: expected a list of topics", clusters, cluster, setting)
+					}
+					config.topics = append(config.topics, topic)
+				}
+			case slices.Contains(clusterSettings, setting), slices.Contains(consumerSettings, setting):
+				if config.settings == nil {
+					config.settings = make(map[string]any)
+				}
+				config.settings[setting] = value
+			default:
+				return nil, fmt.Errorf("invalid %s configuration: cluster '%s': unknown setting '%s'", clusters, cluster, setting)
+			}
+		}
+		if config.brokers == cluster {
+			return nil, fmt.Errorf("invalid %s configuration: cluster '%s': missing brokers", clusters, cluster)
+		}
+	default:
+		return nil, fmt.Errorf("invalid %s configuration: cluster '%s': expected the bootstrap servers or the cluster settings", clusters, cluster)
+	}
+
+	return config, nil
+}
+
+// brokerList returns the bootstrap servers of the cluster.
+func (config *clusterConfig) brokerList() []string {
+	return strings.Split(config.brokers, ",")
+}
+
+// isSet reports whether the flag is given in the command line or configured
+// for the cluster.
+func (config *clusterConfig) isSet(cmd *cobra.Command, flag string) bool {
+	_, configured := config.settings[flag]
+	return configured || cmd.Flags().Changed(flag)
+}
+
+// newSaramaConfig returns the sarama configuration of the clients of the
+// cluster, holding the client ID, the Kafka version, the security and the
+// consumer tuning given by the flags or, if not given, by the profile of the
+// cluster. The producer is configured by configureProducer.
+func newSaramaConfig(cmd *cobra.Command, cluster *clusterConfig) (*sarama.Config, error) {
+	config := sarama.NewConfig()
+
+	var err error
+	if config.ClientID, err = getClusterSetting(cmd, cluster, clientID, getViperString, parseString); err != nil {
+		return nil, err
+	}
+
+	config.Version, err = getClusterSetting(cmd, cluster, kafkaVersion, func(flag string) (sarama.KafkaVersion, error) {
+		version := viper.GetString(flag)
+		if version == "" {
+			return sarama.DefaultVersion, nil
+		}
+		parsed, err := sarama.ParseKafkaVersion(version)
+		if err != nil {
+			return parsed, fmt.Errorf("invalid --%s: %w", flag, err)
+		}
+		return parsed, nil
+	}, sarama.ParseKafkaVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := configureSecurity(cmd, config, cluster); err != nil {
+		return nil, err
+	}
+	if err := configureConsumer(config, cluster); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// configureConsumer tunes the consumers with the consumer settings of the
+// cluster, keeping the sarama defaults of the settings not configured.
+func configureConsumer(config *sarama.Config, cluster *clusterConfig) error {
+	if err := setClusterSetting(cluster, fetchMinBytes, &config.Consumer.Fetch.Min, parseInt32); err != nil {
+		return err
+	}
+	if err := setClusterSetting(cluster, fetchMaxBytes, &config.Consumer.Fetch.Max, parseInt32); err != nil {
+		return err
+	}
+	if err := setClusterSetting(cluster, maxPartitionFetchBytes, &config.Consumer.Fetch.Default, parseInt32); err != nil {
+		return err
+	}
+	if err := setClusterSetting(cluster, fetchMaxWait, &config.Consumer.MaxWaitTime, time.ParseDuration); err != nil {
+		return err
+	}
+	if err := setClusterSetting(cluster, sessionTimeout, &config.Consumer.Group.Session.Timeout, time.ParseDuration); err != nil {
+		return err
+	}
+	if err := setClusterSetting(cluster, heartbeatInterval, &config.Consumer.Group.Heartbeat.Interval, time.ParseDuration); err != nil {
+		return err
+	}
+	return setClusterSetting(cluster, rebalanceStrategy, &config.Consumer.Group.Rebalance.GroupStrategies, func(name string) ([]sarama.BalanceStrategy, error) {
+		strategy, err := kafkautils.ParseBalanceStrategy(name)
+		if err != nil {
+			return nil, err
+		}
+		return []sarama.BalanceStrategy{strategy}, nil
+	})
+}
+
+// getClusterSetting returns the value of the flag given in the command line,
+// or, if not given, the value configured for the cluster parsed by parse, or
+// the default value of the flag if neither is given.
+func getClusterSetting[T any](cmd *cobra.Command, config *clusterConfig, flag string, get func(string) (T, error), parse func(string) (T, error)) (T, error) {
+	if value, configured := config.settings[flag]; configured && !cmd.Flags().Changed(flag) {
+		parsed, err := parse(fmt.Sprint(value))
+		if err != nil {
+			return parsed, fmt.Errorf("invalid %s configuration: cluster '%s': %s: %w", clusters, config.name, flag, err)
+		}
+		return parsed, nil
+	}
+	return get(flag)
+}
+
+// setClusterSetting sets the target to the value of the setting configured
+// for the cluster, parsed by parse, leaving it unchanged if not configured.
+func setClusterSetting[T any](config *clusterConfig, setting string, target *T, parse func(string) (T, error)) error {
+	value, configured := config.settings[setting]
+	if !configured {
+		return nil
+	}
+	parsed, err := parse(fmt.Sprint(value))
+	if err != nil {
+		return fmt.Errorf("invalid %s configuration: cluster '%s': %s: %w", clusters, config.name, setting, err)
+	}
+	*target = parsed
+	return nil
+}
+
+// parseString is the parse function of getClusterSetting for string flags.
+func parseString(value string) (string, error) {
+	return value, nil
+}
+
+// parseInt32 is the parse function of setClusterSetting for int32 settings.
+func parseInt32(value string) (int32, error) {
+	parsed, err := strconv.ParseInt(value, 10, 32)
+	return int32(parsed), err
+}
+
+// getViperString and getViperBool are the get functions of getClusterSetting
+// for the flags bound to viper, which can also be given by environment
+// variables and by the top level settings of the configuration file.
+func getViperString(flag string) (string, error) {
+	return viper.GetString(flag), nil
+}
+
+func getViperBool(flag string) (bool, error) {
+	return viper.GetBool(flag), nil
+}
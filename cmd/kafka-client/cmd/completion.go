@@ -79,14 +79,14 @@ func completeProtoFile(cmd *cobra.Command, args []string, toComplete string) ([]
 func completeProto(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 	viper.BindPFlag(importPath, cmd.Flags().Lookup(importPath))
 	viper.BindPFlag(protoFile, cmd.Flags().Lookup(protoFile))
+	viper.BindPFlag(descriptorSet, cmd.Flags().Lookup(descriptorSet))
 
-	importPath := viper.GetStringSlice(importPath)
-	cobra.CompDebugln(fmt.Sprintf("using import-path: %s", strings.Join(importPath, ", ")), true)
-
-	protoFile := viper.GetStringSlice(protoFile)
-	cobra.CompDebugln(fmt.Sprintf("using proto-file: %s", strings.Join(protoFile, ", ")), true)
+	sources := getProtoSources(cmd)
+	cobra.CompDebugln(fmt.Sprintf("using import-path: %s", strings.Join(sources.ImportPaths, ", ")), true)
+	cobra.CompDebugln(fmt.Sprintf("using proto-file: %s", strings.Join(sources.ProtoFiles, ", ")), true)
+	cobra.CompDebugln(fmt.Sprintf("using descriptor-set: %s", strings.Join(sources.DescriptorSets, ", ")), true)
 
-	protoMessageTypes, err := protoutils.ProtoMessageTypes(cmd.Context(), protoFile, importPath)
+	protoMessageTypes, err := protoutils.ProtoMessageTypes(cmd.Context(), sources)
 	if err != nil {
 		cobra.CompErrorln(err.Error())
 		return nil, cobra.ShellCompDirectiveError
@@ -96,6 +96,21 @@ func completeProto(cmd *cobra.Command, args []string, toComplete string) ([]stri
 	return sliceutils.FilterSlice(protoMessageTypes, sliceutils.HasPrefix(toComplete)), cobra.ShellCompDirectiveDefault
 }
 
+func completeCodecFormat(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	// Complete the protobuf message type of proto:<Type> formats
+	if messageType, found := strings.CutPrefix(toComplete, "proto:"); found {
+		protoMessageTypes, directive := completeProto(cmd, args, messageType)
+		formats := make([]string, 0, len(protoMessageTypes))
+		for _, protoMessageType := range protoMessageTypes {
+			formats = append(formats, "proto:"+protoMessageType)
+		}
+		return formats, directive
+	}
+
+	formats := []string{"text", "utf8", "raw", "base64", "hex", "int64", "uuid", "proto", "proto:", "proto-registry", "proto-registry:", "proto-raw", "avro", "avro:"}
+	return sliceutils.FilterSlice(formats, sliceutils.HasPrefix(toComplete)), cobra.ShellCompDirectiveNoFileComp
+}
+
 func completeClustersAndTopic(nArgs int) func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 		normArgs := colonWorkarround(args)
@@ -124,20 +139,24 @@ func completeClusters(cmd *cobra.Command, args []string, toComplete string) ([]s
 
 func completeTopic(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 	// Get the Kafka client
-	clientID := viper.GetString(clientID)
-	config := sarama.NewConfig()
+	cluster, err := getClusterConfig(args[len(args)-1])
+	if err != nil {
+		cobra.CompErrorln(err.Error())
+		return nil, cobra.ShellCompDirectiveError
+	}
+	config, err := newSaramaConfig(cmd, cluster)
+	if err != nil {
+		cobra.CompErrorln(err.Error())
+		return nil, cobra.ShellCompDirectiveError
+	}
 	config.Net.DialTimeout = 500 * time.Millisecond
 	config.Net.ReadTimeout = 500 * time.Millisecond
 	config.Net.WriteTimeout = 500 * time.Millisecond
 	config.Metadata.Timeout = 500 * time.Millisecond
 	config.Metadata.Retry.Max = 0
-	config.ClientID = clientID
 
 	cobra.CompDebugln("Connecting to Kafka cluster", true)
-	client, err := sarama.NewClient(
-		strings.Split(resolveCluster(args[len(args)-1]), ","),
-		config,
-	)
+	client, err := sarama.NewClient(cluster.brokerList(), config)
 	if err != nil {
 		cobra.CompErrorln(err.Error())
 		return nil, cobra.ShellCompDirectiveError
@@ -155,10 +174,3 @@ func completeTopic(cmd *cobra.Command, args []string, toComplete string) ([]stri
 	filter := notInternalTopics.And(sliceutils.HasPrefix(toComplete))
 	return sliceutils.FilterSlice(availableTopics, filter), cobra.ShellCompDirectiveDefault
 }
-
-func resolveCluster(cluster string) string {
-	if hosts, ok := viper.GetStringMapString(clusters)[cluster]; ok && hosts != "" {
-		return hosts
-	}
-	return cluster
-}
@@ -0,0 +1,194 @@
+/*
+Copyright © 2023 VECI Group Tech S.L.
+This file is part of kafka-client.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	configShort = "Lists, shows and validates the cluster profiles."
+	configLong  = `config command inspects the profiles of the clusters configured in the
+clusters section of the configuration file, given by the --config flag or
+$HOME/.kafka-client.yaml by default.`
+
+	configListShort = "Lists the configured clusters and their brokers."
+	configShowShort = "Shows the profile of a cluster, hiding its secrets."
+	configShowLong  = `show command prints the profile of the given cluster as configured in the
+configuration file, with its password hidden.`
+	configValidateShort = "Validates the profiles of the clusters."
+	configValidateLong  = `validate command checks the settings and topics of the profiles of the given
+clusters, or of every configured cluster if none is given, building the
+configuration of their clients without connecting to them.`
+)
+
+// configCmd represents the config command
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: configShort,
+	Long:  configLong,
+}
+
+// configListCmd represents the config list command
+var configListCmd = &cobra.Command{
+	Use:   "list",
+	Short: configListShort,
+	Args:  cobra.NoArgs,
+	RunE:  configList,
+}
+
+// configShowCmd represents the config show command
+var configShowCmd = &cobra.Command{
+	Use:               "show cluster",
+	Short:             configShowShort,
+	Long:              configShowLong,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeConfiguredClusters(1),
+	RunE:              configShow,
+}
+
+// configValidateCmd represents the config validate command
+var configValidateCmd = &cobra.Command{
+	Use:               "validate [cluster...]",
+	Short:             configValidateShort,
+	Long:              configValidateLong,
+	ValidArgsFunction: completeConfiguredClusters(-1),
+	RunE:              configValidate,
+}
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configListCmd, configShowCmd, configValidateCmd)
+}
+
+// configuredClusters returns the sorted names of the configured clusters.
+func configuredClusters() []string {
+	return slices.Sorted(maps.Keys(viper.GetStringMap(clusters)))
+}
+
+func completeConfiguredClusters(maxArgs int) cobra.CompletionFunc {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if maxArgs >= 0 && len(args) >= maxArgs {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		clusters, _ := completeClusters(cmd, args, toComplete)
+		return clusters, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+func configList(cmd *cobra.Command, args []string) error {
+	names := configuredClusters()
+	if len(names) == 0 {
+		logger.Printf("no clusters configured")
+		return nil
+	}
+
+	writer := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	for _, name := range names {
+		cluster, err := getClusterConfig(name)
+		if err != nil {
+			fmt.Fprintf(writer, "%s\tinvalid: %v\n", name, err)
+			continue
+		}
+		fmt.Fprintf(writer, "%s\t%s\n", name, cluster.brokers)
+	}
+	return writer.Flush()
+}
+
+func configShow(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+
+	if !slices.Contains(configuredClusters(), args[0]) {
+		return fmt.Errorf("cluster '%s' is not configured", args[0])
+	}
+	cluster, err := getClusterConfig(args[0])
+	if err != nil {
+		return err
+	}
+
+	// Show the profile as configured, hiding the secrets
+	profile := map[string]any{brokers: cluster.brokers}
+	for setting, value := range cluster.settings {
+		if slices.Contains(secretSettings, setting) {
+			value = "****"
+		}
+		profile[setting] = value
+	}
+	if len(cluster.topics) > 0 {
+		profile[topicsConfig] = cluster.topics
+	}
+
+	encoder := yaml.NewEncoder(cmd.OutOrStdout())
+	encoder.SetIndent(2)
+	if err := encoder.Encode(map[string]any{cluster.name: profile}); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+func configValidate(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+
+	names := args
+	if len(names) == 0 {
+		names = configuredClusters()
+	}
+
+	invalid := 0
+	for _, name := range names {
+		if err := validateCluster(cmd, name); err != nil {
+			fmt.Fprintf(cmd.OutOrStdout(), "%s: %v\n", name, err)
+			invalid++
+			continue
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "%s: ok\n", name)
+	}
+
+	if invalid > 0 {
+		return fmt.Errorf("%d of %d clusters are invalid", invalid, len(names))
+	}
+	return nil
+}
+
+// validateCluster builds the configuration of the clients of the cluster and
+// applies its topics, returning the first invalid setting found.
+func validateCluster(cmd *cobra.Command, name string) error {
+	cluster, err := getClusterConfig(name)
+	if err != nil {
+		return err
+	}
+
+	config, err := newSaramaConfig(cmd, cluster)
+	if err != nil {
+		return err
+	}
+
+	// The producer settings and the topics are applied to commands having
+	// the producer and format flags, as this command doesn't have them
+	producerCmd := &cobra.Command{}
+	addProducerFlags(producerCmd)
+	if err := configureProducer(producerCmd, config, cluster); err != nil {
+		return err
+	}
+	for _, topic := range cluster.topics {
+		pattern, _ := topic["topic"].(string)
+		topicCmd := &cobra.Command{}
+		addFormatFlags(topicCmd)
+		addRedactFlags(topicCmd)
+		if err := applyTopicSettings(topicCmd, pattern, topic); err != nil {
+			return err
+		}
+	}
+
+	return config.Validate()
+}
@@ -12,6 +12,7 @@ import (
 
 	"github.com/bluekiri/kafka-client/internal/handlers"
 	"github.com/bluekiri/kafka-client/internal/ioutils"
+	"github.com/bluekiri/kafka-client/internal/kafkautils"
 	"github.com/bluekiri/kafka-client/internal/sliceutils"
 
 	"github.com/IBM/sarama"
@@ -45,25 +46,56 @@ func init() {
 	consumeCmd.Flags().StringP(output, "o", "", "write to file instead of stdout.")
 	consumeCmd.MarkFlagFilename(output)
 
+	consumeCmd.Flags().Bool(metadata, false, "write the partition, offset, timestamp and headers before every message. Not supported by the raw format nor by the json-multiline and binary proto encodings.")
+
+	consumeCmd.Flags().Int64(maxMessages, 0, "exit after consuming the given number of messages, counted before filtering them.")
+	consumeCmd.Flags().Bool(untilEnd, false, "exit once every partition reaches the last message it had when the consumption started.")
+
 	addFormatFlags(consumeCmd)
+	addFilterFlags(consumeCmd)
+	addRedactFlags(consumeCmd)
+	addStartFlags(consumeCmd)
+	addGroupFlags(consumeCmd)
+	consumeCmd.MarkFlagsMutuallyExclusive(group, untilEnd)
 }
 
 func consume(cmd *cobra.Command, args []string) error {
 	cmd.SilenceUsage = true
 
 	// Get the command arguments and flags
-	kafkaBrokers := strings.Split(resolveCluster(args[0]), ",")
+	cluster, err := getClusterConfig(args[0])
+	if err != nil {
+		return err
+	}
+	kafkaBrokers := cluster.brokerList()
 	kafkaTopic := args[1]
-	kafkaClientID := viper.GetString(clientID)
 	outputFilename, _ := cmd.Flags().GetString(output)
+	maxMessages, _ := cmd.Flags().GetInt64(maxMessages)
+	untilEnd, _ := cmd.Flags().GetBool(untilEnd)
 	duration := viper.GetDuration(duration)
 	reportingPeriod := time.Duration(1) * time.Second
 	if viper.GetBool(quiet) {
 		reportingPeriod = -1
 	}
 
+	// Get the start positions and partitions
+	startPositions, err := getStartPositions(cmd, kafkautils.Latest)
+	if err != nil {
+		return err
+	}
+	partitions, err := getPartitions(cmd)
+	if err != nil {
+		return err
+	}
+
 	// Get the formatter
-	formatter, err := getFormatter(cmd, outputFilename)
+	formatter, err := getFormatter(cmd, outputFilename, args[0], kafkaTopic)
+	if err != nil {
+		return err
+	}
+
+	// Get the filter
+	messageFilter, err := getFilter(cmd, kafkaTopic)
 	if err != nil {
 		return err
 	}
@@ -76,9 +108,12 @@ func consume(cmd *cobra.Command, args []string) error {
 	defer writer.Close()
 
 	// Kafka configuration
-	config := sarama.NewConfig()
-	config.ClientID = kafkaClientID
+	config, err := newSaramaConfig(cmd, cluster)
+	if err != nil {
+		return err
+	}
 	config.Consumer.Return.Errors = true
+	configureGroup(cmd, config)
 
 	// Get the Kafka client
 	client, err := sarama.NewClient(kafkaBrokers, config)
@@ -95,13 +130,22 @@ func consume(cmd *cobra.Command, args []string) error {
 	}
 
 	// Create the handlers
-	inputHandler, err := handlers.NewKafkaInputHandler(client, kafkaTopic)
+	inputHandler, err := newKafkaInputHandler(cmd, client, kafkaTopic, handlers.KafkaInputOptions{
+		Start:       startPositions,
+		Partitions:  partitions,
+		MaxMessages: maxMessages,
+		UntilEnd:    untilEnd,
+	})
 	if err != nil {
-		return nil
+		return err
 	}
-	outputHandler, err := handlers.NewFileOutputHandler(inputHandler.Messages(), formatter.NewWriter(writer))
+	filterHandler, err := handlers.NewFilterHandler(inputHandler.Messages(), messageFilter)
 	if err != nil {
-		return nil
+		return err
+	}
+	outputHandler, err := handlers.NewFileOutputHandler(filterHandler.Messages(), formatter.NewWriter(writer))
+	if err != nil {
+		return err
 	}
 	reportingHandler := handlers.NewReportingHandler(logger, reportingPeriod)
 
@@ -112,11 +156,14 @@ func consume(cmd *cobra.Command, args []string) error {
 	g, ctx := errgroup.WithContext(ctx)
 
 	// Start reporting goroutine
-	g.Go(reportingHandler.Start(inputHandler.Progress(), outputHandler.Progress()))
+	g.Go(reportingHandler.Start(inputHandler.Progress(), filterHandler.Progress(), outputHandler.Progress()))
 
 	// Start the output goroutine
 	g.Go(outputHandler.Run)
 
+	// Start the filter goroutine
+	g.Go(filterHandler.Start(ctx))
+
 	// Start the input goroutine
 	g.Go(inputHandler.Start(ctx))
 
@@ -126,11 +173,13 @@ func consume(cmd *cobra.Command, args []string) error {
 		logOutput = fmt.Sprintf(" to '%s'", outputFilename)
 	}
 	logger.Printf(
-		"consuming messages from cluster %s topic '%s'%s",
-		strings.Join(kafkaBrokers, ","), kafkaTopic,
+		"consuming messages from cluster %s topic '%s' %s%s",
+		strings.Join(kafkaBrokers, ","), kafkaTopic, describeStart(cmd, startPositions),
 		logOutput,
 	)
-	logger.Printf("press ctrl-c to exit")
+	if !untilEnd && maxMessages <= 0 {
+		logger.Printf("press ctrl-c to exit")
+	}
 
 	// Return the error group error
 	return adaptError(g.Wait())
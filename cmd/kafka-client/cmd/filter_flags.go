@@ -0,0 +1,41 @@
+/*
+Copyright © 2023 VECI Group Tech S.L.
+This file is part of kafka-client.
+*/
+
+package cmd
+
+import (
+	"github.com/bluekiri/kafka-client/internal/filters"
+
+	"github.com/spf13/cobra"
+)
+
+func addFilterFlags(cmd *cobra.Command) {
+	cmd.Flags().String(filter, "", "handle only the messages for which the given CEL expression is true (e.g. 'value.status == \"FAILED\" && headers[\"tenant\"] == \"x\"'). The expression can use key, value, headers, partition, offset and timestamp. Keys and values are decoded using --key-format and the value format.")
+}
+
+// getFilter returns the filter given by the --filter flag, or nil if no
+// filter is given. The filter decodes the keys using the key format and the
+// values using the value format.
+func getFilter(cmd *cobra.Command, topic string) (filters.Filter, error) {
+	expression, _ := cmd.Flags().GetString(filter)
+	if expression == "" {
+		return nil, nil
+	}
+	if err := bindFlags(cmd); err != nil {
+		return nil, err
+	}
+
+	keyFormat, _ := cmd.Flags().GetString(keyFormat)
+	keyCodec, err := getCodec(cmd, keyFormat, keySubject(topic))
+	if err != nil {
+		return nil, err
+	}
+	valueCodec, err := getCodec(cmd, getValueFormat(cmd), valueSubject(topic))
+	if err != nil {
+		return nil, err
+	}
+
+	return filters.NewExpressionFilter(expression, keyCodec, valueCodec)
+}
@@ -16,7 +16,90 @@ const (
 	formatRaw   = "raw"
 	formatText  = "text"
 	formatProto = "proto"
+	formatJSON  = "json"
+	keyFormat   = "key-format"
+	valueFormat = "value-format"
 	importPath  = "import-path"
 	protoFile   = "proto-file"
 	clusters    = "clusters"
+
+	partitions    = "partitions"
+	fromBeginning = "from-beginning"
+	startOffset   = "offset"
+	fromTime      = "from-time"
+	fromRelative  = "from-relative"
+	maxMessages   = "max-messages"
+	untilEnd      = "until-end"
+	group         = "group"
+	metadata      = "metadata"
+	keepTimestamp = "keep-timestamp"
+	keySeparator  = "key-separator"
+
+	formatAvro          = "avro"
+	avroSchema          = "avro-schema"
+	schemaRegistry      = "schema-registry"
+	registryTimeout     = "schema-registry-timeout"
+	formatProtoRegistry = "proto-registry"
+	descriptorSet       = "descriptor-set"
+	topicsConfig        = "topics"
+	formatProtoRaw      = "proto-raw"
+	protoEncoding       = "proto-encoding"
+	emitUnpopulated     = "emit-unpopulated"
+	useProtoNames       = "use-proto-names"
+	useEnumNumbers      = "use-enum-numbers"
+	filter              = "filter"
+	setKey              = "set-key"
+	addHeader           = "add-header"
+	removeHeader        = "remove-header"
+	renameHeader        = "rename-header"
+	renameField         = "rename-field"
+	mask                = "mask"
+	toValueFormat       = "to-value-format"
+	redactFields        = "redact"
+	redactPolicy        = "redact-policy"
+	redactHashKey       = "redact-hash-key"
+	untilOffset         = "until-offset"
+	untilTime           = "until-time"
+	untilRelative       = "until-relative"
+	searchPath          = "jsonpath"
+	searchIn            = "in"
+	ignoreCase          = "ignore-case"
+	partition           = "partition"
+	partitioner         = "partitioner"
+	compression         = "compression"
+	acks                = "acks"
+	idempotent          = "idempotent"
+	maxMessageBytes     = "max-message-bytes"
+	batchBytes          = "batch-bytes"
+	batchMessages       = "batch-messages"
+	linger              = "linger"
+	exactlyOnce         = "exactly-once"
+	commitInterval      = "commit-interval"
+	transactionalID     = "transactional-id"
+	tlsEnable           = "tls"
+	tlsCA               = "tls-ca"
+	tlsCert             = "tls-cert"
+	tlsKey              = "tls-key"
+	tlsInsecure         = "tls-insecure-skip-verify"
+	saslMechanism       = "sasl-mechanism"
+	saslUsername        = "sasl-username"
+	saslPassword        = "sasl-password"
+	saslTokenFile       = "sasl-token-file"
+	saslTokenCommand    = "sasl-token-command"
+	kafkaVersion        = "kafka-version"
+
+	brokers                = "brokers"
+	fetchMinBytes          = "fetch-min-bytes"
+	fetchMaxBytes          = "fetch-max-bytes"
+	maxPartitionFetchBytes = "max-partition-fetch-bytes"
+	fetchMaxWait           = "fetch-max-wait"
+	sessionTimeout         = "session-timeout"
+	heartbeatInterval      = "heartbeat-interval"
+	rebalanceStrategy      = "rebalance-strategy"
+
+	internalTopics    = "internal"
+	allConfigs        = "all-configs"
+	replicationFactor = "replication-factor"
+	setConfig         = "set"
+	unsetConfig       = "unset"
 )
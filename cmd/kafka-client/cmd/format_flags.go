@@ -0,0 +1,467 @@
+/*
+Copyright © 2023 VECI Group Tech S.L.
+This file is part of kafka-client.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/bluekiri/kafka-client/internal/formatters"
+
+	"github.com/spf13/cobra"
+)
+
+// codecFormats lists the formats of keys and values accepted by getCodec.
+const codecFormats = "text, utf8, raw, base64, hex, int64, uuid, proto, proto:<Type>, proto-registry, proto-registry:<Type>, proto-raw, avro or avro:<file.avsc>"
+
+func addFormatFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolP(formatRaw, "r", false, "write the message as raw bytes (default true if an output file is given).")
+	cmd.Flags().BoolP(formatText, "t", false, "write the message as text (default true if no output file is given).")
+	cmd.Flags().String(formatProto, "", "write the message as JSON using the given protobuf message type.")
+	cmd.Flags().Bool(formatProtoRegistry, false, "write the message value as JSON using the protobuf schema fetched from --schema-registry and the message type given by the wire format, or by --proto when producing.")
+	cmd.Flags().Bool(formatProtoRaw, false, "write the message value, a protobuf message of unknown type, as JSON keyed by field number, like protoc --decode_raw. Messages can't be read in this format.")
+	cmd.Flags().Bool(formatAvro, false, "write the message value as JSON using its Avro schema, given by --avro-schema or fetched from --schema-registry.")
+	cmd.Flags().Bool(formatJSON, false, "write the message as a JSON envelope holding its key, value, headers, partition, offset and timestamp.")
+	cmd.Flags().String(keySeparator, "", "write the key before the value, separated by the given string (e.g. \\t), in the text, proto and avro formats.")
+	cmd.Flags().String(keyFormat, "text", "format of the key in the JSON envelope or with --key-separator: "+codecFormats+".")
+	cmd.Flags().String(valueFormat, "", "format of the value in the text and JSON formats: "+codecFormats+" (default proto-registry if --proto-registry is given, proto-raw if --proto-raw is given, proto if --proto is given, avro if --avro is given, text otherwise).")
+
+	cmd.Flags().String(protoEncoding, "json", "encoding of the messages in the proto and proto-registry formats: json, json-multiline, text (protobuf text format) or binary (varint length delimited).")
+	cmd.Flags().Bool(emitUnpopulated, true, "write the fields with default values in the JSON encodings of the proto and proto-registry formats.")
+	cmd.Flags().Bool(useProtoNames, false, "write the field names of the proto files instead of their lowerCamelCase names in the JSON encodings of the proto and proto-registry formats.")
+	cmd.Flags().Bool(useEnumNumbers, false, "write enum values as numbers instead of names in the JSON encodings of the proto and proto-registry formats.")
+
+	addSchemaFlags(cmd)
+
+	cmd.RegisterFlagCompletionFunc(formatProto, wrapCompletion(completeProto, bindFlags))
+	cmd.RegisterFlagCompletionFunc(keyFormat, wrapCompletion(completeCodecFormat, bindFlags))
+	cmd.RegisterFlagCompletionFunc(valueFormat, wrapCompletion(completeCodecFormat, bindFlags))
+	cmd.RegisterFlagCompletionFunc(protoEncoding, cobra.FixedCompletions(protoEncodings, cobra.ShellCompDirectiveNoFileComp))
+}
+
+// getFormatter returns the formatter requested by the format flags, or by the
+// topics configuration, for the messages of the given cluster and topic.
+func getFormatter(cmd *cobra.Command, filename string, cluster string, topic string) (formatters.Formatter, error) {
+	// Bind the flags of this command, as the flags of every command using
+	// formatters were bound when created
+	if err := bindFlags(cmd); err != nil {
+		return nil, err
+	}
+	if err := applyTopicConfig(cmd, cluster, topic); err != nil {
+		return nil, err
+	}
+
+	formatter, err := getMessageFormatter(cmd, filename, cluster, topic)
+	if err != nil {
+		return nil, err
+	}
+
+	// Wrap the formatter to write the metadata if requested
+	if withMetadata, _ := cmd.Flags().GetBool(metadata); withMetadata {
+		if isRawFormat(cmd, filename) {
+			return nil, fmt.Errorf("--%s can't be used with the raw format", metadata)
+		}
+		if encoding := multilineEncoding(cmd); encoding != "" {
+			return nil, fmt.Errorf("--%s can't be used with the %s encoding", metadata, encoding)
+		}
+		return formatters.WithMetadata(formatter), nil
+	}
+
+	return formatter, nil
+}
+
+func isRawFormat(cmd *cobra.Command, filename string) bool {
+	raw, _ := cmd.Flags().GetBool(formatRaw)
+	text, _ := cmd.Flags().GetBool(formatText)
+	jsonEnvelope, _ := cmd.Flags().GetBool(formatJSON)
+	avro, _ := cmd.Flags().GetBool(formatAvro)
+	protoRegistry, _ := cmd.Flags().GetBool(formatProtoRegistry)
+	protoRaw, _ := cmd.Flags().GetBool(formatProtoRaw)
+	messageFullName, _ := cmd.Flags().GetString(formatProto)
+	return raw || (!text && !jsonEnvelope && !avro && !protoRegistry && !protoRaw && len(messageFullName) == 0 && len(filename) > 0)
+}
+
+// multilineEncoding returns the proto encoding of the messages if they are
+// written in a proto format by an encoding not writing every message in a
+// single line, so the messages can't be prefixed by their metadata, or an
+// empty string otherwise.
+func multilineEncoding(cmd *cobra.Command) string {
+	jsonEnvelope, _ := cmd.Flags().GetBool(formatJSON)
+	protoRegistry, _ := cmd.Flags().GetBool(formatProtoRegistry)
+	messageFullName, _ := cmd.Flags().GetString(formatProto)
+	if !protoRegistry && (len(messageFullName) == 0 || jsonEnvelope) {
+		return ""
+	}
+	if encoding, _ := cmd.Flags().GetString(protoEncoding); encoding == "json-multiline" || encoding == "binary" {
+		return encoding
+	}
+	return ""
+}
+
+func getMessageFormatter(cmd *cobra.Command, filename string, cluster string, topic string) (formatters.Formatter, error) {
+	raw, _ := cmd.Flags().GetBool(formatRaw)
+	text, _ := cmd.Flags().GetBool(formatText)
+	jsonEnvelope, _ := cmd.Flags().GetBool(formatJSON)
+	avro, _ := cmd.Flags().GetBool(formatAvro)
+	protoRegistry, _ := cmd.Flags().GetBool(formatProtoRegistry)
+	protoRaw, _ := cmd.Flags().GetBool(formatProtoRaw)
+	messageFullName, _ := cmd.Flags().GetString(formatProto)
+
+	// Ensure only one format is given. The JSON format uses the protobuf
+	// message type or the Avro schema to represent the value, so --proto,
+	// --proto-registry, --proto-raw and --avro are not formats then. The proto-registry
+	// format uses --proto as the message type to produce.
+	nFormats := 0
+	if raw {
+		nFormats++
+	}
+	if text {
+		nFormats++
+	}
+	if jsonEnvelope {
+		nFormats++
+	}
+	if len(messageFullName) > 0 && !jsonEnvelope && !protoRegistry {
+		nFormats++
+	}
+	if protoRegistry && !jsonEnvelope {
+		nFormats++
+	}
+	if protoRaw && !jsonEnvelope {
+		nFormats++
+	}
+	if avro && !jsonEnvelope {
+		nFormats++
+	}
+	nValueFormats := 0
+	for _, valueFormat := range []bool{len(messageFullName) > 0 && !protoRegistry, protoRegistry, protoRaw, avro} {
+		if valueFormat {
+			nValueFormats++
+		}
+	}
+	if nFormats > 1 || nValueFormats > 1 {
+		return nil, fmt.Errorf("too many formats, expected only one format: raw, text, json, proto, proto-registry, proto-raw or avro")
+	}
+
+	// Keys are written by the text and proto formats only if a key separator
+	// is given
+	keyOptions, err := getKeyOptions(cmd, topic)
+	if err != nil {
+		return nil, err
+	}
+	if cmd.Flags().Changed(keySeparator) && (raw || jsonEnvelope) {
+		return nil, fmt.Errorf("--%s can only be used with the text, proto, proto-registry, proto-raw and avro formats", keySeparator)
+	}
+
+	// The key format is only used by the JSON format, with a key separator or
+	// to decode the messages and the value format by the text and JSON formats
+	// or to decode the messages
+	if cmd.Flags().Changed(keyFormat) && !jsonEnvelope && keyOptions.KeySeparator == "" && !decodesMessages(cmd) {
+		return nil, fmt.Errorf("--%s can only be used with --%s, --%s, --%s or the transform flags", keyFormat, formatJSON, keySeparator, filter)
+	}
+	if cmd.Flags().Changed(valueFormat) && !jsonEnvelope && !text && !decodesMessages(cmd) {
+		return nil, fmt.Errorf("--%s can only be used with --%s, --%s, --%s or the transform flags", valueFormat, formatText, formatJSON, filter)
+	}
+
+	// The protobuf encoding options are only used by the proto formats
+	isProtoFormat := protoRegistry || (len(messageFullName) > 0 && !jsonEnvelope)
+	for _, flag := range []string{protoEncoding, emitUnpopulated, useProtoNames, useEnumNumbers} {
+		if cmd.Flags().Changed(flag) && !isProtoFormat {
+			return nil, fmt.Errorf("--%s can only be used with --%s or --%s", flag, formatProto, formatProtoRegistry)
+		}
+	}
+
+	// The values are redacted when written, so raw values can't be redacted
+	redactions, err := getRedactFields(cmd)
+	if err != nil {
+		return nil, err
+	}
+	redactor, err := newRedactor(redactions)
+	if err != nil {
+		return nil, err
+	}
+	if redactor != nil && isRawFormat(cmd, filename) {
+		return nil, fmt.Errorf("the raw format can't be redacted, use a format decoding the values")
+	}
+
+	// Return the requested Formatter
+	rawHeader := formatters.RawHeader{
+		Cluster: cluster,
+		Topic:   topic,
+	}
+	if raw {
+		return formatters.NewRawFormatter(rawHeader), nil
+	}
+
+	if text {
+		valueFormat, _ := cmd.Flags().GetString(valueFormat)
+		valueCodec, err := getCodec(cmd, valueFormat, valueSubject(topic))
+		if err != nil {
+			return nil, err
+		}
+
+		return formatters.NewTextFormatter(formatters.TextOptions{
+			KeyOptions: keyOptions,
+			ValueCodec: formatters.NewRedactingCodec(valueCodec, redactor),
+		}), nil
+	}
+
+	if jsonEnvelope {
+		keyFormat, _ := cmd.Flags().GetString(keyFormat)
+		keyCodec, err := getCodec(cmd, keyFormat, keySubject(topic))
+		if err != nil {
+			return nil, err
+		}
+
+		valueCodec, err := getCodec(cmd, getValueFormat(cmd), valueSubject(topic))
+		if err != nil {
+			return nil, err
+		}
+
+		return formatters.NewJSONFormatter(keyCodec, formatters.NewRedactingCodec(valueCodec, redactor)), nil
+	}
+
+	if protoRegistry {
+		client, err := getRegistryClient(cmd)
+		if err != nil {
+			return nil, err
+		}
+
+		protoOptions, err := getProtoOptions(cmd, keyOptions)
+		if err != nil {
+			return nil, err
+		}
+		protoOptions.Redactor = redactor
+
+		return formatters.NewRegistryProtoFormatter(cmd.Context(), client, valueSubject(topic), messageFullName, protoOptions), nil
+	}
+
+	if len(messageFullName) > 0 {
+		messageType, err := resolveProtoMessageType(cmd, messageFullName)
+		if err != nil {
+			return nil, err
+		}
+
+		protoOptions, err := getProtoOptions(cmd, keyOptions)
+		if err != nil {
+			return nil, err
+		}
+		protoOptions.Redactor = redactor
+
+		return formatters.NewProtoFormatter(messageType, protoOptions), nil
+	}
+
+	// The proto-raw format is the text format with raw protobuf values
+	if protoRaw {
+		return formatters.NewTextFormatter(formatters.TextOptions{
+			KeyOptions: keyOptions,
+			ValueCodec: formatters.NewRedactingCodec(formatters.ProtoRawCodec, redactor),
+		}), nil
+	}
+
+	// The avro format is the text format with Avro values
+	if avro {
+		valueCodec, err := getCodec(cmd, "avro", valueSubject(topic))
+		if err != nil {
+			return nil, err
+		}
+
+		return formatters.NewTextFormatter(formatters.TextOptions{
+			KeyOptions: keyOptions,
+			ValueCodec: formatters.NewRedactingCodec(valueCodec, redactor),
+		}), nil
+	}
+
+	// If no formatter is requested return raw if filename is given or text otherwise
+	if len(filename) > 0 {
+		return formatters.NewRawFormatter(rawHeader), nil
+	}
+
+	return formatters.NewTextFormatter(formatters.TextOptions{
+		KeyOptions: keyOptions,
+		ValueCodec: formatters.NewRedactingCodec(formatters.TextCodec, redactor),
+	}), nil
+}
+
+// protoEncodings lists the encodings accepted by getProtoOptions.
+var protoEncodings = []string{"json", "json-multiline", "text", "binary"}
+
+// decodesMessages reports whether the filter, the transforms or the search
+// decode the keys and values using the key and value formats.
+func decodesMessages(cmd *cobra.Command) bool {
+	// The search command always decodes the messages
+	if cmd.Flags().Lookup(searchIn) != nil {
+		return true
+	}
+	for _, flag := range []string{filter, setKey, renameField, mask, toValueFormat} {
+		if cmd.Flags().Changed(flag) {
+			return true
+		}
+	}
+	return false
+}
+
+// getValueFormat returns the format of the values given by --value-format or,
+// if not given, by the --proto-registry, --proto-raw, --proto and --avro flags.
+func getValueFormat(cmd *cobra.Command) string {
+	if valueFormat, _ := cmd.Flags().GetString(valueFormat); valueFormat != "" {
+		return valueFormat
+	}
+
+	messageFullName, _ := cmd.Flags().GetString(formatProto)
+	if protoRegistry, _ := cmd.Flags().GetBool(formatProtoRegistry); protoRegistry {
+		if len(messageFullName) > 0 {
+			return "proto-registry:" + messageFullName
+		}
+		return "proto-registry"
+	}
+	if protoRaw, _ := cmd.Flags().GetBool(formatProtoRaw); protoRaw {
+		return "proto-raw"
+	}
+	if len(messageFullName) > 0 {
+		return "proto"
+	}
+	if avro, _ := cmd.Flags().GetBool(formatAvro); avro {
+		return "avro"
+	}
+	return "text"
+}
+
+// getProtoOptions returns the options of the proto and proto-registry formats.
+func getProtoOptions(cmd *cobra.Command, keyOptions formatters.KeyOptions) (formatters.ProtoOptions, error) {
+	emitUnpopulated, _ := cmd.Flags().GetBool(emitUnpopulated)
+	useProtoNames, _ := cmd.Flags().GetBool(useProtoNames)
+	useEnumNumbers, _ := cmd.Flags().GetBool(useEnumNumbers)
+	options := formatters.ProtoOptions{
+		KeyOptions:      keyOptions,
+		OmitUnpopulated: !emitUnpopulated,
+		UseProtoNames:   useProtoNames,
+		UseEnumNumbers:  useEnumNumbers,
+	}
+
+	encoding, _ := cmd.Flags().GetString(protoEncoding)
+	switch encoding {
+	case "json":
+		options.Encoding = formatters.ProtoJSON
+	case "json-multiline":
+		options.Encoding = formatters.ProtoMultilineJSON
+	case "text":
+		options.Encoding = formatters.ProtoText
+	case "binary":
+		options.Encoding = formatters.ProtoBinary
+	default:
+		return formatters.ProtoOptions{}, fmt.Errorf("unknown --%s '%s', expected %s", protoEncoding, encoding, strings.Join(protoEncodings, ", "))
+	}
+
+	// Keys are only written by the line oriented encodings
+	if keyOptions.KeySeparator != "" && (options.Encoding == formatters.ProtoMultilineJSON || options.Encoding == formatters.ProtoBinary) {
+		return formatters.ProtoOptions{}, fmt.Errorf("--%s can't be used with the %s encoding", keySeparator, encoding)
+	}
+
+	return options, nil
+}
+
+// getKeyOptions returns the key separator and format of the text, proto,
+// proto-registry, proto-raw and avro formats. The separator may contain escape sequences such as \t.
+func getKeyOptions(cmd *cobra.Command, topic string) (formatters.KeyOptions, error) {
+	separator, _ := cmd.Flags().GetString(keySeparator)
+	if unquoted, err := strconv.Unquote(`"` + separator + `"`); err == nil {
+		separator = unquoted
+	}
+	if separator == "" {
+		return formatters.KeyOptions{}, nil
+	}
+
+	keyFormat, _ := cmd.Flags().GetString(keyFormat)
+	keyCodec, err := getCodec(cmd, keyFormat, keySubject(topic))
+	if err != nil {
+		return formatters.KeyOptions{}, err
+	}
+
+	return formatters.KeyOptions{
+		KeySeparator: separator,
+		KeyCodec:     keyCodec,
+	}, nil
+}
+
+// getCodec returns the codec used to represent a key or a value in the given
+// format. The proto format uses the protobuf message type given by --proto
+// while the proto:<Type> format uses the given protobuf message type.
+//
+// The proto-registry format uses the schema registry, which resolves the
+// schemas to produce by the given subject. The message type to produce is the
+// first one of the schema or, in the proto-registry:<Type> format, the given
+// one.
+//
+// The avro format uses the schema file given by --avro-schema or, if not
+// given, the schema registry, which resolves the schemas to produce by the
+// given subject. The avro:<file.avsc> format uses the given schema file.
+func getCodec(cmd *cobra.Command, format string, subject string) (formatters.Codec, error) {
+	switch format {
+	case "", "text", "utf8", "raw":
+		return formatters.TextCodec, nil
+	case "base64":
+		return formatters.Base64Codec, nil
+	case "hex":
+		return formatters.HexCodec, nil
+	case "int64":
+		return formatters.Int64Codec, nil
+	case "uuid":
+		return formatters.UUIDCodec, nil
+	case "proto-raw":
+		return formatters.ProtoRawCodec, nil
+	}
+
+	if messageFullName, found := strings.CutPrefix(format, "proto-registry"); found {
+		if messageFullName, found = strings.CutPrefix(messageFullName, ":"); !found && messageFullName != "" {
+			return nil, fmt.Errorf("unknown format '%s'", format)
+		}
+
+		client, err := getRegistryClient(cmd)
+		if err != nil {
+			return nil, err
+		}
+		return formatters.NewRegistryProtoCodec(cmd.Context(), client, subject, messageFullName), nil
+	}
+
+	if messageFullName, found := strings.CutPrefix(format, "proto"); found {
+		if messageFullName == "" {
+			messageFullName, _ = cmd.Flags().GetString(formatProto)
+			if len(messageFullName) == 0 {
+				return nil, fmt.Errorf("the proto format requires the protobuf message type given by --%s", formatProto)
+			}
+		} else if messageFullName, found = strings.CutPrefix(messageFullName, ":"); !found {
+			return nil, fmt.Errorf("unknown format '%s'", format)
+		}
+
+		messageType, err := resolveProtoMessageType(cmd, messageFullName)
+		if err != nil {
+			return nil, err
+		}
+		return formatters.NewProtoCodec(messageType), nil
+	}
+
+	if schemaFile, found := strings.CutPrefix(format, "avro"); found {
+		if schemaFile == "" {
+			schemaFile, _ = cmd.Flags().GetString(avroSchema)
+			if len(schemaFile) == 0 {
+				return getRegistryAvroCodec(cmd, subject)
+			}
+		} else if schemaFile, found = strings.CutPrefix(schemaFile, ":"); !found {
+			return nil, fmt.Errorf("unknown format '%s'", format)
+		}
+
+		schema, err := os.ReadFile(schemaFile)
+		if err != nil {
+			return nil, err
+		}
+		return formatters.NewAvroCodec(string(schema))
+	}
+
+	return nil, fmt.Errorf("unknown format '%s', expected %s", format, codecFormats)
+}
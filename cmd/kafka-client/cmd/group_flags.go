@@ -0,0 +1,47 @@
+/*
+Copyright © 2023 VECI Group Tech S.L.
+This file is part of kafka-client.
+*/
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/bluekiri/kafka-client/internal/handlers"
+	"github.com/bluekiri/kafka-client/internal/kafkautils"
+
+	"github.com/IBM/sarama"
+	"github.com/spf13/cobra"
+)
+
+func addGroupFlags(cmd *cobra.Command) {
+	cmd.Flags().StringP(group, "g", "", "consume as a member of the given consumer group, committing the offset of a message once it and the previous messages of its partition are handled.")
+
+	// Consumer groups decide the partitions and offsets to consume
+	for _, flag := range []string{partitions, startOffset, fromTime, fromRelative} {
+		cmd.MarkFlagsMutuallyExclusive(group, flag)
+	}
+}
+
+func configureGroup(cmd *cobra.Command, config *sarama.Config) {
+	// Partitions without committed offsets start from the oldest message
+	// if requested
+	if beginning, _ := cmd.Flags().GetBool(fromBeginning); beginning {
+		config.Consumer.Offsets.Initial = sarama.OffsetOldest
+	}
+}
+
+func newKafkaInputHandler(cmd *cobra.Command, client sarama.Client, topic string, options handlers.KafkaInputOptions) (handlers.InputHandler, error) {
+	if groupID, _ := cmd.Flags().GetString(group); groupID != "" {
+		return handlers.NewKafkaGroupInputHandler(client, topic, groupID, options)
+	}
+	return handlers.NewKafkaInputHandler(client, topic, options)
+}
+
+func describeStart(cmd *cobra.Command, positions kafkautils.Positions) string {
+	if groupID, _ := cmd.Flags().GetString(group); groupID != "" {
+		return fmt.Sprintf("as member of group %s", groupID)
+	}
+	return fmt.Sprintf("starting at %v", positions)
+}
@@ -0,0 +1,143 @@
+/*
+Copyright © 2023 VECI Group Tech S.L.
+This file is part of kafka-client.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bluekiri/kafka-client/internal/kafkautils"
+
+	"github.com/spf13/cobra"
+)
+
+func addStartFlags(cmd *cobra.Command) {
+	cmd.Flags().String(partitions, "", "consume only from the given partitions (e.g. 0,3,7-9).")
+	cmd.Flags().Bool(fromBeginning, false, "consume from the oldest message available in every partition.")
+	cmd.Flags().String(startOffset, "", "consume from the given offset. Accepts an offset, earliest or latest, optionally per partition (e.g. 3:1200,7:earliest).")
+	cmd.Flags().String(fromTime, "", "consume from the first message produced at or after the given RFC 3339 time (e.g. 2026-10-01T00:00:00Z).")
+	cmd.Flags().Duration(fromRelative, 0, "consume from the first message produced at or after the given time relative to now (e.g. -1h).")
+
+	cmd.MarkFlagsMutuallyExclusive(fromBeginning, fromTime, fromRelative)
+}
+
+func getPartitions(cmd *cobra.Command) ([]int32, error) {
+	if selected, _ := cmd.Flags().GetString(partitions); selected != "" {
+		return kafkautils.ParsePartitions(selected)
+	}
+	return nil, nil
+}
+
+// getStartPositions returns the start positions given by the start flags.
+// Partitions without a start position start at the given default position.
+func getStartPositions(cmd *cobra.Command, defaultPosition kafkautils.Position) (kafkautils.Positions, error) {
+	positions := kafkautils.Positions{Default: defaultPosition}
+	offset, _ := cmd.Flags().GetString(startOffset)
+	hasDefaultOffset := slices.ContainsFunc(strings.Split(offset, ","), func(element string) bool {
+		return element != "" && !strings.Contains(element, ":")
+	})
+	if offset != "" {
+		parsed, err := kafkautils.ParsePositions(offset)
+		if err != nil {
+			return kafkautils.Positions{}, err
+		}
+		positions.ByPartition = parsed.ByPartition
+		if hasDefaultOffset {
+			positions.Default = parsed.Default
+		}
+	}
+
+	// The default position can be given either by --offset or by the other
+	// start flags, but not by both
+	startPosition, err := getStartPosition(cmd)
+	if err != nil {
+		return kafkautils.Positions{}, err
+	}
+	if startPosition != nil {
+		if hasDefaultOffset {
+			return kafkautils.Positions{}, fmt.Errorf("--%s without partition can't be combined with --%s, --%s or --%s", startOffset, fromBeginning, fromTime, fromRelative)
+		}
+		positions.Default = *startPosition
+	}
+
+	return positions, nil
+}
+
+func getStartPosition(cmd *cobra.Command) (*kafkautils.Position, error) {
+	if beginning, _ := cmd.Flags().GetBool(fromBeginning); beginning {
+		return &kafkautils.Earliest, nil
+	}
+	return getTimePosition(cmd, fromTime, fromRelative)
+}
+
+func addEndFlags(cmd *cobra.Command) {
+	cmd.Flags().String(untilOffset, "", "stop before the given offset. Accepts an offset, earliest or latest, optionally per partition (e.g. 3:1500,7:latest).")
+	cmd.Flags().String(untilTime, "", "stop before the first message produced at or after the given RFC 3339 time (e.g. 2026-10-02T00:00:00Z).")
+	cmd.Flags().Duration(untilRelative, 0, "stop before the first message produced at or after the given time relative to now (e.g. -1h).")
+
+	cmd.MarkFlagsMutuallyExclusive(untilTime, untilRelative)
+}
+
+// getEndPositions returns the end positions given by the end flags, or nil if
+// no end flag is given. Partitions without an end position end at the latest
+// position.
+func getEndPositions(cmd *cobra.Command) (*kafkautils.Positions, error) {
+	positions := kafkautils.Positions{Default: kafkautils.Latest}
+	offset, _ := cmd.Flags().GetString(untilOffset)
+	if offset != "" {
+		var err error
+		if positions, err = kafkautils.ParsePositions(offset); err != nil {
+			return nil, err
+		}
+	}
+
+	endPosition, err := getTimePosition(cmd, untilTime, untilRelative)
+	if err != nil {
+		return nil, err
+	}
+	if endPosition != nil {
+		hasDefaultOffset := slices.ContainsFunc(strings.Split(offset, ","), func(element string) bool {
+			return element != "" && !strings.Contains(element, ":")
+		})
+		if hasDefaultOffset {
+			return nil, fmt.Errorf("--%s without partition can't be combined with --%s or --%s", untilOffset, untilTime, untilRelative)
+		}
+		positions.Default = *endPosition
+	}
+
+	if offset == "" && endPosition == nil {
+		return nil, nil
+	}
+	return &positions, nil
+}
+
+// getTimePosition returns the position given by the time flag, an RFC 3339
+// time, or by the relative flag, a duration relative to now, or nil if none
+// of them is given.
+func getTimePosition(cmd *cobra.Command, timeFlag string, relativeFlag string) (*kafkautils.Position, error) {
+	if timestamp, _ := cmd.Flags().GetString(timeFlag); timestamp != "" {
+		t, err := time.Parse(time.RFC3339, timestamp)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --%s: %w", timeFlag, err)
+		}
+		position := kafkautils.AtTime(t)
+		return &position, nil
+	}
+
+	if cmd.Flags().Changed(relativeFlag) {
+		relative, _ := cmd.Flags().GetDuration(relativeFlag)
+		// Relative times always point to the past, so -1h and 1h are equivalent
+		if relative > 0 {
+			relative = -relative
+		}
+		position := kafkautils.AtTime(time.Now().Add(relative))
+		return &position, nil
+	}
+
+	return nil, nil
+}
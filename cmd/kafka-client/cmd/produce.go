@@ -50,15 +50,20 @@ func init() {
 	viper.BindPFlag(period, produceCmd.Flags().Lookup(period))
 
 	addFormatFlags(produceCmd)
+	addTransformFlags(produceCmd)
+	addProducerFlags(produceCmd)
 }
 
 func produce(cmd *cobra.Command, args []string) error {
 	cmd.SilenceUsage = true
 
 	// Get the command arguments and flags
-	kafkaBrokers := strings.Split(resolveCluster(args[0]), ",")
+	cluster, err := getClusterConfig(args[0])
+	if err != nil {
+		return err
+	}
+	kafkaBrokers := cluster.brokerList()
 	kafkaTopic := args[1]
-	kafkaClientID := viper.GetString(clientID)
 	inputFilename, _ := cmd.Flags().GetString(input)
 	pacerPeriod := viper.GetDuration(period)
 	duration := viper.GetDuration(duration)
@@ -68,7 +73,13 @@ func produce(cmd *cobra.Command, args []string) error {
 	}
 
 	// Get the formatter
-	formatter, err := getFormatter(cmd, inputFilename)
+	formatter, err := getFormatter(cmd, inputFilename, args[0], kafkaTopic)
+	if err != nil {
+		return err
+	}
+
+	// Get the transform
+	messageTransform, err := getTransform(cmd, kafkaTopic, kafkaTopic)
 	if err != nil {
 		return err
 	}
@@ -81,10 +92,15 @@ func produce(cmd *cobra.Command, args []string) error {
 	defer reader.Close()
 
 	// Kafka configuration
-	config := sarama.NewConfig()
-	config.ClientID = kafkaClientID
+	config, err := newSaramaConfig(cmd, cluster)
+	if err != nil {
+		return err
+	}
 	config.Producer.Return.Successes = true
 	config.Producer.Return.Errors = true
+	if err := configureProducer(cmd, config, cluster); err != nil {
+		return err
+	}
 
 	// Get the Kafka client
 	client, err := sarama.NewClient(kafkaBrokers, config)
@@ -107,11 +123,15 @@ func produce(cmd *cobra.Command, args []string) error {
 	// Create the handlers
 	inputHandler, err := handlers.NewFileInputHandler(formatter.NewReader(reader))
 	if err != nil {
-		return nil
+		return err
 	}
-	outputHandler, err := handlers.NewKafkaOutputHandler(inputHandler.Messages(), pacer, client, kafkaTopic)
+	transformHandler, err := handlers.NewTransformHandler(inputHandler.Messages(), messageTransform)
 	if err != nil {
-		return nil
+		return err
+	}
+	outputHandler, err := handlers.NewKafkaOutputHandler(transformHandler.Messages(), pacer, client, kafkaTopic, getKafkaOutputOptions(cmd))
+	if err != nil {
+		return err
 	}
 	reportingHandler := handlers.NewReportingHandler(logger, reportingPeriod)
 
@@ -122,11 +142,14 @@ func produce(cmd *cobra.Command, args []string) error {
 	g, ctx := errgroup.WithContext(ctx)
 
 	// Start reporting goroutine
-	g.Go(reportingHandler.Start(inputHandler.Progress(), outputHandler.Progress()))
+	g.Go(reportingHandler.Start(inputHandler.Progress(), transformHandler.Progress(), outputHandler.Progress()))
 
 	// Start the output goroutine
 	g.Go(outputHandler.Run)
 
+	// Start the transform goroutine
+	g.Go(transformHandler.Start(ctx))
+
 	// Start the input goroutine
 	g.Go(inputHandler.Start(ctx))
 
@@ -0,0 +1,114 @@
+/*
+Copyright © 2023 VECI Group Tech S.L.
+This file is part of kafka-client.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/bluekiri/kafka-client/internal/handlers"
+	"github.com/bluekiri/kafka-client/internal/kafkautils"
+
+	"github.com/IBM/sarama"
+	"github.com/spf13/cobra"
+)
+
+func addProducerFlags(cmd *cobra.Command) {
+	cmd.Flags().Bool(keepTimestamp, false, "produce the messages with their original timestamp instead of the current time.")
+	cmd.Flags().Int32(partition, 0, "produce every message to the given partition.")
+	cmd.Flags().String(partitioner, "fnv", "partitioner choosing the partition of the messages: murmur2 (hash of the key like the Java producer), fnv (hash of the key), random, roundrobin or manual (the original partition of the messages, given by the source topic or the JSON format).")
+
+	cmd.Flags().String(compression, "none", "compression of the produced messages: none, gzip, snappy, lz4 or zstd.")
+	cmd.Flags().String(acks, "1", "acknowledgements required to consider a message produced: all (every in-sync replica), 1 (the leader) or 0 (none).")
+	cmd.Flags().Bool(idempotent, false, "produce every message exactly once per partition, even if retried. Requires --acks all, which is the default then.")
+	cmd.Flags().Int(maxMessageBytes, 1024*1024, "maximum size in bytes of a produced message.")
+	cmd.Flags().Int(batchBytes, 0, "produce a batch once it reaches the given size in bytes.")
+	cmd.Flags().Int(batchMessages, 0, "produce a batch once it holds the given number of messages.")
+	cmd.Flags().Duration(linger, 0, "time to wait for more messages before producing a batch (e.g. 10ms).")
+
+	cmd.MarkFlagsMutuallyExclusive(partition, partitioner)
+	cmd.RegisterFlagCompletionFunc(partition, cobra.NoFileCompletions)
+	cmd.RegisterFlagCompletionFunc(partitioner, cobra.FixedCompletions(kafkautils.Partitioners, cobra.ShellCompDirectiveNoFileComp))
+	cmd.RegisterFlagCompletionFunc(compression, cobra.FixedCompletions(kafkautils.Compressions, cobra.ShellCompDirectiveNoFileComp))
+	cmd.RegisterFlagCompletionFunc(acks, cobra.FixedCompletions(kafkautils.Acks, cobra.ShellCompDirectiveNoFileComp))
+}
+
+// configureProducer configures the producer with the producer flags or, if
+// not given, with the settings of the cluster the messages are produced to.
+func configureProducer(cmd *cobra.Command, config *sarama.Config, settings *clusterConfig) error {
+	var err error
+
+	// Messages are produced to the given partition by the manual partitioner
+	if cmd.Flags().Changed(partition) {
+		config.Producer.Partitioner = sarama.NewManualPartitioner
+	} else {
+		var name string
+		if name, err = getClusterSetting(cmd, settings, partitioner, cmd.Flags().GetString, parseString); err != nil {
+			return err
+		}
+		if config.Producer.Partitioner, err = kafkautils.ParsePartitioner(name); err != nil {
+			return err
+		}
+	}
+
+	name, err := getClusterSetting(cmd, settings, compression, cmd.Flags().GetString, parseString)
+	if err != nil {
+		return err
+	}
+	if config.Producer.Compression, err = kafkautils.ParseCompression(name); err != nil {
+		return err
+	}
+
+	name, err = getClusterSetting(cmd, settings, acks, cmd.Flags().GetString, parseString)
+	if err != nil {
+		return err
+	}
+	if config.Producer.RequiredAcks, err = kafkautils.ParseAcks(name); err != nil {
+		return err
+	}
+
+	// Idempotence requires every in-sync replica to acknowledge the messages
+	// and a single request in flight per broker
+	isIdempotent, err := getClusterSetting(cmd, settings, idempotent, cmd.Flags().GetBool, strconv.ParseBool)
+	if err != nil {
+		return err
+	}
+	if isIdempotent {
+		if settings.isSet(cmd, acks) && config.Producer.RequiredAcks != sarama.WaitForAll {
+			return fmt.Errorf("--%s requires --%s all", idempotent, acks)
+		}
+		config.Producer.Idempotent = true
+		config.Producer.RequiredAcks = sarama.WaitForAll
+		config.Net.MaxOpenRequests = 1
+	}
+
+	if config.Producer.MaxMessageBytes, err = getClusterSetting(cmd, settings, maxMessageBytes, cmd.Flags().GetInt, strconv.Atoi); err != nil {
+		return err
+	}
+	if config.Producer.Flush.Bytes, err = getClusterSetting(cmd, settings, batchBytes, cmd.Flags().GetInt, strconv.Atoi); err != nil {
+		return err
+	}
+	if config.Producer.Flush.Messages, err = getClusterSetting(cmd, settings, batchMessages, cmd.Flags().GetInt, strconv.Atoi); err != nil {
+		return err
+	}
+	if config.Producer.Flush.Frequency, err = getClusterSetting(cmd, settings, linger, cmd.Flags().GetDuration, time.ParseDuration); err != nil {
+		return err
+	}
+	return nil
+}
+
+func getKafkaOutputOptions(cmd *cobra.Command) handlers.KafkaOutputOptions {
+	keepTimestamp, _ := cmd.Flags().GetBool(keepTimestamp)
+	options := handlers.KafkaOutputOptions{
+		KeepTimestamp: keepTimestamp,
+	}
+	if cmd.Flags().Changed(partition) {
+		partition, _ := cmd.Flags().GetInt32(partition)
+		options.Partition = &partition
+	}
+	return options
+}
@@ -0,0 +1,69 @@
+/*
+Copyright © 2023 VECI Group Tech S.L.
+This file is part of kafka-client.
+*/
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/bluekiri/kafka-client/internal/redact"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// redactPolicies lists the policies accepted by --redact-policy.
+var redactPolicies = []string{string(redact.Mask), string(redact.Hash), string(redact.Drop)}
+
+func addRedactFlags(cmd *cobra.Command) {
+	cmd.Flags().StringSlice(redactFields, nil, "redact a field of the decoded values, given by its dot separated path and optionally its policy (e.g. customer.email,card.number:hash). Values that can't be decoded as JSON are not written.")
+	cmd.Flags().String(redactPolicy, string(redact.Mask), "policy of the redacted fields without policy: mask (strings are replaced by **** and other values by null), hash (values are replaced by their HMAC-SHA256 keyed by the redact-hash-key setting) or drop (fields are removed).")
+
+	cmd.RegisterFlagCompletionFunc(redactPolicy, cobra.FixedCompletions(redactPolicies, cobra.ShellCompDirectiveNoFileComp))
+}
+
+// getRedactFields returns the fields to redact given by the --redact flag and
+// the redact setting of the configuration file, which are always redacted by
+// the commands with the --redact flag.
+func getRedactFields(cmd *cobra.Command) ([]redact.Field, error) {
+	if cmd.Flags().Lookup(redactFields) == nil {
+		return nil, nil
+	}
+
+	policyName := viper.GetString(redactPolicy)
+	if isFlagSet(cmd, redactPolicy) || policyName == "" {
+		policyName, _ = cmd.Flags().GetString(redactPolicy)
+	}
+	policy, err := redact.ParsePolicy(policyName)
+	if err != nil {
+		return nil, err
+	}
+
+	flagFields, _ := cmd.Flags().GetStringSlice(redactFields)
+	var fields []redact.Field
+	for _, text := range append(viper.GetStringSlice(redactFields), flagFields...) {
+		field, err := redact.ParseField(text, policy)
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+// newRedactor returns the redactor of the fields, or nil if there are no
+// fields to redact. The hashed fields are keyed by the secret given by the
+// redact-hash-key setting of the configuration file or the environment.
+func newRedactor(fields []redact.Field) (*redact.Redactor, error) {
+	if len(fields) == 0 {
+		return nil, nil
+	}
+	redactor, err := redact.NewRedactor([]byte(viper.GetString(redactHashKey)), fields...)
+	if errors.Is(err, redact.ErrMissingHashKey) {
+		return nil, fmt.Errorf("the hash redaction policy requires the secret key given by the %s setting or the KAFKA_CLIENT_REDACT_HASH_KEY environment variable", redactHashKey)
+	}
+	return redactor, err
+}
@@ -54,12 +54,16 @@ func init() {
 
 	rootCmd.PersistentFlags().StringVar(&cfgFile, config, "", "config file (default is $HOME/.kafka-client.yaml)")
 	rootCmd.PersistentFlags().StringP(clientID, "c", "kafka-client", "client ID to sent to Kafka")
+	rootCmd.PersistentFlags().String(kafkaVersion, "", "version of the Kafka brokers, enabling the features of the protocol they support (default 2.1.0)")
 	rootCmd.PersistentFlags().DurationP(duration, "d", 0, "time to wait before exiting")
 	rootCmd.PersistentFlags().BoolP(quiet, "q", false, "enable quiet mode")
 
 	viper.BindPFlag(clientID, rootCmd.PersistentFlags().Lookup(clientID))
+	viper.BindPFlag(kafkaVersion, rootCmd.PersistentFlags().Lookup(kafkaVersion))
 	viper.BindPFlag(duration, rootCmd.PersistentFlags().Lookup(duration))
 	viper.BindPFlag(quiet, rootCmd.PersistentFlags().Lookup(quiet))
+
+	addSecurityFlags(rootCmd)
 }
 
 // initConfig reads in config file and ENV variables if set.
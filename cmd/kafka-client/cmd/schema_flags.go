@@ -0,0 +1,141 @@
+/*
+Copyright © 2023 VECI Group Tech S.L.
+This file is part of kafka-client.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/bluekiri/kafka-client/internal/formatters"
+	"github.com/bluekiri/kafka-client/internal/protoutils"
+	"github.com/bluekiri/kafka-client/internal/registry"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// addSchemaFlags adds the flags giving the Avro and protobuf schemas used by
+// the codecs.
+func addSchemaFlags(cmd *cobra.Command) {
+	cmd.Flags().String(avroSchema, "", "the Avro schema (.avsc) file of the messages. Messages are Avro binary encoded without the schema registry wire format.")
+	cmd.Flags().String(schemaRegistry, "", "URL of the schema registry from which the Avro and protobuf schemas of the messages are fetched.")
+	cmd.Flags().Duration(registryTimeout, 30*time.Second, "time limit of the requests to the schema registry, or no limit if 0.")
+
+	cmd.Flags().StringSlice(importPath, []string{"."}, "directory from which proto sources can be imported.")
+	cmd.Flags().StringSlice(protoFile, []string{"*.proto"}, "the name of a proto source file. Imports will be resolved using the given --import-path flags. Multiple proto files can be specified by specifying multiple --proto-file flags.")
+	cmd.Flags().StringSlice(descriptorSet, nil, "a compiled FileDescriptorSet file, as produced by protoc -o or buf build, used instead of the proto source files. Multiple descriptor sets can be specified by specifying multiple --descriptor-set flags.")
+
+	cmd.MarkFlagDirname(importPath)
+	cmd.MarkFlagFilename(descriptorSet)
+	cmd.MarkFlagFilename(avroSchema, "avsc")
+	cmd.RegisterFlagCompletionFunc(protoFile, wrapCompletion(completeProtoFile, bindFlags))
+
+	bindFlags(cmd)
+}
+
+func getRegistryAvroCodec(cmd *cobra.Command, subject string) (formatters.Codec, error) {
+	registryURL := getSchemaRegistry(cmd)
+	if len(registryURL) == 0 {
+		return nil, fmt.Errorf("the avro format requires the schema file given by --%s or the schema registry given by --%s", avroSchema, schemaRegistry)
+	}
+	return formatters.NewRegistryAvroCodec(cmd.Context(), sharedRegistryClient(cmd, registryURL), subject), nil
+}
+
+func getRegistryClient(cmd *cobra.Command) (*registry.Client, error) {
+	registryURL := getSchemaRegistry(cmd)
+	if len(registryURL) == 0 {
+		return nil, fmt.Errorf("the proto-registry format requires the schema registry given by --%s", schemaRegistry)
+	}
+	return sharedRegistryClient(cmd, registryURL), nil
+}
+
+// registryClients holds the schema registry clients of the commands, so the
+// codecs and formatters of a command run share the schemas they fetch.
+var (
+	registryClientsMutex sync.Mutex
+	registryClients      = make(map[registryClientKey]*registry.Client)
+)
+
+type registryClientKey struct {
+	cmd     *cobra.Command
+	url     string
+	timeout time.Duration
+}
+
+// sharedRegistryClient returns the client of the schema registry at the given
+// URL for the command, creating it if not created yet.
+func sharedRegistryClient(cmd *cobra.Command, registryURL string) *registry.Client {
+	registryClientsMutex.Lock()
+	defer registryClientsMutex.Unlock()
+
+	key := registryClientKey{cmd, registryURL, getSchemaRegistryTimeout(cmd)}
+	client, found := registryClients[key]
+	if !found {
+		client = registry.NewClient(key.url, key.timeout)
+		registryClients[key] = client
+	}
+	return client
+}
+
+// getSchemaRegistry returns the URL of the schema registry given by the
+// --schema-registry flag or the configuration file.
+func getSchemaRegistry(cmd *cobra.Command) string {
+	if registryURL, _ := cmd.Flags().GetString(schemaRegistry); registryURL != "" {
+		return registryURL
+	}
+	return viper.GetString(schemaRegistry)
+}
+
+// getSchemaRegistryTimeout returns the timeout of the requests to the schema
+// registry given by the --schema-registry-timeout flag, the settings of the
+// topic or the configuration file.
+func getSchemaRegistryTimeout(cmd *cobra.Command) time.Duration {
+	if !isFlagSet(cmd, registryTimeout) && viper.IsSet(registryTimeout) {
+		return viper.GetDuration(registryTimeout)
+	}
+	timeout, _ := cmd.Flags().GetDuration(registryTimeout)
+	return timeout
+}
+
+// keySubject and valueSubject return the schema registry subjects of the keys
+// and the values of a topic.
+func keySubject(topic string) string {
+	return topic + "-key"
+}
+
+func valueSubject(topic string) string {
+	return topic + "-value"
+}
+
+func resolveProtoMessageType(cmd *cobra.Command, messageFullName string) (protoreflect.MessageType, error) {
+	return protoutils.ResolveProtoMessageType(
+		cmd.Context(),
+		messageFullName,
+		getProtoSources(cmd),
+	)
+}
+
+// getProtoSources returns the sources of protobuf message types given by the
+// --descriptor-set, --proto-file and --import-path flags.
+func getProtoSources(cmd *cobra.Command) protoutils.Sources {
+	return protoutils.Sources{
+		ProtoFiles:     getSourcesFlag(cmd, protoFile),
+		ImportPaths:    getSourcesFlag(cmd, importPath),
+		DescriptorSets: getSourcesFlag(cmd, descriptorSet),
+	}
+}
+
+// getSourcesFlag returns the value of the proto sources flag set by the
+// settings of the topic or, if not set, bound to viper.
+func getSourcesFlag(cmd *cobra.Command, flag string) []string {
+	if isFlagSet(cmd, flag) {
+		values, _ := cmd.Flags().GetStringSlice(flag)
+		return values
+	}
+	return viper.GetStringSlice(flag)
+}
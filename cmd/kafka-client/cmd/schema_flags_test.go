@@ -0,0 +1,27 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestSharedRegistryClient(t *testing.T) {
+	cmd := &cobra.Command{}
+	addSchemaFlags(cmd)
+
+	// The codecs and formatters of a command share the client of a registry
+	client := sharedRegistryClient(cmd, "http://registry:8081")
+	if sharedRegistryClient(cmd, "http://registry:8081") != client {
+		t.Error("expected the same client for the same registry")
+	}
+	if sharedRegistryClient(cmd, "http://other:8081") == client {
+		t.Error("expected another client for another registry")
+	}
+
+	other := &cobra.Command{}
+	addSchemaFlags(other)
+	if sharedRegistryClient(other, "http://registry:8081") == client {
+		t.Error("expected another client for another command")
+	}
+}
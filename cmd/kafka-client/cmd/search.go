@@ -0,0 +1,299 @@
+/*
+Copyright © 2023 VECI Group Tech S.L.
+This file is part of kafka-client.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bluekiri/kafka-client/internal/dto"
+	"github.com/bluekiri/kafka-client/internal/filters"
+	"github.com/bluekiri/kafka-client/internal/formatters"
+	"github.com/bluekiri/kafka-client/internal/handlers"
+	"github.com/bluekiri/kafka-client/internal/ioutils"
+	"github.com/bluekiri/kafka-client/internal/jsonutils"
+	"github.com/bluekiri/kafka-client/internal/kafkautils"
+	"github.com/bluekiri/kafka-client/internal/sliceutils"
+
+	"github.com/IBM/sarama"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	searchExample = `kafka-client search localhost:9092 orders '"orderId":"1234"' --from-time 2026-10-17T00:00:00Z --until-time 2026-10-18T00:00:00Z
+kafka-client search localhost:9092 orders '^1234$' --jsonpath '$.order.id' --proto acme.orders.v1.Order`
+	searchShort = "Searches a Kafka topic for the messages matching a pattern."
+	searchLong  = `search command uses bootstrap_servers to get the brokers of the Kafka cluster
+and scans the indicated topic, every partition in parallel, for the messages
+whose key, headers or value match the given regular expression, printing the
+partition, offset, timestamp and headers of every hit followed by the message
+to stdout unless a filename is provided by the --output flag.
+
+The scan starts at the oldest message of every partition, unless a start flag
+is given, and stops at the end position given by the --until-* flags or at the
+last message every partition had when the search started.
+
+Headers are matched as name=value, and keys and values using their textual
+representation given by --key-format and the value format. If --jsonpath is
+given, the pattern is matched against the nodes of the values selected by the
+JSONPath, or every message with a selected node is a hit if no pattern is
+given.`
+)
+
+// searchScopes are the parts of the messages where the pattern can be searched.
+var searchScopes = []string{"key", "headers", "value"}
+
+// searchCmd represents the search command
+var searchCmd = &cobra.Command{
+	Use:               "search bootstrap_servers topic [pattern]",
+	Short:             searchShort,
+	Long:              searchLong,
+	Example:           searchExample,
+	Args:              cobra.RangeArgs(2, 3),
+	ValidArgsFunction: completeClustersAndTopic(2),
+	RunE:              search,
+}
+
+func init() {
+	rootCmd.AddCommand(searchCmd)
+
+	searchCmd.Flags().StringP(output, "o", "", "write to file instead of stdout.")
+	searchCmd.MarkFlagFilename(output)
+
+	searchCmd.Flags().String(searchPath, "", "match the pattern against the nodes of the values selected by the given JSONPath (e.g. $.order.id or $..sku). Supports $, .field, ['field'], [index], [*], .* and .. (recursive descent).")
+	searchCmd.Flags().StringSlice(searchIn, nil, "search only in the given parts of the messages: key, headers or value (default every part, or only the value if --jsonpath is given).")
+	searchCmd.Flags().BoolP(ignoreCase, "i", false, "match the pattern ignoring case.")
+
+	// Hits are written with their metadata, which the raw format can't write
+	addFormatFlags(searchCmd)
+	searchCmd.Flags().MarkHidden(formatRaw)
+	addRedactFlags(searchCmd)
+	addStartFlags(searchCmd)
+	addEndFlags(searchCmd)
+
+	searchCmd.RegisterFlagCompletionFunc(searchPath, cobra.NoFileCompletions)
+	searchCmd.RegisterFlagCompletionFunc(searchIn, cobra.FixedCompletions(searchScopes, cobra.ShellCompDirectiveNoFileComp))
+}
+
+func search(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+
+	// Get the command arguments and flags
+	cluster, err := getClusterConfig(args[0])
+	if err != nil {
+		return err
+	}
+	kafkaBrokers := cluster.brokerList()
+	kafkaTopic := args[1]
+	outputFilename, _ := cmd.Flags().GetString(output)
+	duration := viper.GetDuration(duration)
+	reportingPeriod := time.Duration(1) * time.Second
+	if viper.GetBool(quiet) {
+		reportingPeriod = -1
+	}
+
+	// Get the start and end positions and partitions
+	startPositions, err := getStartPositions(cmd, kafkautils.Earliest)
+	if err != nil {
+		return err
+	}
+	endPositions, err := getEndPositions(cmd)
+	if err != nil {
+		return err
+	}
+	partitions, err := getPartitions(cmd)
+	if err != nil {
+		return err
+	}
+
+	// Get the formatter. Hits are written with their metadata, so they are
+	// written as text even to files unless another format is given, and only
+	// by the formats writing every message in a single line.
+	if raw, _ := cmd.Flags().GetBool(formatRaw); raw {
+		return fmt.Errorf("the raw format can't be used to write the hits")
+	}
+	formatter, err := getFormatter(cmd, "", args[0], kafkaTopic)
+	if err != nil {
+		return err
+	}
+	if encoding := multilineEncoding(cmd); encoding != "" {
+		return fmt.Errorf("the %s encoding can't be used to write the hits", encoding)
+	}
+	formatter = formatters.WithMetadata(formatter)
+
+	// Get the search filter
+	searchFilter, err := getSearchFilter(cmd, args[2:], kafkaTopic)
+	if err != nil {
+		return err
+	}
+
+	// Get the writer (sink of messages)
+	writer, err := ioutils.Create(outputFilename)
+	if err != nil {
+		return err
+	}
+	defer writer.Close()
+
+	// Kafka configuration
+	config, err := newSaramaConfig(cmd, cluster)
+	if err != nil {
+		return err
+	}
+	config.Consumer.Return.Errors = true
+
+	// Get the Kafka client
+	client, err := sarama.NewClient(kafkaBrokers, config)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	// Check topic exists
+	if topics, err := client.Topics(); err != nil {
+		return err
+	} else if !sliceutils.Contains(topics, kafkaTopic) {
+		return fmt.Errorf("kafka: topic %s does not exist", kafkaTopic)
+	}
+
+	// Create the handlers
+	inputHandler, err := handlers.NewKafkaInputHandler(client, kafkaTopic, handlers.KafkaInputOptions{
+		Start:      startPositions,
+		Partitions: partitions,
+		UntilEnd:   true,
+		End:        endPositions,
+	})
+	if err != nil {
+		return err
+	}
+	filterHandler, err := handlers.NewFilterHandler(inputHandler.Messages(), searchFilter)
+	if err != nil {
+		return err
+	}
+	outputHandler, err := handlers.NewFileOutputHandler(filterHandler.Messages(), formatter.NewWriter(writer))
+	if err != nil {
+		return err
+	}
+	reportingHandler := handlers.NewReportingHandler(logger, reportingPeriod)
+
+	// Get the interruptable context
+	ctx := interruptableContext(cmd.Context(), duration)
+
+	// Create the error group
+	g, ctx := errgroup.WithContext(ctx)
+
+	// Start reporting goroutine
+	g.Go(reportingHandler.Start(inputHandler.Progress(), filterHandler.Progress(), outputHandler.Progress()))
+
+	// Start the output goroutine
+	g.Go(outputHandler.Run)
+
+	// Start the filter goroutine
+	g.Go(filterHandler.Start(ctx))
+
+	// Start the input goroutine
+	g.Go(inputHandler.Start(ctx))
+
+	// Log start
+	logOutput := ""
+	if outputFilename != "" {
+		logOutput = fmt.Sprintf(" to '%s'", outputFilename)
+	}
+	logEnd := "the last message"
+	if endPositions != nil {
+		logEnd = endPositions.String()
+	}
+	logger.Printf(
+		"searching messages from cluster %s topic '%s' starting at %v until %s%s",
+		strings.Join(kafkaBrokers, ","), kafkaTopic, startPositions, logEnd, logOutput,
+	)
+
+	// Return the error group error once the hits are reported
+	err = g.Wait()
+	logger.Printf("found %d hits in %d messages", searchFilter.hits, searchFilter.searched)
+	return adaptError(err)
+}
+
+// getSearchFilter returns the filter of the search given by the optional
+// pattern argument and the search flags.
+func getSearchFilter(cmd *cobra.Command, args []string, topic string) (*countingFilter, error) {
+	options := filters.SearchOptions{}
+
+	if len(args) > 0 {
+		expression := args[0]
+		if ignoreCase, _ := cmd.Flags().GetBool(ignoreCase); ignoreCase {
+			expression = "(?i)" + expression
+		}
+		pattern, err := regexp.Compile(expression)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern: %w", err)
+		}
+		options.Pattern = pattern
+	}
+
+	if expression, _ := cmd.Flags().GetString(searchPath); expression != "" {
+		path, err := jsonutils.ParseJSONPath(expression)
+		if err != nil {
+			return nil, err
+		}
+		options.Path = path
+	}
+
+	scopes, _ := cmd.Flags().GetStringSlice(searchIn)
+	if len(scopes) == 0 {
+		scopes = searchScopes
+		if options.Path != nil {
+			scopes = []string{"value"}
+		}
+	}
+	for _, scope := range scopes {
+		if !slices.Contains(searchScopes, scope) {
+			return nil, fmt.Errorf("invalid --%s '%s', expected %s", searchIn, scope, strings.Join(searchScopes, ", "))
+		}
+	}
+	options.Keys = slices.Contains(scopes, "key")
+	options.Headers = slices.Contains(scopes, "headers")
+	options.Values = slices.Contains(scopes, "value")
+
+	keyFormat, _ := cmd.Flags().GetString(keyFormat)
+	keyCodec, err := getCodec(cmd, keyFormat, keySubject(topic))
+	if err != nil {
+		return nil, err
+	}
+	valueCodec, err := getCodec(cmd, getValueFormat(cmd), valueSubject(topic))
+	if err != nil {
+		return nil, err
+	}
+	options.KeyCodec = keyCodec
+	options.ValueCodec = valueCodec
+
+	filter, err := filters.NewSearchFilter(options)
+	if err != nil {
+		return nil, err
+	}
+	return &countingFilter{filter: filter}, nil
+}
+
+// countingFilter counts the messages searched and the hits found by a filter.
+// The counters must only be read once the filter handler is done.
+type countingFilter struct {
+	filter   filters.Filter
+	searched int64
+	hits     int64
+}
+
+func (filter *countingFilter) Match(message *dto.KafkaMessage) (bool, error) {
+	filter.searched++
+	matched, err := filter.filter.Match(message)
+	if matched {
+		filter.hits++
+	}
+	return matched, err
+}
@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+func TestSearchUntilEndGap(t *testing.T) {
+	broker := sarama.NewMockBroker(t, 1)
+	t.Cleanup(broker.Close)
+
+	// The last offset before the end has no message, like the marker of a
+	// transaction
+	fetchResponse := sarama.NewMockFetchResponse(t, 1).SetHighWaterMark("orders", 0, 3)
+	for offset := int64(0); offset < 2; offset++ {
+		fetchResponse.SetMessage("orders", 0, offset, sarama.StringEncoder(fmt.Sprintf("order %d", offset)))
+	}
+	broker.SetHandlerByMap(map[string]sarama.MockResponse{
+		"MetadataRequest": sarama.NewMockMetadataResponse(t).
+			SetBroker(broker.Addr(), broker.BrokerID()).
+			SetLeader("orders", 0, broker.BrokerID()),
+		"OffsetRequest": sarama.NewMockOffsetResponse(t).
+			SetOffset("orders", 0, sarama.OffsetOldest, 0).
+			SetOffset("orders", 0, sarama.OffsetNewest, 3),
+		"FetchRequest": fetchResponse,
+	})
+
+	dir := t.TempDir()
+	configFile := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(configFile, nil, 0o600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	hitsFile := filepath.Join(dir, "hits.txt")
+
+	// The search stops by itself at the end of the topic
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	rootCmd.SetArgs([]string{"search", broker.Addr(), "orders", "order 1", "--config", configFile, "--output", hitsFile, "--quiet"})
+	if err := rootCmd.ExecuteContext(ctx); err != nil {
+		t.Fatalf("search failed: %v", err)
+	}
+	if ctx.Err() != nil {
+		t.Fatal("search did not stop at the end of the topic")
+	}
+
+	hits, err := os.ReadFile(hitsFile)
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	if lines := strings.Split(strings.TrimSpace(string(hits)), "\n"); len(lines) != 1 || !strings.HasSuffix(lines[0], "order 1") {
+		t.Errorf("expected the hit of order 1 but got %q", hits)
+	}
+}
@@ -0,0 +1,113 @@
+/*
+Copyright © 2023 VECI Group Tech S.L.
+This file is part of kafka-client.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/bluekiri/kafka-client/internal/kafkautils"
+
+	"github.com/IBM/sarama"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// securityFlags are the flags configuring the security of the connections to
+// the brokers.
+var securityFlags = []string{tlsEnable, tlsCA, tlsCert, tlsKey, tlsInsecure, saslMechanism, saslUsername, saslPassword, saslTokenFile, saslTokenCommand}
+
+func addSecurityFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().Bool(tlsEnable, false, "connect to the brokers using TLS. Implied by the other --tls flags.")
+	cmd.PersistentFlags().String(tlsCA, "", "PEM file of the certificate authorities verifying the brokers (default the system ones).")
+	cmd.PersistentFlags().String(tlsCert, "", "PEM file of the client certificate, for the brokers requiring client authentication.")
+	cmd.PersistentFlags().String(tlsKey, "", "PEM file of the private key of the client certificate.")
+	cmd.PersistentFlags().Bool(tlsInsecure, false, "don't verify the certificates of the brokers.")
+	cmd.PersistentFlags().String(saslMechanism, "", "authenticate to the brokers using the given SASL mechanism: "+strings.Join(kafkautils.SASLMechanisms, ", ")+".")
+	cmd.PersistentFlags().String(saslUsername, "", "username of the PLAIN and SCRAM mechanisms.")
+	cmd.PersistentFlags().String(saslPassword, "", "password of the PLAIN and SCRAM mechanisms. Prefer the KAFKA_CLIENT_SASL_PASSWORD environment variable.")
+	cmd.PersistentFlags().String(saslTokenFile, "", "file holding the token of the OAUTHBEARER mechanism, read on every connection.")
+	cmd.PersistentFlags().String(saslTokenCommand, "", "shell command printing the token of the OAUTHBEARER mechanism, run on every connection.")
+
+	for _, flag := range securityFlags {
+		viper.BindPFlag(flag, cmd.PersistentFlags().Lookup(flag))
+	}
+	cmd.MarkFlagsMutuallyExclusive(saslTokenFile, saslTokenCommand)
+	cmd.RegisterFlagCompletionFunc(saslMechanism, cobra.FixedCompletions(kafkautils.SASLMechanisms, cobra.ShellCompDirectiveNoFileComp))
+}
+
+// configureSecurity configures the TLS connections and the SASL authentication
+// to the cluster with the security flags or, if not given, with the settings
+// of the cluster.
+func configureSecurity(cmd *cobra.Command, config *sarama.Config, settings *clusterConfig) error {
+	// TLS is enabled by any of the TLS settings
+	var tlsOptions kafkautils.TLSOptions
+	useTLS, err := getClusterSetting(cmd, settings, tlsEnable, getViperBool, strconv.ParseBool)
+	if err != nil {
+		return err
+	}
+	if tlsOptions.CAFile, err = getClusterSetting(cmd, settings, tlsCA, getViperString, parseString); err != nil {
+		return err
+	}
+	if tlsOptions.CertFile, err = getClusterSetting(cmd, settings, tlsCert, getViperString, parseString); err != nil {
+		return err
+	}
+	if tlsOptions.KeyFile, err = getClusterSetting(cmd, settings, tlsKey, getViperString, parseString); err != nil {
+		return err
+	}
+	if tlsOptions.InsecureSkipVerify, err = getClusterSetting(cmd, settings, tlsInsecure, getViperBool, strconv.ParseBool); err != nil {
+		return err
+	}
+	if useTLS || tlsOptions != (kafkautils.TLSOptions{}) {
+		if config.Net.TLS.Config, err = kafkautils.NewTLSConfig(tlsOptions); err != nil {
+			return fmt.Errorf("invalid TLS configuration: %w", err)
+		}
+		config.Net.TLS.Enable = true
+	}
+
+	// SASL is enabled by its mechanism
+	var saslOptions kafkautils.SASLOptions
+	if saslOptions.Mechanism, err = getClusterSetting(cmd, settings, saslMechanism, getViperString, parseString); err != nil {
+		return err
+	}
+	if saslOptions.Mechanism == "" {
+		return nil
+	}
+	if saslOptions.Username, err = getClusterSetting(cmd, settings, saslUsername, getViperString, parseString); err != nil {
+		return err
+	}
+	if saslOptions.Password, err = getClusterSetting(cmd, settings, saslPassword, getViperString, parseString); err != nil {
+		return err
+	}
+	if saslOptions.TokenFile, err = getClusterSetting(cmd, settings, saslTokenFile, getViperString, parseString); err != nil {
+		return err
+	}
+	if saslOptions.TokenCommand, err = getClusterSetting(cmd, settings, saslTokenCommand, getViperString, parseString); err != nil {
+		return err
+	}
+	if err := kafkautils.ConfigureSASL(config, saslOptions); err != nil {
+		return fmt.Errorf("invalid SASL configuration: %w", err)
+	}
+	return nil
+}
+
+// checkSecurityFlags fails if any security flag is given for a command
+// connecting to distinct clusters, as the flags would apply to all of them.
+// The security of every cluster is configured by its profile instead.
+func checkSecurityFlags(cmd *cobra.Command, settings ...*clusterConfig) error {
+	for _, cluster := range settings[1:] {
+		if cluster.brokers == settings[0].brokers {
+			continue
+		}
+		for _, flag := range securityFlags {
+			if cmd.Flags().Changed(flag) {
+				return fmt.Errorf("--%s can't be given for distinct clusters, configure the security of every cluster in the %s section of the configuration file", flag, clusters)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestCheckSecurityFlags(t *testing.T) {
+	testCases := map[string]struct {
+		args    []string
+		output  string
+		wantErr bool
+	}{
+		"distinct clusters":               {args: nil, output: "broker2:9092"},
+		"same cluster with flags":         {args: []string{"--tls"}, output: "broker1:9092"},
+		"distinct clusters with tls":      {args: []string{"--tls"}, output: "broker2:9092", wantErr: true},
+		"distinct clusters with username": {args: []string{"--sasl-username", "me"}, output: "broker2:9092", wantErr: true},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			// Not added by addSecurityFlags, which binds them to viper
+			cmd := &cobra.Command{}
+			cmd.Flags().Bool(tlsEnable, false, "")
+			cmd.Flags().String(saslUsername, "", "")
+			if err := cmd.ParseFlags(testCase.args); err != nil {
+				t.Fatalf("ParseFlags failed: %v", err)
+			}
+
+			input := &clusterConfig{name: "broker1:9092", brokers: "broker1:9092"}
+			output := &clusterConfig{name: testCase.output, brokers: testCase.output}
+			err := checkSecurityFlags(cmd, input, output)
+			if testCase.wantErr && err == nil {
+				t.Error("checkSecurityFlags should have failed")
+			}
+			if !testCase.wantErr && err != nil {
+				t.Errorf("checkSecurityFlags failed: %v", err)
+			}
+		})
+	}
+}
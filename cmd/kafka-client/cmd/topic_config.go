@@ -0,0 +1,157 @@
+/*
+Copyright © 2023 VECI Group Tech S.L.
+This file is part of kafka-client.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	// topicFormats are the formats accepted by the format setting of a topic.
+	topicFormats = []string{formatRaw, formatText, formatJSON, formatAvro, formatProtoRegistry, formatProtoRaw}
+
+	// topicSettings are the flags that can be configured for a topic.
+	topicSettings = []string{formatProto, keyFormat, valueFormat, keySeparator, avroSchema, schemaRegistry, registryTimeout, importPath, protoFile, descriptorSet, protoEncoding, emitUnpopulated, useProtoNames, useEnumNumbers, redactFields, redactPolicy}
+
+	// additiveTopicSettings are the settings added to the values given in the
+	// command line instead of being overridden by them.
+	additiveTopicSettings = []string{redactFields}
+)
+
+// applyTopicConfig sets the format flags not given in the command line to the
+// settings of the first entry of the topics section of the configuration file
+// whose topic, a name or a glob pattern, matches the given topic. For example:
+//
+//	topics:
+//	  - topic: orders
+//	    proto: acme.orders.v1.Order
+//	  - topic: acme.*
+//	    format: json
+//	    key-format: int64
+//
+// The topics of the profile of the cluster are matched before the ones of
+// the topics section. The format and proto settings are ignored if any format
+// flag is given.
+//
+// The settings are the defaults of the flags for the topic, so they aren't
+// reported as given in the command line and the ones not used by the format
+// given in the command line are ignored instead of rejected.
+func applyTopicConfig(cmd *cobra.Command, cluster string, topic string) error {
+	profile, err := getClusterConfig(cluster)
+	if err != nil {
+		return err
+	}
+
+	var entries []map[string]any
+	if err := viper.UnmarshalKey(topicsConfig, &entries); err != nil {
+		return fmt.Errorf("invalid %s configuration: %w", topicsConfig, err)
+	}
+	entries = append(slices.Clone(profile.topics), entries...)
+
+	for _, entry := range entries {
+		pattern, _ := entry["topic"].(string)
+		matched, err := path.Match(pattern, topic)
+		if err != nil {
+			return fmt.Errorf("invalid %s configuration: topic '%s': %w", topicsConfig, pattern, err)
+		}
+		if matched {
+			return applyTopicSettings(cmd, pattern, entry)
+		}
+	}
+	return nil
+}
+
+func applyTopicSettings(cmd *cobra.Command, pattern string, entry map[string]any) error {
+	hasFormat := slices.ContainsFunc(topicFormats, cmd.Flags().Changed) || cmd.Flags().Changed(formatProto)
+
+	// Apply the settings in a stable order
+	settings := make([]string, 0, len(entry))
+	for setting := range entry {
+		settings = append(settings, setting)
+	}
+	slices.Sort(settings)
+
+	for _, setting := range settings {
+		value := entry[setting]
+		switch {
+		case setting == "topic":
+			continue
+
+		case setting == "format":
+			format := fmt.Sprint(value)
+			if !slices.Contains(topicFormats, format) {
+				return fmt.Errorf("invalid %s configuration: topic '%s': unknown format '%s', expected one of %s", topicsConfig, pattern, format, strings.Join(topicFormats, ", "))
+			}
+			if !hasFormat {
+				if err := setTopicSetting(cmd, format, "true"); err != nil {
+					return err
+				}
+			}
+
+		case slices.Contains(topicSettings, setting):
+			// Settings of flags the command doesn't have are ignored
+			if cmd.Flags().Lookup(setting) == nil {
+				continue
+			}
+			if (cmd.Flags().Changed(setting) && !slices.Contains(additiveTopicSettings, setting)) || (setting == formatProto && hasFormat) {
+				continue
+			}
+
+			// Lists are set element by element
+			values, ok := value.([]any)
+			if !ok {
+				values = []any{value}
+			}
+			for _, value := range values {
+				if err := setTopicSetting(cmd, setting, fmt.Sprint(value)); err != nil {
+					return fmt.Errorf("invalid %s configuration: topic '%s': %s: %w", topicsConfig, pattern, setting, err)
+				}
+			}
+
+		default:
+			return fmt.Errorf("invalid %s configuration: topic '%s': unknown setting '%s'", topicsConfig, pattern, setting)
+		}
+	}
+	return nil
+}
+
+// topicSettingAnnotation is the annotation of the flags set by the settings of
+// a topic.
+const topicSettingAnnotation = "kafka-client_topic_setting"
+
+// setTopicSetting sets the value of the flag to the setting of a topic without
+// marking the flag as given in the command line.
+func setTopicSetting(cmd *cobra.Command, flag string, value string) error {
+	setting := cmd.Flags().Lookup(flag)
+	if setting == nil {
+		return fmt.Errorf("flag --%s is not defined", flag)
+	}
+	if err := setting.Value.Set(value); err != nil {
+		return err
+	}
+	return cmd.Flags().SetAnnotation(flag, topicSettingAnnotation, []string{"true"})
+}
+
+// isFlagSet reports whether the flag is given in the command line or set by
+// the settings of the topic, which take precedence over the configuration
+// file and the environment.
+func isFlagSet(cmd *cobra.Command, flag string) bool {
+	if cmd.Flags().Changed(flag) {
+		return true
+	}
+	setting := cmd.Flags().Lookup(flag)
+	if setting == nil {
+		return false
+	}
+	_, set := setting.Annotations[topicSettingAnnotation]
+	return set
+}
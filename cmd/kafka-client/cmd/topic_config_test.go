@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/bluekiri/kafka-client/internal/formatters"
+
+	"github.com/spf13/cobra"
+)
+
+// newTopicCmd returns a command with the format flags parsed from the given
+// arguments and the settings of the topic applied.
+func newTopicCmd(t *testing.T, entry map[string]any, args ...string) *cobra.Command {
+	cmd := &cobra.Command{}
+	addFormatFlags(cmd)
+	addRedactFlags(cmd)
+	if err := cmd.ParseFlags(args); err != nil {
+		t.Fatalf("ParseFlags failed: %v", err)
+	}
+	if err := applyTopicSettings(cmd, "acme.*", entry); err != nil {
+		t.Fatalf("applyTopicSettings failed: %v", err)
+	}
+	return cmd
+}
+
+func TestApplyTopicSettings(t *testing.T) {
+	cmd := newTopicCmd(t, map[string]any{"topic": "acme.*", "format": "json", keyFormat: "int64"})
+
+	// The settings are the defaults of the flags
+	if json, _ := cmd.Flags().GetBool(formatJSON); !json {
+		t.Errorf("expected --%s to be set", formatJSON)
+	}
+	if format, _ := cmd.Flags().GetString(keyFormat); format != "int64" {
+		t.Errorf("expected --%s int64 but got %s", keyFormat, format)
+	}
+	for _, flag := range []string{formatJSON, keyFormat} {
+		if cmd.Flags().Changed(flag) {
+			t.Errorf("expected --%s not to be given in the command line", flag)
+		}
+		if !isFlagSet(cmd, flag) {
+			t.Errorf("expected --%s to be set by the topic", flag)
+		}
+	}
+
+	formatter, err := getMessageFormatter(cmd, "", "local", "acme.x")
+	if err != nil {
+		t.Fatalf("getMessageFormatter failed: %v", err)
+	}
+	if expected := fmt.Sprintf("%T", formatters.NewJSONFormatter(nil, nil)); fmt.Sprintf("%T", formatter) != expected {
+		t.Errorf("expected a %s but got %T", expected, formatter)
+	}
+}
+
+func TestApplyTopicSettingsOverriddenFormat(t *testing.T) {
+	tests := []struct {
+		name  string
+		entry map[string]any
+		args  []string
+	}{
+		{"key format with text", map[string]any{"format": "json", keyFormat: "int64"}, []string{"--" + formatText}},
+		{"proto encoding with json", map[string]any{formatProto: "acme.Order", protoEncoding: "text"}, []string{"--" + formatJSON}},
+		{"proto encoding with text", map[string]any{protoEncoding: "text"}, []string{"--" + formatText}},
+		{"key separator with json", map[string]any{keySeparator: `\t`}, []string{"--" + formatJSON}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cmd := newTopicCmd(t, test.entry, test.args...)
+			if _, err := getMessageFormatter(cmd, "", "local", "acme.x"); err != nil {
+				t.Errorf("getMessageFormatter failed: %v", err)
+			}
+		})
+	}
+}
+
+func TestApplyTopicSettingsGivenFlags(t *testing.T) {
+	// The flags given in the command line are still validated
+	cmd := newTopicCmd(t, map[string]any{"format": "json"}, "--"+formatText, "--"+keyFormat, "int64")
+	if _, err := getMessageFormatter(cmd, "", "local", "acme.x"); err == nil {
+		t.Errorf("getMessageFormatter should have failed with --%s and --%s", formatText, keyFormat)
+	}
+
+	// The settings don't override the flags given in the command line
+	cmd = newTopicCmd(t, map[string]any{keyFormat: "int64"}, "--"+formatJSON, "--"+keyFormat, "hex")
+	if format, _ := cmd.Flags().GetString(keyFormat); format != "hex" {
+		t.Errorf("expected --%s hex but got %s", keyFormat, format)
+	}
+
+	// The redacted fields are added to the ones given in the command line
+	cmd = newTopicCmd(t, map[string]any{redactFields: []any{"card.number"}}, "--"+formatText, "--"+redactFields, "customer.email")
+	fields, _ := cmd.Flags().GetStringSlice(redactFields)
+	if len(fields) != 2 {
+		t.Errorf("expected 2 redacted fields but got %v", fields)
+	}
+}
+
+func TestApplyTopicSettingsRegistryTimeout(t *testing.T) {
+	cmd := newTopicCmd(t, map[string]any{schemaRegistry: "http://registry:8081", registryTimeout: "5s"})
+	if timeout := getSchemaRegistryTimeout(cmd); timeout != 5*time.Second {
+		t.Errorf("expected a timeout of 5s but got %v", timeout)
+	}
+
+	cmd = newTopicCmd(t, map[string]any{registryTimeout: "5s"}, "--"+registryTimeout, "1s")
+	if timeout := getSchemaRegistryTimeout(cmd); timeout != time.Second {
+		t.Errorf("expected a timeout of 1s but got %v", timeout)
+	}
+}
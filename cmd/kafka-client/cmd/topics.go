@@ -0,0 +1,407 @@
+/*
+Copyright © 2023 VECI Group Tech S.L.
+This file is part of kafka-client.
+*/
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/bluekiri/kafka-client/internal/kafkautils"
+
+	"github.com/IBM/sarama"
+	"github.com/spf13/cobra"
+)
+
+const (
+	topicsShort = "Administrates the topics of a Kafka cluster."
+	topicsLong  = `topics command groups the subcommands listing, describing, creating, deleting
+and configuring the topics of the Kafka cluster given by bootstrap_servers.`
+
+	topicsListShort     = "Lists the topics with their partition count and replication factor."
+	topicsListExample   = `kafka-client topics list localhost:9092 --json`
+	topicsDescribeShort = "Describes the partitions, watermarks and configuration of topics."
+	topicsDescribeLong  = `describe command prints, for every given topic, the leader, replicas, in-sync
+replicas and oldest and newest offsets of every partition, followed by the
+configuration entries set for the topic or the brokers, or every entry if
+--all-configs is given.`
+	topicsDescribeExample = `kafka-client topics describe localhost:9092 orders payments`
+	topicsCreateShort     = "Creates a topic."
+	topicsCreateExample   = `kafka-client topics create localhost:9092 orders --partitions 6 --replication-factor 3 --set retention.ms=86400000`
+	topicsDeleteShort     = "Deletes topics."
+	topicsDeleteExample   = `kafka-client topics delete localhost:9092 orders payments`
+	topicsAlterShort      = "Sets or removes configuration entries of a topic."
+	topicsAlterExample    = `kafka-client topics alter-config localhost:9092 orders --set retention.ms=86400000 --unset cleanup.policy`
+	topicsPartitionsShort = "Increases the number of partitions of a topic."
+	topicsPartitionsLong  = `add-partitions command increases the number of partitions of the topic to
+the total given by --partitions. The partition of the messages produced by
+key will change, as it depends on the number of partitions.`
+	topicsPartitionsExample = `kafka-client topics add-partitions localhost:9092 orders --partitions 12`
+)
+
+// topicsCmd represents the topics command
+var topicsCmd = &cobra.Command{
+	Use:   "topics",
+	Short: topicsShort,
+	Long:  topicsLong,
+}
+
+// topicsListCmd represents the topics list command
+var topicsListCmd = &cobra.Command{
+	Use:               "list bootstrap_servers",
+	Short:             topicsListShort,
+	Example:           topicsListExample,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeClustersAndTopic(1),
+	RunE:              topicsList,
+}
+
+// topicsDescribeCmd represents the topics describe command
+var topicsDescribeCmd = &cobra.Command{
+	Use:               "describe bootstrap_servers topic...",
+	Short:             topicsDescribeShort,
+	Long:              topicsDescribeLong,
+	Example:           topicsDescribeExample,
+	Args:              cobra.MinimumNArgs(2),
+	ValidArgsFunction: completeClusterAndTopics,
+	RunE:              topicsDescribe,
+}
+
+// topicsCreateCmd represents the topics create command
+var topicsCreateCmd = &cobra.Command{
+	Use:               "create bootstrap_servers topic",
+	Short:             topicsCreateShort,
+	Example:           topicsCreateExample,
+	Args:              cobra.ExactArgs(2),
+	ValidArgsFunction: completeClustersAndTopic(1),
+	RunE:              topicsCreate,
+}
+
+// topicsDeleteCmd represents the topics delete command
+var topicsDeleteCmd = &cobra.Command{
+	Use:               "delete bootstrap_servers topic...",
+	Short:             topicsDeleteShort,
+	Example:           topicsDeleteExample,
+	Args:              cobra.MinimumNArgs(2),
+	ValidArgsFunction: completeClusterAndTopics,
+	RunE:              topicsDelete,
+}
+
+// topicsAlterConfigCmd represents the topics alter-config command
+var topicsAlterConfigCmd = &cobra.Command{
+	Use:               "alter-config bootstrap_servers topic",
+	Short:             topicsAlterShort,
+	Example:           topicsAlterExample,
+	Args:              cobra.ExactArgs(2),
+	ValidArgsFunction: completeClustersAndTopic(2),
+	RunE:              topicsAlterConfig,
+}
+
+// topicsAddPartitionsCmd represents the topics add-partitions command
+var topicsAddPartitionsCmd = &cobra.Command{
+	Use:               "add-partitions bootstrap_servers topic",
+	Short:             topicsPartitionsShort,
+	Long:              topicsPartitionsLong,
+	Example:           topicsPartitionsExample,
+	Args:              cobra.ExactArgs(2),
+	ValidArgsFunction: completeClustersAndTopic(2),
+	RunE:              topicsAddPartitions,
+}
+
+func init() {
+	rootCmd.AddCommand(topicsCmd)
+	topicsCmd.AddCommand(topicsListCmd, topicsDescribeCmd, topicsCreateCmd, topicsDeleteCmd, topicsAlterConfigCmd, topicsAddPartitionsCmd)
+
+	topicsListCmd.Flags().Bool(internalTopics, false, "list the internal topics too.")
+	topicsListCmd.Flags().Bool(formatJSON, false, "write the topics as JSON instead of a table.")
+
+	topicsDescribeCmd.Flags().Bool(allConfigs, false, "write every configuration entry, including the default ones.")
+	topicsDescribeCmd.Flags().Bool(formatJSON, false, "write the descriptions as JSON instead of tables.")
+
+	topicsCreateCmd.Flags().Int32(partitions, 1, "number of partitions of the topic.")
+	topicsCreateCmd.Flags().Int16(replicationFactor, 1, "number of replicas of every partition.")
+	topicsCreateCmd.Flags().StringArray(setConfig, nil, "set a configuration entry of the topic, given as key=value (e.g. retention.ms=86400000).")
+
+	topicsAlterConfigCmd.Flags().StringArray(setConfig, nil, "set a configuration entry of the topic, given as key=value (e.g. retention.ms=86400000).")
+	topicsAlterConfigCmd.Flags().StringSlice(unsetConfig, nil, "remove a configuration entry of the topic, restoring its default value.")
+	topicsAlterConfigCmd.MarkFlagsOneRequired(setConfig, unsetConfig)
+
+	topicsAddPartitionsCmd.Flags().Int32(partitions, 0, "total number of partitions of the topic.")
+	topicsAddPartitionsCmd.MarkFlagRequired(partitions)
+
+	for _, cmd := range []*cobra.Command{topicsCreateCmd, topicsAlterConfigCmd} {
+		cmd.RegisterFlagCompletionFunc(setConfig, cobra.NoFileCompletions)
+	}
+	topicsAlterConfigCmd.RegisterFlagCompletionFunc(unsetConfig, cobra.NoFileCompletions)
+}
+
+// completeClusterAndTopics completes the cluster and then any number of its
+// topics.
+func completeClusterAndTopics(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	normArgs := colonWorkarround(args)
+	if len(normArgs) == 0 {
+		return completeClusters(cmd, normArgs, toComplete)
+	}
+	return completeTopic(cmd, normArgs[:1], toComplete)
+}
+
+// newClusterAdmin returns the admin of the given cluster and its client,
+// which is closed when the admin is.
+func newClusterAdmin(cmd *cobra.Command, name string) (sarama.ClusterAdmin, sarama.Client, error) {
+	cluster, err := getClusterConfig(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	config, err := newSaramaConfig(cmd, cluster)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	client, err := sarama.NewClient(cluster.brokerList(), config)
+	if err != nil {
+		return nil, nil, err
+	}
+	admin, err := sarama.NewClusterAdminFromClient(client)
+	if err != nil {
+		client.Close()
+		return nil, nil, err
+	}
+	return admin, client, nil
+}
+
+// writeJSON writes the value as indented JSON to the output of the command.
+func writeJSON(cmd *cobra.Command, value any) error {
+	encoder := json.NewEncoder(cmd.OutOrStdout())
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
+
+// joinIDs joins the broker IDs with commas.
+func joinIDs(ids []int32) string {
+	values := make([]string, 0, len(ids))
+	for _, id := range ids {
+		values = append(values, fmt.Sprint(id))
+	}
+	return strings.Join(values, ",")
+}
+
+func topicsList(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+
+	admin, _, err := newClusterAdmin(cmd, args[0])
+	if err != nil {
+		return err
+	}
+	defer admin.Close()
+
+	internal, _ := cmd.Flags().GetBool(internalTopics)
+	topics, err := kafkautils.ListTopics(admin, internal)
+	if err != nil {
+		return err
+	}
+
+	if asJSON, _ := cmd.Flags().GetBool(formatJSON); asJSON {
+		return writeJSON(cmd, topics)
+	}
+
+	writer := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "TOPIC\tPARTITIONS\tREPLICATION FACTOR")
+	for _, topic := range topics {
+		fmt.Fprintf(writer, "%s\t%d\t%d\n", topic.Name, topic.Partitions, topic.ReplicationFactor)
+	}
+	return writer.Flush()
+}
+
+func topicsDescribe(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+
+	admin, client, err := newClusterAdmin(cmd, args[0])
+	if err != nil {
+		return err
+	}
+	defer admin.Close()
+
+	all, _ := cmd.Flags().GetBool(allConfigs)
+	descriptions := make([]*kafkautils.TopicDescription, 0, len(args)-1)
+	for _, topic := range args[1:] {
+		description, err := kafkautils.DescribeTopic(admin, client, topic, all)
+		if err != nil {
+			return err
+		}
+		descriptions = append(descriptions, description)
+	}
+
+	if asJSON, _ := cmd.Flags().GetBool(formatJSON); asJSON {
+		return writeJSON(cmd, descriptions)
+	}
+
+	writer := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	for i, description := range descriptions {
+		if i > 0 {
+			fmt.Fprintln(writer)
+		}
+		internal := ""
+		if description.Internal {
+			internal = " (internal)"
+		}
+		fmt.Fprintf(writer, "Topic: %s%s\n\n", description.Name, internal)
+
+		fmt.Fprintln(writer, "PARTITION\tLEADER\tREPLICAS\tISR\tOLDEST\tNEWEST")
+		for _, partition := range description.Partitions {
+			fmt.Fprintf(writer, "%d\t%d\t%s\t%s\t%d\t%d\n",
+				partition.Partition, partition.Leader, joinIDs(partition.Replicas), joinIDs(partition.ISR), partition.Oldest, partition.Newest)
+		}
+
+		if len(description.Configs) > 0 {
+			fmt.Fprintln(writer)
+			fmt.Fprintln(writer, "CONFIG\tVALUE\tSOURCE")
+			for _, config := range description.Configs {
+				fmt.Fprintf(writer, "%s\t%s\t%s\n", config.Name, config.Value, config.Source)
+			}
+		}
+	}
+	return writer.Flush()
+}
+
+func topicsCreate(cmd *cobra.Command, args []string) error {
+	partitions, _ := cmd.Flags().GetInt32(partitions)
+	replicationFactor, _ := cmd.Flags().GetInt16(replicationFactor)
+	entries, _ := cmd.Flags().GetStringArray(setConfig)
+	configs, err := kafkautils.ParseConfigEntries(entries)
+	if err != nil {
+		return err
+	}
+	cmd.SilenceUsage = true
+
+	admin, _, err := newClusterAdmin(cmd, args[0])
+	if err != nil {
+		return err
+	}
+	defer admin.Close()
+
+	err = admin.CreateTopic(args[1], &sarama.TopicDetail{
+		NumPartitions:     partitions,
+		ReplicationFactor: replicationFactor,
+		ConfigEntries:     configs,
+	}, false)
+	if err != nil {
+		return err
+	}
+	logger.Printf("created topic %s with %d partitions and replication factor %d", args[1], partitions, replicationFactor)
+	return nil
+}
+
+func topicsDelete(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+
+	admin, _, err := newClusterAdmin(cmd, args[0])
+	if err != nil {
+		return err
+	}
+	defer admin.Close()
+
+	for _, topic := range args[1:] {
+		if err := admin.DeleteTopic(topic); err != nil {
+			return fmt.Errorf("topic %s: %w", topic, err)
+		}
+		logger.Printf("deleted topic %s", topic)
+	}
+	return nil
+}
+
+func topicsAlterConfig(cmd *cobra.Command, args []string) error {
+	setEntries, _ := cmd.Flags().GetStringArray(setConfig)
+	unsetEntries, _ := cmd.Flags().GetStringSlice(unsetConfig)
+	configs, err := kafkautils.ParseConfigEntries(setEntries)
+	if err != nil {
+		return err
+	}
+
+	entries := make(map[string]sarama.IncrementalAlterConfigsEntry, len(configs)+len(unsetEntries))
+	for key, value := range configs {
+		entries[key] = sarama.IncrementalAlterConfigsEntry{
+			Operation: sarama.IncrementalAlterConfigsOperationSet,
+			Value:     value,
+		}
+	}
+	for _, key := range unsetEntries {
+		if _, found := entries[key]; found {
+			return fmt.Errorf("configuration entry %s can't be both set and unset", key)
+		}
+		entries[key] = sarama.IncrementalAlterConfigsEntry{
+			Operation: sarama.IncrementalAlterConfigsOperationDelete,
+		}
+	}
+	cmd.SilenceUsage = true
+
+	admin, client, err := newClusterAdmin(cmd, args[0])
+	if err != nil {
+		return err
+	}
+	defer admin.Close()
+
+	if err := alterTopicConfig(admin, client.Config().Version, args[1], entries); err != nil {
+		return err
+	}
+	logger.Printf("altered %d configuration entries of topic %s", len(entries), args[1])
+	return nil
+}
+
+// alterTopicConfig alters the given configuration entries of the topic,
+// keeping the other ones. Kafka versions before 2.3.0 can't alter entries
+// incrementally, so the entries of the topic are described and altered as a
+// whole, losing the changes made by others in between.
+func alterTopicConfig(admin sarama.ClusterAdmin, version sarama.KafkaVersion, topic string, entries map[string]sarama.IncrementalAlterConfigsEntry) error {
+	if version.IsAtLeast(sarama.V2_3_0_0) {
+		return admin.IncrementalAlterConfig(sarama.TopicResource, topic, entries, false)
+	}
+
+	current, err := admin.DescribeConfig(sarama.ConfigResource{Type: sarama.TopicResource, Name: topic})
+	if err != nil {
+		return err
+	}
+	configs := make(map[string]*string, len(current)+len(entries))
+	for _, entry := range current {
+		// Keep the entries set for the topic, which older versions only tell
+		// by not being default values
+		set := entry.Source == sarama.SourceTopic || (entry.Source == sarama.SourceUnknown && !entry.Default)
+		if !set || entry.ReadOnly {
+			continue
+		}
+		if _, altered := entries[entry.Name]; entry.Sensitive && !altered {
+			return fmt.Errorf("configuration entry %s is sensitive and can't be kept by Kafka versions before 2.3.0, given by --%s", entry.Name, kafkaVersion)
+		}
+		value := entry.Value
+		configs[entry.Name] = &value
+	}
+	for key, entry := range entries {
+		if entry.Operation == sarama.IncrementalAlterConfigsOperationDelete {
+			delete(configs, key)
+		} else {
+			configs[key] = entry.Value
+		}
+	}
+	return admin.AlterConfig(sarama.TopicResource, topic, configs, false)
+}
+
+func topicsAddPartitions(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+
+	partitions, _ := cmd.Flags().GetInt32(partitions)
+
+	admin, _, err := newClusterAdmin(cmd, args[0])
+	if err != nil {
+		return err
+	}
+	defer admin.Close()
+
+	if err := admin.CreatePartitions(args[1], partitions, nil, false); err != nil {
+		return err
+	}
+	logger.Printf("topic %s has now %d partitions", args[1], partitions)
+	return nil
+}
@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/IBM/sarama"
+)
+
+func TestAlterTopicConfigBeforeKafka23(t *testing.T) {
+	broker := sarama.NewMockBroker(t, 1)
+	t.Cleanup(broker.Close)
+
+	broker.SetHandlerByMap(map[string]sarama.MockResponse{
+		"MetadataRequest": sarama.NewMockMetadataResponse(t).
+			SetController(broker.BrokerID()).
+			SetBroker(broker.Addr(), broker.BrokerID()),
+		"DescribeConfigsRequest": sarama.NewMockWrapper(&sarama.DescribeConfigsResponse{
+			Version: 2,
+			Resources: []*sarama.ResourceResponse{{
+				Type: sarama.TopicResource,
+				Name: "orders",
+				Configs: []*sarama.ConfigEntry{
+					{Name: "retention.ms", Value: "5000", Source: sarama.SourceTopic},
+					{Name: "cleanup.policy", Value: "compact", Source: sarama.SourceTopic},
+					{Name: "segment.bytes", Value: "1024", Source: sarama.SourceStaticBroker},
+					{Name: "max.message.bytes", Value: "1000000", Source: sarama.SourceDefault, Default: true},
+				},
+			}},
+		}),
+		"AlterConfigsRequest": sarama.NewMockAlterConfigsResponse(t),
+	})
+
+	config := sarama.NewConfig()
+	config.Version = sarama.V2_1_0_0
+	admin, err := sarama.NewClusterAdmin([]string{broker.Addr()}, config)
+	if err != nil {
+		t.Fatalf("NewClusterAdmin failed: %v", err)
+	}
+	t.Cleanup(func() { admin.Close() })
+
+	maxMessageBytes := "2000"
+	err = alterTopicConfig(admin, config.Version, "orders", map[string]sarama.IncrementalAlterConfigsEntry{
+		"max.message.bytes": {Operation: sarama.IncrementalAlterConfigsOperationSet, Value: &maxMessageBytes},
+		"cleanup.policy":    {Operation: sarama.IncrementalAlterConfigsOperationDelete},
+	})
+	if err != nil {
+		t.Fatalf("alterTopicConfig failed: %v", err)
+	}
+
+	// The entries of the topic are altered as a whole, keeping the ones not
+	// given
+	var request *sarama.AlterConfigsRequest
+	for _, exchange := range broker.History() {
+		if alter, ok := exchange.Request.(*sarama.AlterConfigsRequest); ok {
+			request = alter
+		}
+	}
+	if request == nil || len(request.Resources) != 1 {
+		t.Fatalf("expected the entries of the topic to be altered but got %v", request)
+	}
+	got := make(map[string]string)
+	for key, value := range request.Resources[0].ConfigEntries {
+		got[key] = *value
+	}
+	if len(got) != 2 || got["retention.ms"] != "5000" || got["max.message.bytes"] != "2000" {
+		t.Errorf("expected retention.ms and max.message.bytes but got %v", got)
+	}
+}
@@ -0,0 +1,131 @@
+/*
+Copyright © 2023 VECI Group Tech S.L.
+This file is part of kafka-client.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bluekiri/kafka-client/internal/filters"
+	"github.com/bluekiri/kafka-client/internal/redact"
+	"github.com/bluekiri/kafka-client/internal/transforms"
+
+	"github.com/spf13/cobra"
+)
+
+func addTransformFlags(cmd *cobra.Command) {
+	cmd.Flags().String(setKey, "", "replace the key of the messages by the result of the given CEL expression, which can use the same variables as --filter (e.g. 'value.customer.id'). The key is encoded using --key-format.")
+	cmd.Flags().StringSlice(removeHeader, nil, "remove the headers with the given key.")
+	cmd.Flags().StringSlice(renameHeader, nil, "rename the headers with the given key, given as from=to.")
+	cmd.Flags().StringArray(addHeader, nil, "add a header, given as key=value.")
+	cmd.Flags().StringSlice(renameField, nil, "move a field of the values to another path, given as from=to (e.g. customer.mail=contact.email). Fields are given by dot separated paths.")
+	cmd.Flags().StringSlice(mask, nil, "mask a field of the values, given by its dot separated path (e.g. customer.email). Strings are replaced by **** and other values by null.")
+	cmd.Flags().String(toValueFormat, "", "encode the values in the given format once transformed: "+codecFormats+" (default the value format).")
+
+	cmd.RegisterFlagCompletionFunc(toValueFormat, wrapCompletion(completeCodecFormat, bindFlags))
+}
+
+// getTransform returns the transform given by the transform flags, or nil if
+// no transform is given. The transform decodes the messages consumed from the
+// input topic using the key and value formats and encodes them for the output
+// topic. Keys are rewritten first, then headers are removed, renamed and
+// added, and finally the fields of the values are renamed and redacted.
+func getTransform(cmd *cobra.Command, inputTopic string, outputTopic string) (transforms.Transform, error) {
+	if err := bindFlags(cmd); err != nil {
+		return nil, err
+	}
+
+	var chain []transforms.Transform
+	keyFormat, _ := cmd.Flags().GetString(keyFormat)
+	valueFormat := getValueFormat(cmd)
+
+	if expression, _ := cmd.Flags().GetString(setKey); expression != "" {
+		keyCodec, err := getCodec(cmd, keyFormat, keySubject(inputTopic))
+		if err != nil {
+			return nil, err
+		}
+		valueCodec, err := getCodec(cmd, valueFormat, valueSubject(inputTopic))
+		if err != nil {
+			return nil, err
+		}
+		compiled, err := filters.NewExpression(expression, keyCodec, valueCodec)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --%s expression: %w", setKey, err)
+		}
+		outputKeyCodec, err := getCodec(cmd, keyFormat, keySubject(outputTopic))
+		if err != nil {
+			return nil, err
+		}
+		chain = append(chain, transforms.SetKey(compiled, outputKeyCodec))
+	}
+
+	removeHeaders, _ := cmd.Flags().GetStringSlice(removeHeader)
+	for _, key := range removeHeaders {
+		chain = append(chain, transforms.RemoveHeader(key))
+	}
+	renameHeaders, _ := cmd.Flags().GetStringSlice(renameHeader)
+	for _, rename := range renameHeaders {
+		from, to, found := strings.Cut(rename, "=")
+		if !found {
+			return nil, fmt.Errorf("invalid --%s '%s', expected from=to", renameHeader, rename)
+		}
+		chain = append(chain, transforms.RenameHeader(from, to))
+	}
+	addHeaders, _ := cmd.Flags().GetStringArray(addHeader)
+	for _, header := range addHeaders {
+		key, value, found := strings.Cut(header, "=")
+		if !found {
+			return nil, fmt.Errorf("invalid --%s '%s', expected key=value", addHeader, header)
+		}
+		chain = append(chain, transforms.AddHeader(key, value))
+	}
+
+	renameFields, _ := cmd.Flags().GetStringSlice(renameField)
+	redactions, err := getRedactFields(cmd)
+	if err != nil {
+		return nil, err
+	}
+	maskFields, _ := cmd.Flags().GetStringSlice(mask)
+	for _, path := range maskFields {
+		redactions = append(redactions, redact.Field{Path: path, Policy: redact.Mask})
+	}
+	redactor, err := newRedactor(redactions)
+	if err != nil {
+		return nil, err
+	}
+	outputValueFormat, _ := cmd.Flags().GetString(toValueFormat)
+	if len(renameFields) > 0 || redactor != nil || outputValueFormat != "" {
+		options := transforms.ValueOptions{Redactor: redactor}
+		for _, rename := range renameFields {
+			from, to, found := strings.Cut(rename, "=")
+			if !found {
+				return nil, fmt.Errorf("invalid --%s '%s', expected from=to", renameField, rename)
+			}
+			options.RenameFields = append(options.RenameFields, transforms.FieldRename{From: from, To: to})
+		}
+
+		if options.Codec, err = getCodec(cmd, valueFormat, valueSubject(inputTopic)); err != nil {
+			return nil, err
+		}
+		if outputValueFormat == "" {
+			outputValueFormat = valueFormat
+		}
+		if options.OutputCodec, err = getCodec(cmd, outputValueFormat, valueSubject(outputTopic)); err != nil {
+			return nil, err
+		}
+
+		transform, err := transforms.NewValueTransform(options)
+		if err != nil {
+			return nil, err
+		}
+		chain = append(chain, transform)
+	}
+
+	if len(chain) == 0 {
+		return nil, nil
+	}
+	return transforms.Chain(chain...), nil
+}
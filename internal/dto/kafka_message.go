@@ -5,7 +5,32 @@ This file is part of kafka-client.
 
 package dto
 
-type KafkaMessage struct {
+import "time"
+
+type KafkaHeader struct {
 	Key   []byte
 	Value []byte
 }
+
+type KafkaMessage struct {
+	Key     []byte
+	Value   []byte
+	Headers []KafkaHeader
+
+	// Timestamp, Partition and Offset are only known for consumed messages.
+	Timestamp time.Time
+	Partition int32
+	Offset    int64
+
+	// OnDone, if not nil, is called once the message has been successfully
+	// written or produced by an output handler.
+	OnDone func()
+}
+
+// Done notifies the source of the message that it has been successfully
+// handled.
+func (message *KafkaMessage) Done() {
+	if message.OnDone != nil {
+		message.OnDone()
+	}
+}
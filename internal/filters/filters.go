@@ -0,0 +1,165 @@
+/*
+Copyright © 2023 VECI Group Tech S.L.
+This file is part of kafka-client.
+*/
+
+package filters
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/bluekiri/kafka-client/internal/dto"
+	"github.com/bluekiri/kafka-client/internal/formatters"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+)
+
+// Filter selects the messages to handle.
+type Filter interface {
+	// Match reports whether the message must be handled.
+	Match(message *dto.KafkaMessage) (bool, error)
+}
+
+// NewExpressionFilter returns a Filter selecting the messages for which the
+// given CEL (https://cel.dev) expression, see NewExpression, is true.
+func NewExpressionFilter(expression string, keyCodec formatters.Codec, valueCodec formatters.Codec) (Filter, error) {
+	compiled, err := NewExpression(expression, keyCodec, valueCodec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid filter: %w", err)
+	}
+	if outputType := compiled.outputType; outputType != cel.BoolType && outputType != cel.DynType {
+		return nil, fmt.Errorf("invalid filter: expected a bool expression but got %v", outputType)
+	}
+
+	return &expressionFilter{compiled}, nil
+}
+
+type expressionFilter struct {
+	expression *Expression
+}
+
+func (filter *expressionFilter) Match(message *dto.KafkaMessage) (bool, error) {
+	result, err := filter.expression.Eval(message)
+	if err != nil {
+		return false, err
+	}
+
+	matched, ok := result.Value().(bool)
+	if !ok {
+		return false, fmt.Errorf("filter: expected a bool result but got %v", result)
+	}
+	return matched, nil
+}
+
+// Expression is a CEL (https://cel.dev) expression evaluated against
+// messages. The expression can use the following variables:
+//
+//   - key: the key of the message, represented by the key codec.
+//   - value: the value of the message, represented by the value codec.
+//   - headers: the headers of the message as a map(string, string).
+//   - partition and offset: the partition and offset of the message as ints.
+//   - timestamp: the timestamp of the message.
+//
+// Keys and values represented as JSON, like protobuf and Avro messages or
+// JSON text, are available as JSON values, so their fields can be selected
+// (e.g. value.status == "FAILED"), while other representations are available
+// as strings. Null keys and values are null. Keys and values are only decoded
+// if the expression uses them.
+type Expression struct {
+	program    cel.Program
+	outputType *cel.Type
+	keyCodec   formatters.Codec
+	valueCodec formatters.Codec
+}
+
+// NewExpression compiles the given CEL expression.
+func NewExpression(expression string, keyCodec formatters.Codec, valueCodec formatters.Codec) (*Expression, error) {
+	env, err := cel.NewEnv(
+		cel.Variable("key", cel.DynType),
+		cel.Variable("value", cel.DynType),
+		cel.Variable("headers", cel.MapType(cel.StringType, cel.StringType)),
+		cel.Variable("partition", cel.IntType),
+		cel.Variable("offset", cel.IntType),
+		cel.Variable("timestamp", cel.TimestampType),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	ast, issues := env.Compile(expression)
+	if issues.Err() != nil {
+		return nil, issues.Err()
+	}
+
+	program, err := env.Program(ast)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Expression{program, ast.OutputType(), keyCodec, valueCodec}, nil
+}
+
+// Eval evaluates the expression against the message.
+func (expression *Expression) Eval(message *dto.KafkaMessage) (ref.Val, error) {
+	headers := make(map[string]string, len(message.Headers))
+	for _, header := range message.Headers {
+		headers[string(header.Key)] = string(header.Value)
+	}
+
+	timestamp := message.Timestamp
+	if timestamp.IsZero() {
+		timestamp = time.Unix(0, 0)
+	}
+
+	result, _, err := expression.program.Eval(map[string]any{
+		"key":       lazyDecode(expression.keyCodec, message.Key),
+		"value":     lazyDecode(expression.valueCodec, message.Value),
+		"headers":   headers,
+		"partition": int64(message.Partition),
+		"offset":    message.Offset,
+		"timestamp": timestamp,
+	})
+	return result, err
+}
+
+// lazyDecode returns a function decoding the data when called, so only the
+// data used by the expression is decoded.
+func lazyDecode(codec formatters.Codec, data []byte) func() ref.Val {
+	return func() ref.Val {
+		value, err := decode(codec, data)
+		if err != nil {
+			return types.WrapErr(err)
+		}
+		return types.DefaultTypeAdapter.NativeToValue(value)
+	}
+}
+
+// decode returns the JSON value represented by the data, or its textual
+// representation if it is not JSON.
+func decode(codec formatters.Codec, data []byte) (any, error) {
+	if data == nil {
+		return nil, nil
+	}
+
+	text, err := codec.Format(data)
+	if err != nil {
+		return nil, err
+	}
+
+	// Text holding JSON objects or arrays is decoded too
+	trimmed := bytes.TrimSpace(text)
+	if codec.IsJSON() || bytes.HasPrefix(trimmed, []byte("{")) || bytes.HasPrefix(trimmed, []byte("[")) {
+		var value any
+		if err := json.Unmarshal(text, &value); err == nil {
+			return value, nil
+		} else if codec.IsJSON() {
+			return nil, err
+		}
+	}
+	return string(text), nil
+}
@@ -0,0 +1,109 @@
+package filters_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bluekiri/kafka-client/internal/dto"
+	"github.com/bluekiri/kafka-client/internal/filters"
+	"github.com/bluekiri/kafka-client/internal/formatters"
+)
+
+var testMessage = &dto.KafkaMessage{
+	Key:   []byte("order-1"),
+	Value: []byte(`{"status":"FAILED","items":[{"id":1},{"id":2}]}`),
+	Headers: []dto.KafkaHeader{
+		{Key: []byte("tenant"), Value: []byte("x")},
+	},
+	Timestamp: time.Date(2023, 5, 1, 10, 0, 0, 0, time.UTC),
+	Partition: 2,
+	Offset:    42,
+}
+
+func TestExpressionFilter(t *testing.T) {
+	testCases := map[string]bool{
+		`value.status == "FAILED" && headers["tenant"] == "x"`: true,
+		`value.status == "OK"`:                                 false,
+		`key.startsWith("order-")`:                             true,
+		`size(value.items) == 2 && value.items[1].id == 2.0`:   true,
+		`"tenant" in headers && !("user" in headers)`:          true,
+		`partition == 2 && offset >= 40`:                       true,
+		`timestamp > timestamp("2023-06-01T00:00:00Z")`:        false,
+		`key == "order-1" || value.missing == 1`:               true,
+	}
+
+	for expression, expected := range testCases {
+		t.Run(expression, func(t *testing.T) {
+			filter, err := filters.NewExpressionFilter(expression, formatters.TextCodec, formatters.TextCodec)
+			if err != nil {
+				t.Fatalf("NewExpressionFilter failed: %v", err)
+			}
+			matched, err := filter.Match(testMessage)
+			if err != nil {
+				t.Fatalf("Match failed: %v", err)
+			}
+			if matched != expected {
+				t.Errorf("Expected %v but got %v", expected, matched)
+			}
+		})
+	}
+}
+
+func TestExpressionFilterNull(t *testing.T) {
+	filter, err := filters.NewExpressionFilter(`value == null`, formatters.TextCodec, formatters.TextCodec)
+	if err != nil {
+		t.Fatalf("NewExpressionFilter failed: %v", err)
+	}
+	matched, err := filter.Match(&dto.KafkaMessage{Key: []byte("tombstone")})
+	if err != nil {
+		t.Fatalf("Match failed: %v", err)
+	}
+	if !matched {
+		t.Error("Expected the tombstone to match")
+	}
+}
+
+func TestExpressionFilterCodec(t *testing.T) {
+	filter, err := filters.NewExpressionFilter(`key == "6f726465722d31"`, formatters.HexCodec, formatters.TextCodec)
+	if err != nil {
+		t.Fatalf("NewExpressionFilter failed: %v", err)
+	}
+	matched, err := filter.Match(testMessage)
+	if err != nil {
+		t.Fatalf("Match failed: %v", err)
+	}
+	if !matched {
+		t.Error("Expected the key to be represented by the key codec")
+	}
+}
+
+func TestInvalidExpressionFilter(t *testing.T) {
+	for _, expression := range []string{`value.status ==`, `partition + 1`, `unknown == 1`} {
+		if _, err := filters.NewExpressionFilter(expression, formatters.TextCodec, formatters.TextCodec); err == nil {
+			t.Errorf("NewExpressionFilter should have failed for '%s'", expression)
+		}
+	}
+}
+
+func TestExpressionFilterErrors(t *testing.T) {
+	testCases := map[string]formatters.Codec{
+		// The key is not a JSON object
+		`key.status == "FAILED"`: formatters.TextCodec,
+		// The value is not a valid number
+		`value == 1`: formatters.Int64Codec,
+		// The result is not a bool
+		`value.status`: formatters.TextCodec,
+	}
+
+	for expression, codec := range testCases {
+		t.Run(expression, func(t *testing.T) {
+			filter, err := filters.NewExpressionFilter(expression, formatters.TextCodec, codec)
+			if err != nil {
+				t.Fatalf("NewExpressionFilter failed: %v", err)
+			}
+			if _, err := filter.Match(testMessage); err == nil {
+				t.Error("Match should have failed")
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+/*
+Copyright © 2023 VECI Group Tech S.L.
+This file is part of kafka-client.
+*/
+
+package filters
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/bluekiri/kafka-client/internal/dto"
+	"github.com/bluekiri/kafka-client/internal/formatters"
+	"github.com/bluekiri/kafka-client/internal/jsonutils"
+)
+
+// SearchOptions are the options of the search Filter.
+type SearchOptions struct {
+	// Pattern is the regular expression searched for. If nil, the messages
+	// match if Path selects any node of their values.
+	Pattern *regexp.Regexp
+
+	// Path, if not nil, selects the nodes of the values where the pattern is
+	// searched for. Values that are not JSON have no nodes.
+	Path *jsonutils.JSONPath
+
+	// Keys, Headers and Values tell where the pattern is searched for. Headers
+	// are searched as name=value and keys and values using the textual
+	// representation given by their codecs.
+	Keys    bool
+	Headers bool
+	Values  bool
+
+	KeyCodec   formatters.Codec
+	ValueCodec formatters.Codec
+}
+
+// NewSearchFilter returns a Filter selecting the messages whose key, headers
+// or value match the search options.
+func NewSearchFilter(options SearchOptions) (Filter, error) {
+	if options.Pattern == nil && options.Path == nil {
+		return nil, errors.New("invalid search: a pattern or a JSONPath is required")
+	}
+	if !options.Keys && !options.Headers && !options.Values {
+		return nil, errors.New("invalid search: nothing to search in")
+	}
+	if options.Path != nil && !options.Values {
+		return nil, errors.New("invalid search: a JSONPath only applies to the values")
+	}
+	if options.Pattern == nil && (options.Keys || options.Headers) {
+		return nil, errors.New("invalid search: a pattern is required to search in keys and headers")
+	}
+
+	return &searchFilter{options}, nil
+}
+
+type searchFilter struct {
+	options SearchOptions
+}
+
+func (filter *searchFilter) Match(message *dto.KafkaMessage) (bool, error) {
+	pattern := filter.options.Pattern
+
+	if filter.options.Keys && message.Key != nil {
+		key, err := filter.options.KeyCodec.Format(message.Key)
+		if err != nil {
+			return false, fmt.Errorf("search: %w", err)
+		}
+		if pattern.Match(key) {
+			return true, nil
+		}
+	}
+
+	if filter.options.Headers {
+		for _, header := range message.Headers {
+			if pattern.MatchString(string(header.Key) + "=" + string(header.Value)) {
+				return true, nil
+			}
+		}
+	}
+
+	if filter.options.Values && message.Value != nil {
+		value, err := filter.options.ValueCodec.Format(message.Value)
+		if err != nil {
+			return false, fmt.Errorf("search: %w", err)
+		}
+		if filter.options.Path != nil {
+			return filter.matchPath(value)
+		}
+		if pattern.Match(value) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// matchPath reports whether any node of the value selected by the path
+// matches the pattern. Strings are matched without quotes and other nodes
+// using their JSON text.
+func (filter *searchFilter) matchPath(value []byte) (bool, error) {
+	trimmed := bytes.TrimSpace(value)
+	if !filter.options.ValueCodec.IsJSON() && !bytes.HasPrefix(trimmed, []byte("{")) && !bytes.HasPrefix(trimmed, []byte("[")) {
+		return false, nil
+	}
+	document, err := jsonutils.Decode(value)
+	if err != nil {
+		if filter.options.ValueCodec.IsJSON() {
+			return false, fmt.Errorf("search: %w", err)
+		}
+		return false, nil
+	}
+
+	nodes := filter.options.Path.Select(document)
+	if filter.options.Pattern == nil {
+		return len(nodes) > 0, nil
+	}
+	for _, node := range nodes {
+		text, isString := node.(string)
+		if !isString {
+			encoded, err := jsonutils.Encode(node)
+			if err != nil {
+				return false, fmt.Errorf("search: %w", err)
+			}
+			text = string(encoded)
+		}
+		if filter.options.Pattern.MatchString(text) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
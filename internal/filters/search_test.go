@@ -0,0 +1,115 @@
+package filters_test
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/bluekiri/kafka-client/internal/dto"
+	"github.com/bluekiri/kafka-client/internal/filters"
+	"github.com/bluekiri/kafka-client/internal/formatters"
+	"github.com/bluekiri/kafka-client/internal/jsonutils"
+)
+
+func TestSearchFilter(t *testing.T) {
+	testCases := map[string]struct {
+		pattern  string
+		path     string
+		in       [3]bool
+		expected bool
+	}{
+		"key":                    {pattern: "^order-1$", in: [3]bool{true, true, true}, expected: true},
+		"key not searched":       {pattern: "^order-1$", in: [3]bool{false, true, true}, expected: false},
+		"header":                 {pattern: "^tenant=x$", in: [3]bool{true, true, true}, expected: true},
+		"header not searched":    {pattern: "^tenant=x$", in: [3]bool{true, false, true}, expected: false},
+		"value":                  {pattern: `"status":"FAILED"`, in: [3]bool{false, false, true}, expected: true},
+		"no match":               {pattern: "PENDING", in: [3]bool{true, true, true}, expected: false},
+		"path and pattern":       {pattern: "^FAILED$", path: "$.status", in: [3]bool{false, false, true}, expected: true},
+		"path and number":        {pattern: "^2$", path: "$.items[*].id", in: [3]bool{false, false, true}, expected: true},
+		"path without match":     {pattern: "^order", path: "$.status", in: [3]bool{false, false, true}, expected: false},
+		"path only":              {path: "$..id", in: [3]bool{false, false, true}, expected: true},
+		"path selecting nothing": {path: "$.customer", in: [3]bool{false, false, true}, expected: false},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			options := filters.SearchOptions{
+				Keys:       testCase.in[0],
+				Headers:    testCase.in[1],
+				Values:     testCase.in[2],
+				KeyCodec:   formatters.TextCodec,
+				ValueCodec: formatters.TextCodec,
+			}
+			if testCase.pattern != "" {
+				options.Pattern = regexp.MustCompile(testCase.pattern)
+			}
+			if testCase.path != "" {
+				path, err := jsonutils.ParseJSONPath(testCase.path)
+				if err != nil {
+					t.Fatalf("ParseJSONPath failed: %v", err)
+				}
+				options.Path = path
+			}
+
+			filter, err := filters.NewSearchFilter(options)
+			if err != nil {
+				t.Fatalf("NewSearchFilter failed: %v", err)
+			}
+			matched, err := filter.Match(testMessage)
+			if err != nil {
+				t.Fatalf("Match failed: %v", err)
+			}
+			if matched != testCase.expected {
+				t.Errorf("Expected %v but got %v", testCase.expected, matched)
+			}
+		})
+	}
+}
+
+func TestSearchFilterNotJSON(t *testing.T) {
+	path, err := jsonutils.ParseJSONPath("$.status")
+	if err != nil {
+		t.Fatalf("ParseJSONPath failed: %v", err)
+	}
+	filter, err := filters.NewSearchFilter(filters.SearchOptions{
+		Path:       path,
+		Values:     true,
+		ValueCodec: formatters.TextCodec,
+	})
+	if err != nil {
+		t.Fatalf("NewSearchFilter failed: %v", err)
+	}
+
+	// Text values that are not JSON have no nodes
+	for _, value := range []string{"status FAILED", "{not json"} {
+		matched, err := filter.Match(&dto.KafkaMessage{Value: []byte(value)})
+		if err != nil {
+			t.Fatalf("Match failed: %v", err)
+		}
+		if matched {
+			t.Errorf("Expected '%s' not to match", value)
+		}
+	}
+}
+
+func TestInvalidSearchFilter(t *testing.T) {
+	path, err := jsonutils.ParseJSONPath("$.status")
+	if err != nil {
+		t.Fatalf("ParseJSONPath failed: %v", err)
+	}
+	pattern := regexp.MustCompile("x")
+
+	testCases := map[string]filters.SearchOptions{
+		"nothing to search for": {Keys: true, Values: true},
+		"nothing to search in":  {Pattern: pattern},
+		"path without values":   {Pattern: pattern, Path: path, Keys: true},
+		"keys without pattern":  {Path: path, Keys: true, Values: true},
+	}
+
+	for name, options := range testCases {
+		t.Run(name, func(t *testing.T) {
+			if _, err := filters.NewSearchFilter(options); err == nil {
+				t.Fatal("NewSearchFilter should have failed")
+			}
+		})
+	}
+}
@@ -0,0 +1,153 @@
+/*
+Copyright © 2023 VECI Group Tech S.L.
+This file is part of kafka-client.
+*/
+
+package formatters
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bluekiri/kafka-client/internal/jsonutils"
+	"github.com/bluekiri/kafka-client/internal/registry"
+	"github.com/linkedin/goavro/v2"
+)
+
+// NewAvroCodec returns a Codec that represents the data, Avro binary encoded
+// with the given schema, using its Avro JSON representation.
+func NewAvroCodec(schema string) (Codec, error) {
+	codec, err := goavro.NewCodec(schema)
+	if err != nil {
+		return nil, fmt.Errorf("avro: %w", err)
+	}
+	return &avroCodec{codec}, nil
+}
+
+type avroCodec struct {
+	codec *goavro.Codec
+}
+
+func (codec *avroCodec) Format(data []byte) ([]byte, error) {
+	return formatAvro(codec.codec, data)
+}
+
+func (codec *avroCodec) Parse(text []byte) ([]byte, error) {
+	return parseAvro(codec.codec, nil, text)
+}
+
+func (codec *avroCodec) IsJSON() bool {
+	return true
+}
+
+// NewRegistryAvroCodec returns a Codec that represents the data, Avro binary
+// encoded in the Confluent wire format, using its Avro JSON representation.
+//
+// The schema used to format the data is fetched from the registry by the
+// schema ID found in the data, while the data is parsed using the latest
+// schema registered under the given subject.
+//
+// The requests to the registry are canceled with the given context, as codecs
+// are called without one.
+func NewRegistryAvroCodec(ctx context.Context, client *registry.Client, subject string) Codec {
+	return &registryAvroCodec{
+		ctx:     ctx,
+		client:  client,
+		subject: subject,
+		codecs:  make(map[int]*goavro.Codec),
+	}
+}
+
+type registryAvroCodec struct {
+	ctx     context.Context
+	client  *registry.Client
+	subject string
+	codecs  map[int]*goavro.Codec
+}
+
+func (codec *registryAvroCodec) Format(data []byte) ([]byte, error) {
+	id, payload, err := registry.SplitWireFormat(data)
+	if err != nil {
+		return nil, err
+	}
+
+	schema, err := codec.client.SchemaByID(codec.ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	avro, err := codec.codec(schema)
+	if err != nil {
+		return nil, err
+	}
+	return formatAvro(avro, payload)
+}
+
+func (codec *registryAvroCodec) Parse(text []byte) ([]byte, error) {
+	schema, err := codec.client.LatestSchema(codec.ctx, codec.subject)
+	if err != nil {
+		return nil, err
+	}
+	avro, err := codec.codec(schema)
+	if err != nil {
+		return nil, err
+	}
+	return parseAvro(avro, registry.AppendWireFormat(nil, schema.ID), text)
+}
+
+func (codec *registryAvroCodec) IsJSON() bool {
+	return true
+}
+
+// codec returns the Avro codec of the schema, creating it if not created yet.
+func (codec *registryAvroCodec) codec(schema *registry.Schema) (*goavro.Codec, error) {
+	if avro, found := codec.codecs[schema.ID]; found {
+		return avro, nil
+	}
+
+	if schema.Type() != registry.SchemaTypeAvro {
+		return nil, fmt.Errorf("avro: schema %d is a %s schema", schema.ID, schema.Type())
+	}
+	if len(schema.References) > 0 {
+		return nil, fmt.Errorf("avro: schema %d has references, which are not supported", schema.ID)
+	}
+	avro, err := goavro.NewCodec(schema.Schema)
+	if err != nil {
+		return nil, fmt.Errorf("avro: schema %d: %w", schema.ID, err)
+	}
+	codec.codecs[schema.ID] = avro
+	return avro, nil
+}
+
+func formatAvro(codec *goavro.Codec, data []byte) ([]byte, error) {
+	native, remaining, err := codec.NativeFromBinary(data)
+	if err != nil {
+		return nil, fmt.Errorf("avro: %w", err)
+	}
+	if len(remaining) > 0 {
+		return nil, fmt.Errorf("avro: %d unexpected bytes after the data", len(remaining))
+	}
+	text, err := codec.TextualFromNative(nil, native)
+	if err != nil {
+		return nil, fmt.Errorf("avro: %w", err)
+	}
+	return sortJSONKeys(text)
+}
+
+// sortJSONKeys sorts the keys of the JSON objects, as goavro writes the fields
+// of records in random order.
+func sortJSONKeys(text []byte) ([]byte, error) {
+	value, err := jsonutils.Decode(text)
+	if err != nil {
+		return nil, err
+	}
+	return jsonutils.Encode(value)
+}
+
+// parseAvro appends the binary encoding of the Avro JSON text to prefix.
+func parseAvro(codec *goavro.Codec, prefix []byte, text []byte) ([]byte, error) {
+	native, _, err := codec.NativeFromTextual(text)
+	if err != nil {
+		return nil, fmt.Errorf("avro: %w", err)
+	}
+	return codec.BinaryFromNative(prefix, native)
+}
@@ -0,0 +1,113 @@
+package formatters_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/bluekiri/kafka-client/internal/formatters"
+	"github.com/bluekiri/kafka-client/internal/registry"
+	"github.com/bluekiri/kafka-client/internal/registry/registrytest"
+)
+
+const avroSchema = `{
+	"type": "record",
+	"name": "Test",
+	"fields": [
+		{"name": "value", "type": "string"},
+		{"name": "count", "type": ["null", "long"], "default": null}
+	]
+}`
+
+// The Avro binary encoding of {"count":{"long":2},"value":"abc"}
+var avroData = []byte{0x06, 'a', 'b', 'c', 0x02, 0x04}
+
+const avroText = `{"count":{"long":2},"value":"abc"}`
+
+func TestAvroCodec(t *testing.T) {
+	codec, err := formatters.NewAvroCodec(avroSchema)
+	if err != nil {
+		t.Fatalf("NewAvroCodec failed: %v", err)
+	}
+
+	text, err := codec.Format(avroData)
+	if err != nil {
+		t.Fatalf("Format failed: %v", err)
+	}
+	if string(text) != avroText {
+		t.Errorf("Expected text '%s' but got '%s'", avroText, text)
+	}
+
+	data, err := codec.Parse([]byte(avroText))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if !bytes.Equal(data, avroData) {
+		t.Errorf("Expected data '%v' but got '%v'", avroData, data)
+	}
+}
+
+func TestAvroCodecInvalidSchema(t *testing.T) {
+	if _, err := formatters.NewAvroCodec(`{"type": "unknown"}`); err == nil {
+		t.Fatal("NewAvroCodec should have failed")
+	}
+}
+
+func TestRegistryAvroCodec(t *testing.T) {
+	server := registrytest.NewServer(
+		registry.Schema{ID: 7, Subject: "test-value", Version: 1, Schema: `"string"`},
+		registry.Schema{ID: 8, Subject: "test-value", Version: 2, Schema: avroSchema},
+	)
+	defer server.Close()
+
+	codec := formatters.NewRegistryAvroCodec(context.Background(), registry.NewClient(server.URL, 0), "test-value")
+
+	// Data is formatted using the schema given by its schema ID
+	text, err := codec.Format(append(registry.AppendWireFormat(nil, 7), 0x06, 'a', 'b', 'c'))
+	if err != nil {
+		t.Fatalf("Format failed: %v", err)
+	}
+	if string(text) != `"abc"` {
+		t.Errorf(`Expected text '"abc"' but got '%s'`, text)
+	}
+
+	// Text is parsed using the latest schema of the subject
+	data, err := codec.Parse([]byte(avroText))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	expected := append(registry.AppendWireFormat(nil, 8), avroData...)
+	if !bytes.Equal(data, expected) {
+		t.Errorf("Expected data '%v' but got '%v'", expected, data)
+	}
+
+	text, err = codec.Format(data)
+	if err != nil {
+		t.Fatalf("Format failed: %v", err)
+	}
+	if string(text) != avroText {
+		t.Errorf("Expected text '%s' but got '%s'", avroText, text)
+	}
+}
+
+func TestRegistryAvroCodecFormatError(t *testing.T) {
+	server := registrytest.NewServer(
+		registry.Schema{ID: 1, Subject: "test-value", Version: 1, SchemaType: registry.SchemaTypeProtobuf, Schema: `syntax = "proto3";`},
+	)
+	defer server.Close()
+
+	codec := formatters.NewRegistryAvroCodec(context.Background(), registry.NewClient(server.URL, 0), "test-value")
+
+	testCases := map[string][]byte{
+		"not wire format": avroData,
+		"unknown schema":  registry.AppendWireFormat(nil, 2),
+		"not avro schema": registry.AppendWireFormat(nil, 1),
+	}
+	for name, data := range testCases {
+		t.Run(name, func(t *testing.T) {
+			if _, err := codec.Format(data); err == nil {
+				t.Fatal("Format should have failed")
+			}
+		})
+	}
+}
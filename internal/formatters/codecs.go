@@ -0,0 +1,189 @@
+/*
+Copyright © 2023 VECI Group Tech S.L.
+This file is part of kafka-client.
+*/
+
+package formatters
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Codec converts the key or the value of a message between its bytes and
+// their textual representation.
+type Codec interface {
+	// Format returns the textual representation of the data.
+	Format(data []byte) ([]byte, error)
+
+	// Parse returns the data represented by the text.
+	Parse(text []byte) ([]byte, error)
+
+	// IsJSON reports whether the textual representation is a JSON value, so
+	// it can be embedded as is in a JSON document.
+	IsJSON() bool
+}
+
+var (
+	// TextCodec represents the data as UTF-8 text.
+	TextCodec Codec = textCodec{}
+
+	// Base64Codec represents the data using the standard base64 encoding.
+	Base64Codec Codec = base64Codec{}
+
+	// HexCodec represents the data using the hexadecimal encoding.
+	HexCodec Codec = hexCodec{}
+
+	// Int64Codec represents the data, a big endian 64 bits integer as written
+	// by the Java LongSerializer, as a decimal number.
+	Int64Codec Codec = int64Codec{}
+
+	// UUIDCodec represents the data, a 16 bytes binary UUID, using the
+	// canonical textual representation of UUIDs.
+	UUIDCodec Codec = uuidCodec{}
+)
+
+type textCodec struct{}
+
+func (textCodec) Format(data []byte) ([]byte, error) {
+	return data, nil
+}
+
+func (textCodec) Parse(text []byte) ([]byte, error) {
+	return text, nil
+}
+
+func (textCodec) IsJSON() bool {
+	return false
+}
+
+type base64Codec struct{}
+
+func (base64Codec) Format(data []byte) ([]byte, error) {
+	return base64.StdEncoding.AppendEncode(nil, data), nil
+}
+
+func (base64Codec) Parse(text []byte) ([]byte, error) {
+	return base64.StdEncoding.AppendDecode(nil, text)
+}
+
+func (base64Codec) IsJSON() bool {
+	return false
+}
+
+type hexCodec struct{}
+
+func (hexCodec) Format(data []byte) ([]byte, error) {
+	return hex.AppendEncode(nil, data), nil
+}
+
+func (hexCodec) Parse(text []byte) ([]byte, error) {
+	return hex.AppendDecode(nil, text)
+}
+
+func (hexCodec) IsJSON() bool {
+	return false
+}
+
+type int64Codec struct{}
+
+func (int64Codec) Format(data []byte) ([]byte, error) {
+	if len(data) != 8 {
+		return nil, fmt.Errorf("int64: expected 8 bytes but got %d", len(data))
+	}
+	return strconv.AppendInt(nil, int64(binary.BigEndian.Uint64(data)), 10), nil
+}
+
+func (int64Codec) Parse(text []byte) ([]byte, error) {
+	n, err := strconv.ParseInt(string(text), 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	return binary.BigEndian.AppendUint64(nil, uint64(n)), nil
+}
+
+func (int64Codec) IsJSON() bool {
+	return true
+}
+
+type uuidCodec struct{}
+
+func (uuidCodec) Format(data []byte) ([]byte, error) {
+	if len(data) != 16 {
+		return nil, fmt.Errorf("uuid: expected 16 bytes but got %d", len(data))
+	}
+	return fmt.Appendf(nil, "%x-%x-%x-%x-%x", data[0:4], data[4:6], data[6:8], data[8:10], data[10:16]), nil
+}
+
+func (uuidCodec) Parse(text []byte) ([]byte, error) {
+	if len(text) != 36 || text[8] != '-' || text[13] != '-' || text[18] != '-' || text[23] != '-' {
+		return nil, fmt.Errorf("uuid: invalid UUID '%s'", text)
+	}
+	data := make([]byte, 0, 16)
+	for _, group := range [][]byte{text[0:8], text[9:13], text[14:18], text[19:23], text[24:36]} {
+		var err error
+		if data, err = hex.AppendDecode(data, group); err != nil {
+			return nil, fmt.Errorf("uuid: invalid UUID '%s'", text)
+		}
+	}
+	return data, nil
+}
+
+func (uuidCodec) IsJSON() bool {
+	return false
+}
+
+// NewProtoCodec returns a Codec that represents the data, a serialized
+// protobuf message of the given type, using its JSON representation.
+func NewProtoCodec(messageType protoreflect.MessageType) Codec {
+	return &protoCodec{messageType}
+}
+
+type protoCodec struct {
+	messageType protoreflect.MessageType
+}
+
+func (codec *protoCodec) Format(data []byte) ([]byte, error) {
+	pb, err := codec.unmarshal(data)
+	if err != nil {
+		return nil, err
+	}
+	return jsonMarshalOptions.Marshal(pb)
+}
+
+func (codec *protoCodec) Parse(text []byte) ([]byte, error) {
+	pb, err := codec.newMessage()
+	if err != nil {
+		return nil, err
+	}
+	if err := jsonUnmarshalOptions.Unmarshal(text, pb); err != nil {
+		return nil, err
+	}
+	return codec.marshal(pb)
+}
+
+func (codec *protoCodec) unmarshal(data []byte) (proto.Message, error) {
+	pb := codec.messageType.New().Interface()
+	if err := proto.Unmarshal(data, pb); err != nil {
+		return nil, err
+	}
+	return pb, nil
+}
+
+func (codec *protoCodec) newMessage() (proto.Message, error) {
+	return codec.messageType.New().Interface(), nil
+}
+
+func (codec *protoCodec) marshal(pb proto.Message) ([]byte, error) {
+	return proto.Marshal(pb)
+}
+
+func (codec *protoCodec) IsJSON() bool {
+	return true
+}
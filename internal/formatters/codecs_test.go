@@ -0,0 +1,82 @@
+package formatters_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/bluekiri/kafka-client/internal/formatters"
+)
+
+func TestCodecs(t *testing.T) {
+	testCases := map[string]struct {
+		codec formatters.Codec
+		data  []byte
+		text  string
+	}{
+		"text":   {formatters.TextCodec, []byte("this is a key"), "this is a key"},
+		"base64": {formatters.Base64Codec, []byte("this is a key"), "dGhpcyBpcyBhIGtleQ=="},
+		"hex":    {formatters.HexCodec, []byte{0x01, 0xab}, "01ab"},
+		"int64":  {formatters.Int64Codec, []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xfe}, "-2"},
+		"uuid": {
+			formatters.UUIDCodec,
+			[]byte{0x12, 0x3e, 0x45, 0x67, 0xe8, 0x9b, 0x12, 0xd3, 0xa4, 0x56, 0x42, 0x66, 0x14, 0x17, 0x40, 0x00},
+			"123e4567-e89b-12d3-a456-426614174000",
+		},
+		"proto": {formatters.NewProtoCodec(messageType), expectedProtoMessage.Value, `{"value":"this is a proto message"}`},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			text, err := tc.codec.Format(tc.data)
+			if err != nil {
+				t.Fatalf("Format failed: %v", err)
+			}
+			// protojson adds random spaces, so compact the text before comparing
+			if actual := string(bytes.ReplaceAll(text, []byte(" "), nil)); actual != string(bytes.ReplaceAll([]byte(tc.text), []byte(" "), nil)) {
+				t.Errorf("Expected text '%s' but got '%s'", tc.text, text)
+			}
+
+			data, err := tc.codec.Parse([]byte(tc.text))
+			if err != nil {
+				t.Fatalf("Parse failed: %v", err)
+			}
+			if !bytes.Equal(data, tc.data) {
+				t.Errorf("Expected data '%v' but got '%v'", tc.data, data)
+			}
+		})
+	}
+}
+
+func TestCodecsFormatError(t *testing.T) {
+	testCases := map[string]formatters.Codec{
+		"int64": formatters.Int64Codec,
+		"uuid":  formatters.UUIDCodec,
+		"proto": formatters.NewProtoCodec(messageType),
+	}
+
+	for name, codec := range testCases {
+		t.Run(name, func(t *testing.T) {
+			if _, err := codec.Format([]byte{0xff, 0xff, 0xff}); err == nil {
+				t.Fatal("Format should have failed")
+			}
+		})
+	}
+}
+
+func TestCodecsParseError(t *testing.T) {
+	testCases := map[string]formatters.Codec{
+		"base64": formatters.Base64Codec,
+		"hex":    formatters.HexCodec,
+		"int64":  formatters.Int64Codec,
+		"uuid":   formatters.UUIDCodec,
+		"proto":  formatters.NewProtoCodec(messageType),
+	}
+
+	for name, codec := range testCases {
+		t.Run(name, func(t *testing.T) {
+			if _, err := codec.Parse([]byte("not valid!")); err == nil {
+				t.Fatal("Parse should have failed")
+			}
+		})
+	}
+}
@@ -0,0 +1,234 @@
+/*
+Copyright © 2023 VECI Group Tech S.L.
+This file is part of kafka-client.
+*/
+
+package formatters
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+	"unicode/utf8"
+
+	"github.com/bluekiri/kafka-client/internal/dto"
+)
+
+// NewJSONFormatter returns a Formatter that writes every message as a JSON
+// envelope, one per line, holding the key, value, headers, partition, offset
+// and timestamp of the message. The key and the value are represented using
+// the given codecs.
+func NewJSONFormatter(keyCodec Codec, valueCodec Codec) Formatter {
+	return &jsonFactory{keyCodec, valueCodec}
+}
+
+type jsonFactory struct {
+	keyCodec   Codec
+	valueCodec Codec
+}
+
+func (factory *jsonFactory) NewReader(reader io.Reader) Reader {
+	return &jsonReader{factory, json.NewDecoder(reader)}
+}
+
+func (factory *jsonFactory) NewWriter(writer io.Writer) Writer {
+	return &jsonWriter{factory, writer}
+}
+
+// jsonEnvelope is the envelope of a message. Keys and values represented as
+// JSON strings, and header keys and values, that are not valid UTF-8 are
+// base64 encoded, as given by their encoding, so they aren't altered when
+// represented as JSON strings.
+type jsonEnvelope struct {
+	Key           json.RawMessage `json:"key"`
+	KeyEncoding   string          `json:"keyEncoding,omitempty"`
+	Value         json.RawMessage `json:"value"`
+	ValueEncoding string          `json:"valueEncoding,omitempty"`
+	Headers       []jsonHeader    `json:"headers,omitempty"`
+	Partition     *int32          `json:"partition,omitempty"`
+	Offset        *int64          `json:"offset,omitempty"`
+	Timestamp     *time.Time      `json:"timestamp,omitempty"`
+}
+
+type jsonHeader struct {
+	Key           string `json:"key"`
+	KeyEncoding   string `json:"keyEncoding,omitempty"`
+	Value         string `json:"value"`
+	ValueEncoding string `json:"valueEncoding,omitempty"`
+}
+
+// base64Encoding is the encoding of the base64 encoded strings.
+const base64Encoding = "base64"
+
+// encodeJSONString returns the JSON string representing the given data and
+// its encoding.
+func encodeJSONString(data []byte) (string, string) {
+	if utf8.Valid(data) {
+		return string(data), ""
+	}
+	return base64.StdEncoding.EncodeToString(data), base64Encoding
+}
+
+// decodeJSONString returns the data represented by a JSON string with the
+// given encoding.
+func decodeJSONString(s string, encoding string) ([]byte, error) {
+	switch encoding {
+	case "":
+		return []byte(s), nil
+	case base64Encoding:
+		return base64.StdEncoding.DecodeString(s)
+	default:
+		return nil, fmt.Errorf("unknown encoding '%s', expected %s", encoding, base64Encoding)
+	}
+}
+
+func newJSONHeader(header dto.KafkaHeader) jsonHeader {
+	var jsonHeader jsonHeader
+	jsonHeader.Key, jsonHeader.KeyEncoding = encodeJSONString(header.Key)
+	jsonHeader.Value, jsonHeader.ValueEncoding = encodeJSONString(header.Value)
+	return jsonHeader
+}
+
+func (header jsonHeader) kafkaHeader() (dto.KafkaHeader, error) {
+	key, err := decodeJSONString(header.Key, header.KeyEncoding)
+	if err != nil {
+		return dto.KafkaHeader{}, fmt.Errorf("invalid header key %s: %w", header.Key, err)
+	}
+	value, err := decodeJSONString(header.Value, header.ValueEncoding)
+	if err != nil {
+		return dto.KafkaHeader{}, fmt.Errorf("invalid header %s: %w", header.Key, err)
+	}
+	return dto.KafkaHeader{Key: key, Value: value}, nil
+}
+
+type jsonReader struct {
+	*jsonFactory
+	decoder *json.Decoder
+}
+
+func (reader *jsonReader) Read() (*dto.KafkaMessage, error) {
+	var envelope jsonEnvelope
+	if err := reader.decoder.Decode(&envelope); err != nil {
+		return nil, err
+	}
+
+	key, err := parseJSONField(reader.keyCodec, envelope.Key, envelope.KeyEncoding)
+	if err != nil {
+		return nil, fmt.Errorf("invalid key: %w", err)
+	}
+	value, err := parseJSONField(reader.valueCodec, envelope.Value, envelope.ValueEncoding)
+	if err != nil {
+		return nil, fmt.Errorf("invalid value: %w", err)
+	}
+
+	message := &dto.KafkaMessage{
+		Key:   key,
+		Value: value,
+	}
+	for _, header := range envelope.Headers {
+		kafkaHeader, err := header.kafkaHeader()
+		if err != nil {
+			return nil, err
+		}
+		message.Headers = append(message.Headers, kafkaHeader)
+	}
+	if envelope.Partition != nil {
+		message.Partition = *envelope.Partition
+	}
+	if envelope.Offset != nil {
+		message.Offset = *envelope.Offset
+	}
+	if envelope.Timestamp != nil {
+		message.Timestamp = *envelope.Timestamp
+	}
+
+	return message, nil
+}
+
+// parseJSONField returns the data represented by a key or value field with
+// the given encoding.
+func parseJSONField(codec Codec, field json.RawMessage, encoding string) ([]byte, error) {
+	if len(field) == 0 || bytes.Equal(field, []byte("null")) {
+		return nil, nil
+	}
+
+	// Non JSON representations are embedded as JSON strings
+	text := []byte(field)
+	if !codec.IsJSON() {
+		var s string
+		if err := json.Unmarshal(field, &s); err != nil {
+			return nil, err
+		}
+		var err error
+		if text, err = decodeJSONString(s, encoding); err != nil {
+			return nil, err
+		}
+	} else if encoding != "" {
+		return nil, fmt.Errorf("unexpected encoding '%s' of a JSON representation", encoding)
+	}
+	return codec.Parse(text)
+}
+
+type jsonWriter struct {
+	*jsonFactory
+	writer io.Writer
+}
+
+func (writer *jsonWriter) Write(message *dto.KafkaMessage) error {
+	key, keyEncoding, err := formatJSONField(writer.keyCodec, message.Key)
+	if err != nil {
+		return fmt.Errorf("invalid key: %w", err)
+	}
+	value, valueEncoding, err := formatJSONField(writer.valueCodec, message.Value)
+	if err != nil {
+		return fmt.Errorf("invalid value: %w", err)
+	}
+
+	envelope := jsonEnvelope{
+		Key:           key,
+		KeyEncoding:   keyEncoding,
+		Value:         value,
+		ValueEncoding: valueEncoding,
+		Partition:     &message.Partition,
+		Offset:        &message.Offset,
+	}
+	for _, header := range message.Headers {
+		envelope.Headers = append(envelope.Headers, newJSONHeader(header))
+	}
+	if !message.Timestamp.IsZero() {
+		envelope.Timestamp = &message.Timestamp
+	}
+
+	jsonBytes, err := json.Marshal(envelope)
+	if err != nil {
+		return err
+	}
+
+	// Write the JSON string
+	_, err = fmt.Fprintln(writer.writer, string(jsonBytes))
+	return err
+}
+
+// formatJSONField returns the JSON representation of a key or value and its
+// encoding.
+func formatJSONField(codec Codec, data []byte) (json.RawMessage, string, error) {
+	if data == nil {
+		return json.RawMessage("null"), "", nil
+	}
+
+	text, err := codec.Format(data)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Non JSON representations are embedded as JSON strings
+	if !codec.IsJSON() {
+		s, encoding := encodeJSONString(text)
+		field, err := json.Marshal(s)
+		return field, encoding, err
+	}
+	return text, "", nil
+}
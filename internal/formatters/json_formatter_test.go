@@ -0,0 +1,188 @@
+package formatters_test
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bluekiri/kafka-client/internal/dto"
+	"github.com/bluekiri/kafka-client/internal/formatters"
+)
+
+var expectedJSONMessage = dto.KafkaMessage{
+	Key:   []byte("this is a key"),
+	Value: []byte("this is a message"),
+	Headers: []dto.KafkaHeader{
+		{Key: []byte("trace-id"), Value: []byte("abc")},
+	},
+	Timestamp: time.Date(2026, 10, 1, 10, 30, 0, 0, time.UTC),
+	Partition: 3,
+	Offset:    1200,
+}
+
+func TestJSONFormatter(t *testing.T) {
+	testCases := map[string]struct {
+		keyCodec   formatters.Codec
+		valueCodec formatters.Codec
+		expected   string
+	}{
+		"text": {
+			formatters.TextCodec, formatters.TextCodec,
+			`{"key":"this is a key","value":"this is a message","headers":[{"key":"trace-id","value":"abc"}],"partition":3,"offset":1200,"timestamp":"2026-10-01T10:30:00Z"}`,
+		},
+		"base64 and hex": {
+			formatters.Base64Codec, formatters.HexCodec,
+			`{"key":"dGhpcyBpcyBhIGtleQ==","value":"746869732069732061206d657373616765","headers":[{"key":"trace-id","value":"abc"}],"partition":3,"offset":1200,"timestamp":"2026-10-01T10:30:00Z"}`,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			var buffer bytes.Buffer
+
+			formatter := formatters.NewJSONFormatter(tc.keyCodec, tc.valueCodec)
+
+			err := formatter.NewWriter(&buffer).Write(&expectedJSONMessage)
+			if err != nil {
+				t.Fatalf("Write failed: %v", err)
+			}
+
+			if actual := strings.TrimSuffix(buffer.String(), "\n"); actual != tc.expected {
+				t.Errorf("Expected '%s' but got '%s'", tc.expected, actual)
+			}
+
+			// Reading the written envelope must return the same message
+			actual, err := formatter.NewReader(&buffer).Read()
+			if err != nil {
+				t.Fatalf("Read failed: %v", err)
+			}
+
+			if !reflect.DeepEqual(*actual, expectedJSONMessage) {
+				t.Errorf("Expected message '%v' but got '%v'", expectedJSONMessage, *actual)
+			}
+		})
+	}
+}
+
+func TestJSONFormatterProtoValue(t *testing.T) {
+	var buffer bytes.Buffer
+
+	formatter := formatters.NewJSONFormatter(formatters.TextCodec, formatters.NewProtoCodec(messageType))
+
+	// The decoded protobuf message is embedded in the envelope
+	message := &dto.KafkaMessage{Value: expectedProtoMessage.Value}
+	if err := formatter.NewWriter(&buffer).Write(message); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	expected := `{"key":null,"value":{"value":"this is a proto message"},"partition":0,"offset":0}`
+	if actual := strings.TrimSuffix(buffer.String(), "\n"); actual != expected {
+		t.Errorf("Expected '%s' but got '%s'", expected, actual)
+	}
+
+	actual, err := formatter.NewReader(&buffer).Read()
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+
+	if actual.Key != nil {
+		t.Errorf("Expected null key but got '%v'", actual.Key)
+	}
+
+	if !bytes.Equal(actual.Value, expectedProtoMessage.Value) {
+		t.Errorf("Expected value '%v' but got '%v'", expectedProtoMessage.Value, actual.Value)
+	}
+}
+
+func TestJSONFormatterBinary(t *testing.T) {
+	var buffer bytes.Buffer
+
+	formatter := formatters.NewJSONFormatter(formatters.TextCodec, formatters.TextCodec)
+
+	// Keys, values and headers that are not valid UTF-8 are base64 encoded
+	binary := []byte{0xff, 0xfe}
+	message := &dto.KafkaMessage{
+		Key:   binary,
+		Value: binary,
+		Headers: []dto.KafkaHeader{
+			{Key: []byte("trace-id"), Value: []byte("abc")},
+			{Key: binary, Value: binary},
+		},
+	}
+	if err := formatter.NewWriter(&buffer).Write(message); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	for _, expected := range []string{
+		`"key":"//4=","keyEncoding":"base64","value":"//4=","valueEncoding":"base64"`,
+		`"headers":[{"key":"trace-id","value":"abc"},{"key":"//4=","keyEncoding":"base64","value":"//4=","valueEncoding":"base64"}]`,
+	} {
+		if !strings.Contains(buffer.String(), expected) {
+			t.Errorf("Expected '%s' in '%s'", expected, buffer.String())
+		}
+	}
+
+	actual, err := formatter.NewReader(&buffer).Read()
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if !bytes.Equal(actual.Key, message.Key) || !bytes.Equal(actual.Value, message.Value) {
+		t.Errorf("Expected key and value '%v' but got '%v' and '%v'", binary, actual.Key, actual.Value)
+	}
+	if !reflect.DeepEqual(actual.Headers, message.Headers) {
+		t.Errorf("Expected headers '%v' but got '%v'", message.Headers, actual.Headers)
+	}
+}
+
+func TestJSONFormatterReadHandEdited(t *testing.T) {
+	formatter := formatters.NewJSONFormatter(formatters.TextCodec, formatters.TextCodec)
+
+	// Envelopes may span several lines and omit the metadata
+	reader := formatter.NewReader(strings.NewReader(`{
+		"key": "k1",
+		"value": "v1",
+		"headers": [{"key": "tenant", "value": "x"}]
+	}
+	{"value": "v2"}`))
+
+	first, err := reader.Read()
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if string(first.Key) != "k1" || string(first.Value) != "v1" || len(first.Headers) != 1 {
+		t.Errorf("Unexpected first message '%v'", first)
+	}
+
+	second, err := reader.Read()
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if second.Key != nil || string(second.Value) != "v2" {
+		t.Errorf("Unexpected second message '%v'", second)
+	}
+}
+
+func TestJSONFormatterReadError(t *testing.T) {
+	testCases := map[string]string{
+		"not json":       "this is not a JSON string\n",
+		"invalid base64": `{"key":"not base64!","value":null}`,
+		"not a string":   `{"key":{"a":1},"value":null}`,
+		"invalid header": `{"key":null,"value":null,"headers":[{"key":"k","value":"not base64!","valueEncoding":"base64"}]}`,
+		"unknown header": `{"key":null,"value":null,"headers":[{"key":"k","value":"v","valueEncoding":"hex"}]}`,
+		"invalid value":  `{"key":null,"value":"not base64!","valueEncoding":"base64"}`,
+		"unknown value":  `{"key":null,"value":"v","valueEncoding":"hex"}`,
+		"header key":     `{"key":null,"value":null,"headers":[{"key":"not base64!","keyEncoding":"base64","value":"v"}]}`,
+	}
+
+	formatter := formatters.NewJSONFormatter(formatters.Base64Codec, formatters.TextCodec)
+
+	for name, input := range testCases {
+		t.Run(name, func(t *testing.T) {
+			_, err := formatter.NewReader(strings.NewReader(input)).Read()
+			if err == nil {
+				t.Fatal("Read should have failed")
+			}
+		})
+	}
+}
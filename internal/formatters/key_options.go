@@ -0,0 +1,73 @@
+/*
+Copyright © 2023 VECI Group Tech S.L.
+This file is part of kafka-client.
+*/
+
+package formatters
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// KeyOptions configures how line oriented formatters write and read the key of
+// the messages.
+type KeyOptions struct {
+	// KeySeparator separates the key from the value in every line. If empty,
+	// only the value is written and read.
+	KeySeparator string
+
+	// KeyCodec represents the key of the messages. Defaults to TextCodec.
+	KeyCodec Codec
+}
+
+func (options KeyOptions) keyCodec() Codec {
+	if options.KeyCodec == nil {
+		return TextCodec
+	}
+	return options.KeyCodec
+}
+
+// prefix returns the representation of the key followed by the separator, or
+// nil if keys are not written. Keys whose representation contains the
+// separator are rejected, as they would be read split at the separator.
+func (options KeyOptions) prefix(key []byte) ([]byte, error) {
+	if options.KeySeparator == "" {
+		return nil, nil
+	}
+
+	// Null keys are written as empty keys
+	var prefix []byte
+	if len(key) > 0 {
+		var err error
+		if prefix, err = options.keyCodec().Format(key); err != nil {
+			return nil, err
+		}
+		if bytes.Contains(prefix, []byte(options.KeySeparator)) {
+			return nil, fmt.Errorf("the key %q contains the key separator %q", prefix, options.KeySeparator)
+		}
+	}
+	return append(prefix, options.KeySeparator...), nil
+}
+
+// cutKey returns the key of the line and the rest of the line. Lines without
+// separator, or with an empty key, have a null key.
+func (options KeyOptions) cutKey(line []byte) ([]byte, []byte, error) {
+	if options.KeySeparator == "" {
+		return nil, line, nil
+	}
+
+	text, rest, found := bytes.Cut(line, []byte(options.KeySeparator))
+	if !found {
+		return nil, line, nil
+	}
+	if len(text) == 0 {
+		return nil, rest, nil
+	}
+
+	key, err := options.keyCodec().Parse(text)
+	if err != nil {
+		return nil, nil, err
+	}
+	return key, rest, nil
+}
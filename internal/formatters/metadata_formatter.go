@@ -0,0 +1,76 @@
+/*
+Copyright © 2023 VECI Group Tech S.L.
+This file is part of kafka-client.
+*/
+
+package formatters
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/bluekiri/kafka-client/internal/dto"
+)
+
+// WithMetadata returns a Formatter whose writers write the partition, offset,
+// timestamp and headers of every message, followed by a tab, before the
+// message written by the given line oriented formatter.
+//
+// The messages are written once written by the given formatter, so a message
+// it fails to write is not written at all. The metadata is meant to be read by
+// humans, so the readers of the returned Formatter are the readers of the
+// given formatter.
+func WithMetadata(formatter Formatter) Formatter {
+	return &metadataFactory{formatter}
+}
+
+type metadataFactory struct {
+	formatter Formatter
+}
+
+func (factory *metadataFactory) NewReader(reader io.Reader) Reader {
+	return factory.formatter.NewReader(reader)
+}
+
+func (factory *metadataFactory) NewWriter(writer io.Writer) Writer {
+	metadataWriter := &metadataWriter{writer: writer}
+	metadataWriter.messageWriter = factory.formatter.NewWriter(&metadataWriter.buffer)
+	return metadataWriter
+}
+
+type metadataWriter struct {
+	writer        io.Writer
+	buffer        bytes.Buffer
+	messageWriter Writer
+}
+
+func (writer *metadataWriter) Write(message *dto.KafkaMessage) error {
+	// Write the message to the buffer first, so no metadata is written if
+	// the message can't be written
+	writer.buffer.Reset()
+	if err := writer.messageWriter.Write(message); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(writer.writer, "%s\t%s", FormatMetadata(message), writer.buffer.Bytes())
+	return err
+}
+
+// FormatMetadata returns a human readable representation of the partition,
+// offset, timestamp and headers of the message.
+func FormatMetadata(message *dto.KafkaMessage) string {
+	headers := make([]string, 0, len(message.Headers))
+	for _, header := range message.Headers {
+		headers = append(headers, fmt.Sprintf("%q:%q", header.Key, header.Value))
+	}
+
+	return fmt.Sprintf(
+		"partition=%d offset=%d timestamp=%s headers={%s}",
+		message.Partition,
+		message.Offset,
+		message.Timestamp.UTC().Format(time.RFC3339Nano),
+		strings.Join(headers, ","),
+	)
+}
@@ -0,0 +1,74 @@
+package formatters_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/bluekiri/kafka-client/internal/dto"
+	"github.com/bluekiri/kafka-client/internal/formatters"
+	"github.com/bluekiri/kafka-client/internal/redact"
+)
+
+var expectedMetadataMessage = dto.KafkaMessage{
+	Key:   []byte("this is a key"),
+	Value: []byte("this is a message"),
+	Headers: []dto.KafkaHeader{
+		{Key: []byte("trace-id"), Value: []byte("abc")},
+		{Key: []byte("tenant"), Value: []byte("x")},
+	},
+	Timestamp: time.Date(2026, 10, 1, 10, 30, 0, 0, time.UTC),
+	Partition: 3,
+	Offset:    1200,
+}
+
+func TestMetadataFormatterWrite(t *testing.T) {
+	var buffer bytes.Buffer
+
+	formatter := formatters.WithMetadata(formatters.NewTextFormatter(formatters.TextOptions{}))
+
+	err := formatter.NewWriter(&buffer).Write(&expectedMetadataMessage)
+	if err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	expected := `partition=3 offset=1200 timestamp=2026-10-01T10:30:00Z headers={"trace-id":"abc","tenant":"x"}` +
+		"\tthis is a message\n"
+	if actual := buffer.String(); actual != expected {
+		t.Errorf("Expected '%s' but got '%s'", expected, actual)
+	}
+}
+
+func TestMetadataFormatterWriteError(t *testing.T) {
+	var buffer bytes.Buffer
+
+	// Values that are not JSON can't be redacted, so they aren't written
+	redactor, err := redact.NewRedactor(nil, redact.Field{Path: "email", Policy: redact.Mask})
+	if err != nil {
+		t.Fatalf("NewRedactor failed: %v", err)
+	}
+	formatter := formatters.WithMetadata(formatters.NewTextFormatter(formatters.TextOptions{
+		ValueCodec: formatters.NewRedactingCodec(formatters.TextCodec, redactor),
+	}))
+
+	if err := formatter.NewWriter(&buffer).Write(&expectedMetadataMessage); err == nil {
+		t.Fatal("Write should have failed")
+	}
+	if buffer.Len() > 0 {
+		t.Errorf("Expected nothing to be written but got '%s'", buffer.String())
+	}
+}
+
+func TestMetadataFormatterRead(t *testing.T) {
+	formatter := formatters.WithMetadata(formatters.NewTextFormatter(formatters.TextOptions{}))
+
+	// The reader is the reader of the wrapped formatter
+	actual, err := formatter.NewReader(bytes.NewReader([]byte("this is a message\n"))).Read()
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+
+	if !bytes.Equal(actual.Value, expectedMetadataMessage.Value) {
+		t.Errorf("Expected value '%v' but got '%v'", expectedMetadataMessage.Value, actual.Value)
+	}
+}
@@ -7,12 +7,19 @@ package formatters
 
 import (
 	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 
 	"github.com/bluekiri/kafka-client/internal/dto"
+	"github.com/bluekiri/kafka-client/internal/redact"
+	"github.com/bluekiri/kafka-client/internal/registry"
 
+	"google.golang.org/protobuf/encoding/protodelim"
 	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/encoding/prototext"
 	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/reflect/protoreflect"
 )
@@ -28,46 +35,177 @@ var (
 		DiscardUnknown: true,
 		AllowPartial:   false,
 	}
+
+	textMarshalOptions = prototext.MarshalOptions{
+		Multiline:    false,
+		AllowPartial: false,
+	}
+
+	textUnmarshalOptions = prototext.UnmarshalOptions{
+		DiscardUnknown: true,
+		AllowPartial:   false,
+	}
 )
 
-func NewProtoFormatter(messageType protoreflect.MessageType) Formatter {
-	return &protoFactory{messageType}
+// ProtoEncoding is the representation of the protobuf messages written and
+// read by the proto formatter.
+type ProtoEncoding int
+
+const (
+	// ProtoJSON represents every message as JSON in a line.
+	ProtoJSON ProtoEncoding = iota
+
+	// ProtoMultilineJSON represents every message as indented JSON spanning
+	// several lines. Keys are not written.
+	ProtoMultilineJSON
+
+	// ProtoText represents every message using the protobuf text format in a
+	// line.
+	ProtoText
+
+	// ProtoBinary represents the messages as a stream of binary protobuf
+	// messages, each one prefixed by its varint length. Keys are not written.
+	ProtoBinary
+)
+
+// ProtoOptions configures the proto formatter.
+type ProtoOptions struct {
+	KeyOptions
+
+	// Encoding is the representation of the messages. Defaults to ProtoJSON.
+	Encoding ProtoEncoding
+
+	// OmitUnpopulated omits the fields with default values from the JSON
+	// encodings.
+	OmitUnpopulated bool
+
+	// UseProtoNames uses the field names of the proto files instead of their
+	// lowerCamelCase names in the JSON encodings.
+	UseProtoNames bool
+
+	// UseEnumNumbers writes enum values as numbers instead of strings in the
+	// JSON encodings.
+	UseEnumNumbers bool
+
+	// Redactor, if not nil, redacts the fields of the written messages. The
+	// JSON encodings write the redacted JSON representation, with its keys
+	// sorted, while the other encodings write the message parsed back from
+	// it, so hashed fields must be strings.
+	Redactor *redact.Redactor
+}
+
+// protoValues converts the values of the messages to and from protobuf
+// messages.
+type protoValues interface {
+	// unmarshal returns the protobuf message of the value.
+	unmarshal(value []byte) (proto.Message, error)
+
+	// newMessage returns an empty protobuf message to read a value. Only the
+	// registry codec fails, when the latest schema of its subject can't be
+	// fetched or compiled; protoCodec always returns a nil error.
+	newMessage() (proto.Message, error)
+
+	// marshal returns the value of a message returned by newMessage.
+	marshal(pb proto.Message) ([]byte, error)
+}
+
+// NewProtoFormatter returns a Formatter that writes the value of every message,
+// a protobuf message of the given type, using the encoding given by the
+// options, optionally preceded by its key.
+func NewProtoFormatter(messageType protoreflect.MessageType, options ProtoOptions) Formatter {
+	return &protoFactory{&protoCodec{messageType}, options}
+}
+
+// NewRegistryProtoFormatter returns a Formatter like NewProtoFormatter for
+// values in the Confluent wire format, whose message types are resolved using
+// the schema registry as described by NewRegistryProtoCodec.
+func NewRegistryProtoFormatter(ctx context.Context, client *registry.Client, subject string, messageFullName string, options ProtoOptions) Formatter {
+	return &protoFactory{newRegistryProtoCodec(ctx, client, subject, messageFullName), options}
 }
 
 type protoFactory struct {
-	messageType protoreflect.MessageType
+	values  protoValues
+	options ProtoOptions
 }
 
 func (factory *protoFactory) NewReader(reader io.Reader) Reader {
-	return &protoReader{factory.messageType.New().Interface(), bufio.NewReader(reader)}
+	bufferedReader := bufio.NewReader(reader)
+	return &protoReader{factory, bufferedReader, json.NewDecoder(bufferedReader)}
 }
 
 func (factory *protoFactory) NewWriter(writer io.Writer) Writer {
-	return &protoWriter{factory.messageType.New().Interface(), writer}
+	return &protoWriter{factory, writer}
+}
+
+func (factory *protoFactory) jsonMarshalOptions() protojson.MarshalOptions {
+	options := jsonMarshalOptions
+	options.EmitUnpopulated = !factory.options.OmitUnpopulated
+	options.UseProtoNames = factory.options.UseProtoNames
+	options.UseEnumNumbers = factory.options.UseEnumNumbers
+	if factory.options.Encoding == ProtoMultilineJSON {
+		options.Multiline = true
+		options.Indent = "  "
+	}
+	return options
 }
 
 type protoReader struct {
-	protoMessage protoreflect.ProtoMessage
-	reader       *bufio.Reader
+	*protoFactory
+	reader  *bufio.Reader
+	decoder *json.Decoder
 }
 
 func (reader *protoReader) Read() (*dto.KafkaMessage, error) {
-	jsonBytes, err := reader.reader.ReadBytes('\n')
+	pb, err := reader.values.newMessage()
 	if err != nil {
 		return nil, err
 	}
 
-	pb := reader.protoMessage
-	if err := jsonUnmarshalOptions.Unmarshal(jsonBytes, pb); err != nil {
-		return nil, err
+	var key []byte
+	switch reader.options.Encoding {
+	case ProtoMultilineJSON:
+		// Multiline JSON documents are concatenated
+		var jsonBytes json.RawMessage
+		if err := reader.decoder.Decode(&jsonBytes); err != nil {
+			return nil, err
+		}
+		if err := jsonUnmarshalOptions.Unmarshal(jsonBytes, pb); err != nil {
+			return nil, err
+		}
+
+	case ProtoBinary:
+		if err := protodelim.UnmarshalFrom(reader.reader, pb); err != nil {
+			return nil, err
+		}
+
+	default:
+		line, err := reader.reader.ReadBytes('\n')
+		if err != nil {
+			return nil, err
+		}
+
+		var text []byte
+		if key, text, err = reader.options.cutKey(line); err != nil {
+			return nil, err
+		}
+
+		if reader.options.Encoding == ProtoText {
+			err = textUnmarshalOptions.Unmarshal(text, pb)
+		} else {
+			err = jsonUnmarshalOptions.Unmarshal(text, pb)
+		}
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	bytes, err := proto.Marshal(pb)
+	bytes, err := reader.values.marshal(pb)
 	if err != nil {
 		return nil, err
 	}
 
 	message := &dto.KafkaMessage{
+		Key:   key,
 		Value: bytes,
 	}
 
@@ -75,26 +213,83 @@ func (reader *protoReader) Read() (*dto.KafkaMessage, error) {
 }
 
 type protoWriter struct {
-	protoMessage protoreflect.ProtoMessage
-	writer       io.Writer
+	*protoFactory
+	writer io.Writer
 }
 
 func (writer *protoWriter) Write(message *dto.KafkaMessage) error {
-	pb := writer.protoMessage
-
 	// Unmarshal the protobuf object from the message
-	err := proto.Unmarshal(message.Value, pb)
+	pb, err := writer.values.unmarshal(message.Value)
 	if err != nil {
 		return err
 	}
 
-	// Marshal the protobuf object to JSON
-	jsonBytes, err := jsonMarshalOptions.Marshal(pb)
+	// Redact the protobuf object, if requested. The JSON encodings write the
+	// redacted JSON while the other ones need the redacted protobuf object.
+	var redactedJSON []byte
+	if writer.options.Redactor != nil {
+		if redactedJSON, err = writer.redact(pb); err != nil {
+			return err
+		}
+		if writer.options.Encoding == ProtoText || writer.options.Encoding == ProtoBinary {
+			redacted := pb.ProtoReflect().New().Interface()
+			if err := jsonUnmarshalOptions.Unmarshal(redactedJSON, redacted); err != nil {
+				return fmt.Errorf("redact: %w", err)
+			}
+			pb = redacted
+		}
+	}
+
+	switch writer.options.Encoding {
+	case ProtoMultilineJSON:
+		jsonBytes, err := writer.jsonMarshalOptions().Marshal(pb)
+		if redactedJSON != nil {
+			var buffer bytes.Buffer
+			err = json.Indent(&buffer, redactedJSON, "", "  ")
+			jsonBytes = buffer.Bytes()
+		}
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(writer.writer, string(jsonBytes))
+		return err
+
+	case ProtoBinary:
+		_, err := protodelim.MarshalTo(writer.writer, pb)
+		return err
+	}
+
+	// Marshal the protobuf object to a line
+	var line []byte
+	if writer.options.Encoding == ProtoText {
+		line, err = textMarshalOptions.Marshal(pb)
+	} else if redactedJSON != nil {
+		line = redactedJSON
+	} else {
+		line, err = writer.jsonMarshalOptions().Marshal(pb)
+	}
 	if err != nil {
 		return err
 	}
 
-	// Write the JSON string
-	_, err = fmt.Fprintln(writer.writer, string(jsonBytes))
+	// Write the key, if requested, and the line
+	key, err := writer.options.prefix(message.Key)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(writer.writer, string(key)+string(line))
 	return err
 }
+
+// redact returns the JSON representation of the message with its fields
+// redacted.
+func (writer *protoWriter) redact(pb proto.Message) ([]byte, error) {
+	options := writer.jsonMarshalOptions()
+	options.Multiline = false
+	options.Indent = ""
+	jsonBytes, err := options.Marshal(pb)
+	if err != nil {
+		return nil, err
+	}
+	return writer.options.Redactor.RedactJSON(jsonBytes)
+}
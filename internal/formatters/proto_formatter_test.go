@@ -3,13 +3,17 @@ package formatters_test
 import (
 	"bytes"
 	"context"
+	"errors"
+	"io"
 	"os"
 	"path"
+	"strings"
 	"testing"
 
 	"github.com/bluekiri/kafka-client/internal/dto"
 	"github.com/bluekiri/kafka-client/internal/formatters"
 	"github.com/bluekiri/kafka-client/internal/protoutils"
+	"github.com/bluekiri/kafka-client/internal/redact"
 	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/reflect/protoreflect"
 )
@@ -29,8 +33,10 @@ func init() {
 	messageType, err = protoutils.ResolveProtoMessageType(
 		context.Background(),
 		"test.TestMessage",
-		[]string{"test.proto"},
-		[]string{path.Join(workingDir, "testdata")},
+		protoutils.Sources{
+			ProtoFiles:  []string{"test.proto"},
+			ImportPaths: []string{path.Join(workingDir, "testdata")},
+		},
 	)
 	if err != nil {
 		panic(err)
@@ -57,7 +63,7 @@ func TestProtoFormatter(t *testing.T) {
 		t.Fatalf("os.Pipe failed: %v", err)
 	}
 
-	formatter := formatters.NewProtoFormatter(messageType)
+	formatter := formatters.NewProtoFormatter(messageType, formatters.ProtoOptions{})
 
 	// If we write a messsage to the formater and then read it we should
 	// get a message that is equal to the written
@@ -81,7 +87,7 @@ func TestProtoFormatter(t *testing.T) {
 }
 
 func TestProtoFormatterReadClosed(t *testing.T) {
-	formatter := formatters.NewProtoFormatter(messageType)
+	formatter := formatters.NewProtoFormatter(messageType, formatters.ProtoOptions{})
 
 	// Read won't fine a new line
 	_, err := formatter.NewReader(bytes.NewReader([]byte{})).Read()
@@ -91,7 +97,7 @@ func TestProtoFormatterReadClosed(t *testing.T) {
 }
 
 func TestProtoFormatterReadIllegalJson(t *testing.T) {
-	formatter := formatters.NewProtoFormatter(messageType)
+	formatter := formatters.NewProtoFormatter(messageType, formatters.ProtoOptions{})
 
 	// Read won't fine a new line
 	_, err := formatter.NewReader(bytes.NewReader([]byte("this is not a JSON string\n"))).Read()
@@ -99,3 +105,215 @@ func TestProtoFormatterReadIllegalJson(t *testing.T) {
 		t.Fatal("Read should have failed")
 	}
 }
+
+func TestProtoFormatterKeySeparator(t *testing.T) {
+	var buffer bytes.Buffer
+
+	formatter := formatters.NewProtoFormatter(messageType, formatters.ProtoOptions{
+		KeyOptions: formatters.KeyOptions{KeySeparator: "\t"},
+	})
+
+	// The key is written before the JSON and read back
+	err := formatter.NewWriter(&buffer).Write(&expectedProtoMessage)
+	if err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	if !bytes.HasPrefix(buffer.Bytes(), []byte("this is a key\t{")) {
+		t.Errorf("Expected the key before the JSON but got '%s'", buffer.String())
+	}
+
+	actual, err := formatter.NewReader(&buffer).Read()
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+
+	if !bytes.Equal(actual.Key, expectedProtoMessage.Key) {
+		t.Errorf("Expected key '%v' but got '%v'", expectedProtoMessage.Key, actual.Key)
+	}
+
+	if !bytes.Equal(actual.Value, expectedProtoMessage.Value) {
+		t.Errorf("Expected value '%v' but got '%v'", expectedProtoMessage.Value, actual.Value)
+	}
+}
+
+func TestProtoFormatterEncodings(t *testing.T) {
+	testCases := map[string]struct {
+		encoding formatters.ProtoEncoding
+		expected string
+	}{
+		"json":           {formatters.ProtoJSON, "{\"value\":\"this is a proto message\"}\n"},
+		"multiline json": {formatters.ProtoMultilineJSON, "{\n  \"value\":\"this is a proto message\"\n}\n"},
+		"text":           {formatters.ProtoText, "value:\"this is a proto message\"\n"},
+		"binary":         {formatters.ProtoBinary, string(append([]byte{byte(len(expectedProtoMessage.Value))}, expectedProtoMessage.Value...))},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			var buffer bytes.Buffer
+
+			formatter := formatters.NewProtoFormatter(messageType, formatters.ProtoOptions{
+				Encoding: tc.encoding,
+			})
+
+			// Write two messages and read them back
+			writer := formatter.NewWriter(&buffer)
+			for i := 0; i < 2; i++ {
+				if err := writer.Write(&expectedProtoMessage); err != nil {
+					t.Fatalf("Write failed: %v", err)
+				}
+			}
+
+			// protojson and prototext add random spaces, so remove them
+			// before comparing
+			if tc.encoding != formatters.ProtoBinary {
+				if actual := strings.ReplaceAll(buffer.String(), " ", ""); actual != strings.Repeat(strings.ReplaceAll(tc.expected, " ", ""), 2) {
+					t.Errorf("Expected '%s' twice but got '%s'", tc.expected, buffer.String())
+				}
+			} else if actual := buffer.String(); actual != strings.Repeat(tc.expected, 2) {
+				t.Errorf("Expected '%v' twice but got '%v'", []byte(tc.expected), buffer.Bytes())
+			}
+
+			reader := formatter.NewReader(&buffer)
+			for i := 0; i < 2; i++ {
+				actual, err := reader.Read()
+				if err != nil {
+					t.Fatalf("Read failed: %v", err)
+				}
+				if !bytes.Equal(actual.Value, expectedProtoMessage.Value) {
+					t.Errorf("Expected value '%v' but got '%v'", expectedProtoMessage.Value, actual.Value)
+				}
+			}
+
+			if _, err := reader.Read(); !errors.Is(err, io.EOF) {
+				t.Errorf("Expected io.EOF but got %v", err)
+			}
+		})
+	}
+}
+
+func TestProtoFormatterJSONOptions(t *testing.T) {
+	optionsType, err := protoutils.ResolveProtoMessageType(
+		context.Background(),
+		"test.OptionsMessage",
+		protoutils.Sources{
+			ProtoFiles:  []string{"test.proto"},
+			ImportPaths: []string{"testdata"},
+		},
+	)
+	if err != nil {
+		t.Fatalf("ResolveProtoMessageType failed: %v", err)
+	}
+
+	value := optionsType.New()
+	value.Set(optionsType.Descriptor().Fields().ByName("snake_value"), protoreflect.ValueOf("abc"))
+	value.Set(optionsType.Descriptor().Fields().ByName("kind"), protoreflect.ValueOfEnum(1))
+	data, err := proto.Marshal(value.Interface())
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+
+	testCases := map[string]struct {
+		options  formatters.ProtoOptions
+		expected string
+	}{
+		"default":          {formatters.ProtoOptions{}, `{"snakeValue":"abc","kind":"OTHER","count":0}`},
+		"omit unpopulated": {formatters.ProtoOptions{OmitUnpopulated: true}, `{"snakeValue":"abc","kind":"OTHER"}`},
+		"proto names":      {formatters.ProtoOptions{UseProtoNames: true}, `{"snake_value":"abc","kind":"OTHER","count":0}`},
+		"enum numbers":     {formatters.ProtoOptions{UseEnumNumbers: true}, `{"snakeValue":"abc","kind":1,"count":0}`},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			var buffer bytes.Buffer
+
+			formatter := formatters.NewProtoFormatter(optionsType, tc.options)
+			if err := formatter.NewWriter(&buffer).Write(&dto.KafkaMessage{Value: data}); err != nil {
+				t.Fatalf("Write failed: %v", err)
+			}
+			if actual := strings.TrimSpace(strings.ReplaceAll(buffer.String(), " ", "")); actual != tc.expected {
+				t.Errorf("Expected '%s' but got '%s'", tc.expected, actual)
+			}
+
+			// The written JSON must be readable
+			actual, err := formatter.NewReader(&buffer).Read()
+			if err != nil {
+				t.Fatalf("Read failed: %v", err)
+			}
+			// Dynamic messages are marshaled in any field order
+			actualValue := optionsType.New().Interface()
+			if err := proto.Unmarshal(actual.Value, actualValue); err != nil {
+				t.Fatalf("Unmarshal failed: %v", err)
+			}
+			if !proto.Equal(actualValue, value.Interface()) {
+				t.Errorf("Expected value '%v' but got '%v'", value, actualValue)
+			}
+		})
+	}
+}
+
+func TestProtoFormatterRedaction(t *testing.T) {
+	optionsType, err := protoutils.ResolveProtoMessageType(
+		context.Background(),
+		"test.OptionsMessage",
+		protoutils.Sources{
+			ProtoFiles:  []string{"test.proto"},
+			ImportPaths: []string{"testdata"},
+		},
+	)
+	if err != nil {
+		t.Fatalf("ResolveProtoMessageType failed: %v", err)
+	}
+
+	value := optionsType.New()
+	value.Set(optionsType.Descriptor().Fields().ByName("snake_value"), protoreflect.ValueOf("abc"))
+	value.Set(optionsType.Descriptor().Fields().ByName("kind"), protoreflect.ValueOfEnum(1))
+	value.Set(optionsType.Descriptor().Fields().ByName("count"), protoreflect.ValueOf(int32(7)))
+	data, err := proto.Marshal(value.Interface())
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+
+	redactor, err := redact.NewRedactor([]byte("secret"),
+		redact.Field{Path: "snakeValue", Policy: redact.Hash},
+		redact.Field{Path: "kind", Policy: redact.Drop},
+		redact.Field{Path: "count", Policy: redact.Mask},
+	)
+	if err != nil {
+		t.Fatalf("NewRedactor failed: %v", err)
+	}
+
+	// The HMAC-SHA256 of abc keyed by secret
+	const hashed = "9946dad4e00e913fc8be8e5d3f7e110a4a9e832f83fb09c345285d78638d8a0e"
+	testCases := map[string]struct {
+		encoding formatters.ProtoEncoding
+		expected string
+	}{
+		"json":           {formatters.ProtoJSON, `{"count":null,"snakeValue":"` + hashed + `"}`},
+		"multiline json": {formatters.ProtoMultilineJSON, "{\n  \"count\": null,\n  \"snakeValue\": \"" + hashed + "\"\n}"},
+		"text":           {formatters.ProtoText, `snake_value:"` + hashed + `"`},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			var buffer bytes.Buffer
+
+			formatter := formatters.NewProtoFormatter(optionsType, formatters.ProtoOptions{
+				Encoding: tc.encoding,
+				Redactor: redactor,
+			})
+			if err := formatter.NewWriter(&buffer).Write(&dto.KafkaMessage{Value: data}); err != nil {
+				t.Fatalf("Write failed: %v", err)
+			}
+
+			actual := strings.TrimSpace(buffer.String())
+			if tc.encoding == formatters.ProtoText {
+				// prototext adds random spaces
+				actual = strings.ReplaceAll(actual, " ", "")
+			}
+			if actual != tc.expected {
+				t.Errorf("Expected '%s' but got '%s'", tc.expected, actual)
+			}
+		})
+	}
+}
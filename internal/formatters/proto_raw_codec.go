@@ -0,0 +1,188 @@
+/*
+Copyright © 2023 VECI Group Tech S.L.
+This file is part of kafka-client.
+*/
+
+package formatters
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"unicode"
+	"unicode/utf8"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// ProtoRawCodec represents the data, a serialized protobuf message of unknown
+// type, as a JSON object whose keys are the field numbers, like
+// protoc --decode_raw does. Fields found more than once are represented as
+// arrays. The values are represented depending on their wire type:
+//
+//   - varints as unsigned numbers.
+//   - fixed 32 and 64 bits values as hexadecimal strings, as they may be
+//     integers or floating point numbers.
+//   - length delimited values as strings if they are printable UTF-8 text,
+//     as objects if they are valid protobuf messages, or as base64 encoded
+//     strings otherwise.
+//   - groups as objects.
+//
+// The representation is lossy, so ProtoRawCodec can't parse it.
+var ProtoRawCodec Codec = protoRawCodec{}
+
+// ErrProtoRawParse is returned when parsing with ProtoRawCodec.
+var ErrProtoRawParse = errors.New("proto-raw: messages decoded without schema can't be encoded")
+
+type protoRawCodec struct{}
+
+func (protoRawCodec) Format(data []byte) ([]byte, error) {
+	fields, err := decodeRawMessage(data)
+	if err != nil {
+		return nil, fmt.Errorf("proto-raw: %w", err)
+	}
+	return fields.appendJSON(nil), nil
+}
+
+func (protoRawCodec) Parse(text []byte) ([]byte, error) {
+	return nil, ErrProtoRawParse
+}
+
+func (protoRawCodec) IsJSON() bool {
+	return true
+}
+
+// rawField holds the JSON representation of the values of a field.
+type rawField struct {
+	number protowire.Number
+	values [][]byte
+}
+
+// rawMessage holds the fields of a message in the order they are found.
+type rawMessage []*rawField
+
+func (message rawMessage) appendJSON(dst []byte) []byte {
+	dst = append(dst, '{')
+	for i, field := range message {
+		if i > 0 {
+			dst = append(dst, ',')
+		}
+		dst = append(dst, '"')
+		dst = strconv.AppendInt(dst, int64(field.number), 10)
+		dst = append(dst, '"', ':')
+		if len(field.values) == 1 {
+			dst = append(dst, field.values[0]...)
+			continue
+		}
+		dst = append(dst, '[')
+		dst = append(dst, bytes.Join(field.values, []byte{','})...)
+		dst = append(dst, ']')
+	}
+	return append(dst, '}')
+}
+
+// decodeRawMessage decodes the fields of a protobuf message.
+func decodeRawMessage(data []byte) (rawMessage, error) {
+	var message rawMessage
+	byNumber := make(map[protowire.Number]*rawField)
+	for len(data) > 0 {
+		number, wireType, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return nil, protowire.ParseError(n)
+		}
+		data = data[n:]
+
+		value, n, err := decodeRawValue(number, wireType, data)
+		if err != nil {
+			return nil, err
+		}
+		data = data[n:]
+
+		field, found := byNumber[number]
+		if !found {
+			field = &rawField{number: number}
+			byNumber[number] = field
+			message = append(message, field)
+		}
+		field.values = append(field.values, value)
+	}
+	return message, nil
+}
+
+// decodeRawValue returns the JSON representation of the value of the field
+// and its length.
+func decodeRawValue(number protowire.Number, wireType protowire.Type, data []byte) ([]byte, int, error) {
+	switch wireType {
+	case protowire.VarintType:
+		value, n := protowire.ConsumeVarint(data)
+		if n < 0 {
+			return nil, 0, protowire.ParseError(n)
+		}
+		return strconv.AppendUint(nil, value, 10), n, nil
+
+	case protowire.Fixed32Type:
+		value, n := protowire.ConsumeFixed32(data)
+		if n < 0 {
+			return nil, 0, protowire.ParseError(n)
+		}
+		return fmt.Appendf(nil, `"0x%08x"`, value), n, nil
+
+	case protowire.Fixed64Type:
+		value, n := protowire.ConsumeFixed64(data)
+		if n < 0 {
+			return nil, 0, protowire.ParseError(n)
+		}
+		return fmt.Appendf(nil, `"0x%016x"`, value), n, nil
+
+	case protowire.BytesType:
+		value, n := protowire.ConsumeBytes(data)
+		if n < 0 {
+			return nil, 0, protowire.ParseError(n)
+		}
+		return formatRawBytes(value), n, nil
+
+	case protowire.StartGroupType:
+		value, n := protowire.ConsumeGroup(number, data)
+		if n < 0 {
+			return nil, 0, protowire.ParseError(n)
+		}
+		group, err := decodeRawMessage(value)
+		if err != nil {
+			return nil, 0, err
+		}
+		return group.appendJSON(nil), n, nil
+	}
+
+	return nil, 0, fmt.Errorf("unexpected wire type %d of field %d", wireType, number)
+}
+
+// formatRawBytes returns the JSON representation of a length delimited value,
+// guessing whether it is a string, a message or just bytes.
+func formatRawBytes(value []byte) []byte {
+	if isPrintable(value) {
+		text, _ := json.Marshal(string(value))
+		return text
+	}
+	if message, err := decodeRawMessage(value); err == nil {
+		return message.appendJSON(nil)
+	}
+	text, _ := json.Marshal(base64.StdEncoding.EncodeToString(value))
+	return text
+}
+
+// isPrintable reports whether the value is UTF-8 text without control
+// characters other than white spaces.
+func isPrintable(value []byte) bool {
+	if !utf8.Valid(value) {
+		return false
+	}
+	for _, r := range string(value) {
+		if !unicode.IsPrint(r) && !unicode.IsSpace(r) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,73 @@
+package formatters_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/bluekiri/kafka-client/internal/formatters"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func TestProtoRawCodec(t *testing.T) {
+	// Build a message with every wire type
+	var nested []byte
+	nested = protowire.AppendTag(nested, 1, protowire.BytesType)
+	nested = protowire.AppendString(nested, "nested")
+	nested = protowire.AppendTag(nested, 2, protowire.VarintType)
+	nested = protowire.AppendVarint(nested, 7)
+
+	var data []byte
+	data = protowire.AppendTag(data, 1, protowire.VarintType)
+	data = protowire.AppendVarint(data, 150)
+	data = protowire.AppendTag(data, 2, protowire.BytesType)
+	data = protowire.AppendString(data, "this is a \"string\"")
+	data = protowire.AppendTag(data, 3, protowire.BytesType)
+	data = protowire.AppendBytes(data, nested)
+	data = protowire.AppendTag(data, 4, protowire.Fixed32Type)
+	data = protowire.AppendFixed32(data, 1)
+	data = protowire.AppendTag(data, 5, protowire.Fixed64Type)
+	data = protowire.AppendFixed64(data, 0x3ff0000000000000)
+	data = protowire.AppendTag(data, 6, protowire.BytesType)
+	data = protowire.AppendBytes(data, []byte{0xff, 0xfe})
+	data = protowire.AppendTag(data, 10, protowire.StartGroupType)
+	data = protowire.AppendTag(data, 1, protowire.VarintType)
+	data = protowire.AppendVarint(data, 1)
+	data = protowire.AppendTag(data, 10, protowire.EndGroupType)
+	data = protowire.AppendTag(data, 1, protowire.VarintType)
+	data = protowire.AppendVarint(data, 2)
+
+	text, err := formatters.ProtoRawCodec.Format(data)
+	if err != nil {
+		t.Fatalf("Format failed: %v", err)
+	}
+
+	expected := `{"1":[150,2],"2":"this is a \"string\"","3":{"1":"nested","2":7},"4":"0x00000001","5":"0x3ff0000000000000","6":"//4=","10":{"1":1}}`
+	if string(text) != expected {
+		t.Errorf("Expected text '%s' but got '%s'", expected, text)
+	}
+}
+
+func TestProtoRawCodecFormatError(t *testing.T) {
+	testCases := map[string][]byte{
+		"truncated tag":      {0x80},
+		"truncated varint":   {0x08, 0x80},
+		"truncated bytes":    {0x12, 0x05, 'a'},
+		"truncated fixed":    {0x25, 0x01},
+		"unexpected end":     {0x0c},
+		"unterminated group": {0x0b, 0x08, 0x01},
+	}
+
+	for name, data := range testCases {
+		t.Run(name, func(t *testing.T) {
+			if _, err := formatters.ProtoRawCodec.Format(data); err == nil {
+				t.Fatal("Format should have failed")
+			}
+		})
+	}
+}
+
+func TestProtoRawCodecParse(t *testing.T) {
+	if _, err := formatters.ProtoRawCodec.Parse([]byte(`{"1":150}`)); !errors.Is(err, formatters.ErrProtoRawParse) {
+		t.Fatalf("Expected ErrProtoRawParse but got %v", err)
+	}
+}
@@ -0,0 +1,146 @@
+/*
+Copyright © 2023 VECI Group Tech S.L.
+This file is part of kafka-client.
+*/
+
+package formatters
+
+import (
+	"context"
+
+	"github.com/bluekiri/kafka-client/internal/protoutils"
+	"github.com/bluekiri/kafka-client/internal/registry"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// NewRegistryProtoCodec returns a Codec that represents the data, a serialized
+// protobuf message in the Confluent wire format, using its JSON
+// representation.
+//
+// The data is formatted using the schema given by its schema ID, compiled
+// once fetched from the registry, and the message type given by its message
+// indexes. The text is parsed using the latest schema registered under the
+// given subject and the message type with the given full name, or the first
+// message type of the schema if no name is given.
+//
+// The requests to the registry are canceled with the given context, as codecs
+// are called without one.
+func NewRegistryProtoCodec(ctx context.Context, client *registry.Client, subject string, messageFullName string) Codec {
+	return newRegistryProtoCodec(ctx, client, subject, messageFullName)
+}
+
+func newRegistryProtoCodec(ctx context.Context, client *registry.Client, subject string, messageFullName string) *registryProtoCodec {
+	return &registryProtoCodec{
+		ctx:             ctx,
+		client:          client,
+		subject:         subject,
+		messageFullName: messageFullName,
+		files:           make(map[int]protoreflect.FileDescriptor),
+	}
+}
+
+type registryProtoCodec struct {
+	ctx             context.Context
+	client          *registry.Client
+	subject         string
+	messageFullName string
+	files           map[int]protoreflect.FileDescriptor
+}
+
+func (codec *registryProtoCodec) Format(data []byte) ([]byte, error) {
+	pb, err := codec.unmarshal(data)
+	if err != nil {
+		return nil, err
+	}
+	return jsonMarshalOptions.Marshal(pb)
+}
+
+func (codec *registryProtoCodec) Parse(text []byte) ([]byte, error) {
+	pb, err := codec.newMessage()
+	if err != nil {
+		return nil, err
+	}
+	if err := jsonUnmarshalOptions.Unmarshal(text, pb); err != nil {
+		return nil, err
+	}
+	return codec.marshal(pb)
+}
+
+func (codec *registryProtoCodec) unmarshal(data []byte) (proto.Message, error) {
+	id, payload, err := registry.SplitWireFormat(data)
+	if err != nil {
+		return nil, err
+	}
+	indexes, payload, err := registry.SplitMessageIndexes(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	schema, err := codec.client.SchemaByID(codec.ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	file, err := codec.file(schema)
+	if err != nil {
+		return nil, err
+	}
+	descriptor, err := protoutils.MessageByIndexes(file, indexes)
+	if err != nil {
+		return nil, err
+	}
+
+	pb := dynamicpb.NewMessage(descriptor)
+	if err := proto.Unmarshal(payload, pb); err != nil {
+		return nil, err
+	}
+	return pb, nil
+}
+
+func (codec *registryProtoCodec) newMessage() (proto.Message, error) {
+	schema, err := codec.client.LatestSchema(codec.ctx, codec.subject)
+	if err != nil {
+		return nil, err
+	}
+	file, err := codec.file(schema)
+	if err != nil {
+		return nil, err
+	}
+	descriptor, err := protoutils.FindMessage(file, codec.messageFullName)
+	if err != nil {
+		return nil, err
+	}
+	return dynamicpb.NewMessage(descriptor), nil
+}
+
+// marshal returns the message, created by newMessage, in the wire format.
+func (codec *registryProtoCodec) marshal(pb proto.Message) ([]byte, error) {
+	schema, err := codec.client.LatestSchema(codec.ctx, codec.subject)
+	if err != nil {
+		return nil, err
+	}
+
+	data := registry.AppendWireFormat(nil, schema.ID)
+	data = registry.AppendMessageIndexes(data, protoutils.MessageIndexes(pb.ProtoReflect().Descriptor()))
+	return proto.MarshalOptions{}.MarshalAppend(data, pb)
+}
+
+func (codec *registryProtoCodec) IsJSON() bool {
+	return true
+}
+
+// file returns the compiled schema, compiling it if not compiled yet.
+func (codec *registryProtoCodec) file(schema *registry.Schema) (protoreflect.FileDescriptor, error) {
+	if file, found := codec.files[schema.ID]; found {
+		return file, nil
+	}
+
+	file, err := protoutils.CompileRegistrySchema(codec.ctx, codec.client, schema)
+	if err != nil {
+		return nil, err
+	}
+	codec.files[schema.ID] = file
+	return file, nil
+}
@@ -0,0 +1,131 @@
+package formatters_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/bluekiri/kafka-client/internal/formatters"
+	"github.com/bluekiri/kafka-client/internal/registry"
+	"github.com/bluekiri/kafka-client/internal/registry/registrytest"
+)
+
+var protoRegistrySchemas = []registry.Schema{
+	{
+		ID:         1,
+		Subject:    "common.proto",
+		Version:    1,
+		SchemaType: registry.SchemaTypeProtobuf,
+		Schema:     `syntax = "proto3"; package common; message Id { string value = 1; }`,
+	},
+	{
+		ID:         2,
+		Subject:    "test-value",
+		Version:    1,
+		SchemaType: registry.SchemaTypeProtobuf,
+		Schema: `syntax = "proto3";
+			package test;
+			import "common.proto";
+			message First { string value = 1; }
+			message Second {
+				message Nested { common.Id id = 1; }
+			}`,
+		References: []registry.Reference{{Name: "common.proto", Subject: "common.proto", Version: 1}},
+	},
+}
+
+// The protobuf encoding of {"id":{"value":"abc"}}
+var nestedProtoData = []byte{0x0a, 0x05, 0x0a, 0x03, 'a', 'b', 'c'}
+
+const nestedProtoText = `{"id":{"value":"abc"}}`
+
+func TestRegistryProtoCodec(t *testing.T) {
+	server := registrytest.NewServer(protoRegistrySchemas...)
+	defer server.Close()
+
+	codec := formatters.NewRegistryProtoCodec(context.Background(), registry.NewClient(server.URL, 0), "test-value", "test.Second.Nested")
+
+	// The message type is given by the message indexes
+	data := registry.AppendWireFormat(nil, 2)
+	data = registry.AppendMessageIndexes(data, []int{1, 0})
+	data = append(data, nestedProtoData...)
+
+	text, err := codec.Format(data)
+	if err != nil {
+		t.Fatalf("Format failed: %v", err)
+	}
+	if actual := string(bytes.ReplaceAll(text, []byte(" "), nil)); actual != nestedProtoText {
+		t.Errorf("Expected text '%s' but got '%s'", nestedProtoText, text)
+	}
+
+	actual, err := codec.Parse([]byte(nestedProtoText))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if !bytes.Equal(actual, data) {
+		t.Errorf("Expected data '%v' but got '%v'", data, actual)
+	}
+}
+
+func TestRegistryProtoCodecFirstMessage(t *testing.T) {
+	server := registrytest.NewServer(protoRegistrySchemas...)
+	defer server.Close()
+
+	codec := formatters.NewRegistryProtoCodec(context.Background(), registry.NewClient(server.URL, 0), "test-value", "")
+
+	// Without message type the first message of the schema is used
+	actual, err := codec.Parse([]byte(`{"value":"abc"}`))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	expected := append(registry.AppendWireFormat(nil, 2), 0, 0x0a, 0x03, 'a', 'b', 'c')
+	if !bytes.Equal(actual, expected) {
+		t.Errorf("Expected data '%v' but got '%v'", expected, actual)
+	}
+}
+
+func TestRegistryProtoCodecFormatError(t *testing.T) {
+	server := registrytest.NewServer(append(protoRegistrySchemas,
+		registry.Schema{ID: 3, Subject: "other-value", Version: 1, Schema: `"string"`},
+	)...)
+	defer server.Close()
+
+	codec := formatters.NewRegistryProtoCodec(context.Background(), registry.NewClient(server.URL, 0), "test-value", "")
+
+	testCases := map[string][]byte{
+		"not wire format":  nestedProtoData,
+		"unknown schema":   registry.AppendMessageIndexes(registry.AppendWireFormat(nil, 4), []int{0}),
+		"not proto schema": registry.AppendMessageIndexes(registry.AppendWireFormat(nil, 3), []int{0}),
+		"unknown message":  registry.AppendMessageIndexes(registry.AppendWireFormat(nil, 2), []int{2}),
+	}
+	for name, data := range testCases {
+		t.Run(name, func(t *testing.T) {
+			if _, err := codec.Format(data); err == nil {
+				t.Fatal("Format should have failed")
+			}
+		})
+	}
+}
+
+func TestRegistryProtoFormatter(t *testing.T) {
+	server := registrytest.NewServer(protoRegistrySchemas...)
+	defer server.Close()
+
+	formatter := formatters.NewRegistryProtoFormatter(context.Background(), registry.NewClient(server.URL, 0), "test-value", "", formatters.ProtoOptions{})
+
+	var buffer bytes.Buffer
+	if _, err := buffer.WriteString("{\"value\":\"abc\"}\n"); err != nil {
+		t.Fatalf("WriteString failed: %v", err)
+	}
+	message, err := formatter.NewReader(&buffer).Read()
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+
+	if err := formatter.NewWriter(&buffer).Write(message); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if actual := string(bytes.ReplaceAll(buffer.Bytes(), []byte(" "), nil)); actual != "{\"value\":\"abc\"}\n" {
+		t.Errorf("Expected '{\"value\":\"abc\"}' but got '%s'", buffer.Bytes())
+	}
+}
@@ -6,41 +6,185 @@ This file is part of kafka-client.
 package formatters
 
 import (
+	"bufio"
+	"bytes"
 	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
 	"io"
+	"time"
 
 	"github.com/bluekiri/kafka-client/internal/dto"
 )
 
-func NewRawFormatter() Formatter {
-	return &rawFactory{}
+/*
+The raw format writes the messages as little endian binary records.
+
+The legacy (v1) layout has no file header and every record is just the key
+and the value, each one prefixed by its uint32 length.
+
+The v2 layout starts with a file header:
+
+	magic         [4]byte "\x89KCR"
+	version       uint8   2
+	header length int32   length of the following header fields
+	created       int64   creation time in milliseconds since the epoch
+	cluster       bytes   source cluster
+	topic         bytes   source topic
+
+followed by the records:
+
+	record length int32   length of the record fields
+	partition     int32
+	offset        int64
+	timestamp     int64   milliseconds since the epoch, -1 if unknown
+	key           bytes
+	value         bytes
+	header count  uint32
+	headers       bytes   key and value of every header
+	crc           uint32  CRC-32C of the record fields
+
+where bytes is an int32 length, -1 for null as in the Kafka protocol, followed
+by the data. Readers ignore any header field they don't know, so new fields can
+be appended to the file header.
+
+No length can exceed the maximum size of a Kafka request, so a corrupted length
+is detected before allocating its data.
+*/
+
+const (
+	rawVersion uint8 = 2
+
+	// rawMaxLength is the maximum size of a Kafka request.
+	rawMaxLength = 100 * 1024 * 1024
+)
+
+var (
+	rawMagic    = []byte{0x89, 'K', 'C', 'R'}
+	rawCRCTable = crc32.MakeTable(crc32.Castagnoli)
+
+	// ErrRawChecksum is returned when the CRC or the length of a record is
+	// corrupted.
+	ErrRawChecksum = errors.New("raw format: record checksum mismatch")
+
+	errRawLength = errors.New("raw format: invalid length")
+)
+
+// RawHeader describes the content of a raw file.
+type RawHeader struct {
+	Version uint8
+	Created time.Time
+	Cluster string
+	Topic   string
 }
 
-type rawFactory struct{}
+// NewRawFormatter returns a Formatter whose writers write the v2 layout using
+// the given header and whose readers read both the v2 and the legacy layout.
+func NewRawFormatter(header RawHeader) Formatter {
+	return &rawFactory{header}
+}
+
+type rawFactory struct {
+	header RawHeader
+}
 
 func (factory *rawFactory) NewReader(reader io.Reader) Reader {
-	return &rawReader{reader}
+	return &rawReader{reader: bufio.NewReader(reader)}
 }
 
+// NewWriter returns a Writer that writes the file header right away, so a file
+// without messages is still read as v2. If the header can't be written, the
+// error is returned by the first Write.
 func (factory *rawFactory) NewWriter(writer io.Writer) Writer {
-	return &rawWriter{writer}
+	header := factory.header
+	header.Version = rawVersion
+	if header.Created.IsZero() {
+		header.Created = time.Now()
+	}
+	rawWriter := &rawWriter{writer: writer, header: header}
+	rawWriter.headerErr = rawWriter.writeHeader()
+	return rawWriter
 }
 
 type rawReader struct {
-	reader io.Reader
+	reader *bufio.Reader
+	header *RawHeader
+}
+
+// readHeader returns the header of the file, reading it if not read yet.
+func (reader *rawReader) readHeader() (*RawHeader, error) {
+	if reader.header != nil {
+		return reader.header, nil
+	}
+
+	// Legacy files have no magic number
+	magic, err := reader.reader.Peek(len(rawMagic))
+	if err != nil || !bytes.Equal(magic, rawMagic) {
+		reader.header = &RawHeader{Version: 1}
+		return reader.header, nil
+	}
+	if _, err := reader.reader.Discard(len(rawMagic)); err != nil {
+		return nil, err
+	}
+
+	var version uint8
+	if err := binary.Read(reader.reader, binary.LittleEndian, &version); err != nil {
+		return nil, err
+	}
+	if version != rawVersion {
+		return nil, fmt.Errorf("raw format: unsupported version %d", version)
+	}
+
+	fields, err := readBytes(reader.reader)
+	if err != nil {
+		return nil, err
+	}
+	fieldsReader := bytes.NewReader(fields)
+	header := &RawHeader{Version: version}
+	var created int64
+	if err := binary.Read(fieldsReader, binary.LittleEndian, &created); err != nil {
+		return nil, err
+	}
+	header.Created = time.UnixMilli(created)
+	cluster, err := readBytes(fieldsReader)
+	if err != nil {
+		return nil, err
+	}
+	header.Cluster = string(cluster)
+	topic, err := readBytes(fieldsReader)
+	if err != nil {
+		return nil, err
+	}
+	header.Topic = string(topic)
+
+	reader.header = header
+	return header, nil
 }
 
 func (reader *rawReader) Read() (*dto.KafkaMessage, error) {
+	header, err := reader.readHeader()
+	if err != nil {
+		return nil, err
+	}
+
+	if header.Version == 1 {
+		return reader.readV1()
+	}
+	return reader.readV2()
+}
+
+func (reader *rawReader) readV1() (*dto.KafkaMessage, error) {
 	// Read the key
-	key, err := reader.readBytes()
+	key, err := readBytes(reader.reader)
 	if err != nil {
 		return nil, err
 	}
 
 	// Read the value
-	value, err := reader.readBytes()
+	value, err := readBytes(reader.reader)
 	if err != nil {
-		return nil, err
+		return nil, unexpectedEOF(err)
 	}
 
 	message := &dto.KafkaMessage{
@@ -51,36 +195,147 @@ func (reader *rawReader) Read() (*dto.KafkaMessage, error) {
 	return message, nil
 }
 
-func (reader *rawReader) readBytes() ([]byte, error) {
+func (reader *rawReader) readV2() (*dto.KafkaMessage, error) {
+	// Read the record and check its CRC
+	record, err := readBytes(reader.reader)
+	if errors.Is(err, errRawLength) {
+		return nil, ErrRawChecksum
+	}
+	if err != nil {
+		return nil, err
+	}
+	var crc uint32
+	if err := binary.Read(reader.reader, binary.LittleEndian, &crc); err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	if crc32.Checksum(record, rawCRCTable) != crc {
+		return nil, ErrRawChecksum
+	}
+
+	// Decode the record fields
+	recordReader := bytes.NewReader(record)
+	message := &dto.KafkaMessage{}
+	var timestamp int64
+	for _, field := range []any{&message.Partition, &message.Offset, &timestamp} {
+		if err := binary.Read(recordReader, binary.LittleEndian, field); err != nil {
+			return nil, unexpectedEOF(err)
+		}
+	}
+	if timestamp >= 0 {
+		message.Timestamp = time.UnixMilli(timestamp)
+	}
+	if message.Key, err = readBytes(recordReader); err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	if message.Value, err = readBytes(recordReader); err != nil {
+		return nil, unexpectedEOF(err)
+	}
+
+	var headerCount uint32
+	if err := binary.Read(recordReader, binary.LittleEndian, &headerCount); err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	for i := uint32(0); i < headerCount; i++ {
+		var header dto.KafkaHeader
+		if header.Key, err = readBytes(recordReader); err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		if header.Value, err = readBytes(recordReader); err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		message.Headers = append(message.Headers, header)
+	}
+
+	return message, nil
+}
+
+func readBytes(reader io.Reader) ([]byte, error) {
 	// Read the data length
-	var length uint32
-	if err := binary.Read(reader.reader, binary.LittleEndian, &length); err != nil {
+	var length int32
+	if err := binary.Read(reader, binary.LittleEndian, &length); err != nil {
 		return nil, err
 	}
+	if length == -1 {
+		return nil, nil
+	}
+	if length < -1 || length > rawMaxLength {
+		return nil, fmt.Errorf("%w %d", errRawLength, length)
+	}
 
 	// Read the data
 	data := make([]byte, length)
-	if _, err := io.ReadFull(reader.reader, data); err != nil {
-		return nil, err
+	if _, err := io.ReadFull(reader, data); err != nil {
+		return nil, unexpectedEOF(err)
 	}
 	return data, nil
 }
 
+// unexpectedEOF converts io.EOF into io.ErrUnexpectedEOF, as reaching the end
+// of the file in the middle of a record means the file is truncated.
+func unexpectedEOF(err error) error {
+	if errors.Is(err, io.EOF) {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
 type rawWriter struct {
-	writer io.Writer
+	writer    io.Writer
+	header    RawHeader
+	headerErr error
 }
 
 func (writer *rawWriter) Write(message *dto.KafkaMessage) error {
-	if err := writer.writeBytes(message.Key); err != nil {
+	if writer.headerErr != nil {
+		return writer.headerErr
+	}
+
+	// Encode the record fields
+	var record bytes.Buffer
+	timestamp := int64(-1)
+	if !message.Timestamp.IsZero() {
+		timestamp = message.Timestamp.UnixMilli()
+	}
+	for _, field := range []any{message.Partition, message.Offset, timestamp} {
+		binary.Write(&record, binary.LittleEndian, field)
+	}
+	writeBytes(&record, message.Key)
+	writeBytes(&record, message.Value)
+	binary.Write(&record, binary.LittleEndian, uint32(len(message.Headers)))
+	for _, header := range message.Headers {
+		writeBytes(&record, header.Key)
+		writeBytes(&record, header.Value)
+	}
+
+	// Write the record followed by its CRC
+	if err := writeBytes(writer.writer, record.Bytes()); err != nil {
 		return err
 	}
-	return writer.writeBytes(message.Value)
+	return binary.Write(writer.writer, binary.LittleEndian, crc32.Checksum(record.Bytes(), rawCRCTable))
 }
 
-func (writer *rawWriter) writeBytes(bytes []byte) error {
-	if err := binary.Write(writer.writer, binary.LittleEndian, uint32(len(bytes))); err != nil {
+func (writer *rawWriter) writeHeader() error {
+	var fields bytes.Buffer
+	binary.Write(&fields, binary.LittleEndian, writer.header.Created.UnixMilli())
+	writeBytes(&fields, []byte(writer.header.Cluster))
+	writeBytes(&fields, []byte(writer.header.Topic))
+
+	if _, err := writer.writer.Write(rawMagic); err != nil {
+		return err
+	}
+	if err := binary.Write(writer.writer, binary.LittleEndian, writer.header.Version); err != nil {
+		return err
+	}
+	return writeBytes(writer.writer, fields.Bytes())
+}
+
+func writeBytes(writer io.Writer, data []byte) error {
+	if data == nil {
+		return binary.Write(writer, binary.LittleEndian, int32(-1))
+	}
+	if err := binary.Write(writer, binary.LittleEndian, int32(len(data))); err != nil {
 		return err
 	}
-	_, err := writer.writer.Write(bytes)
+	_, err := writer.Write(data)
 	return err
 }
@@ -2,9 +2,14 @@ package formatters_test
 
 import (
 	"bytes"
+	"encoding/binary"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"reflect"
 	"testing"
+	"time"
 
 	"github.com/bluekiri/kafka-client/internal/dto"
 	"github.com/bluekiri/kafka-client/internal/formatters"
@@ -13,6 +18,17 @@ import (
 var expectedRawMessage = dto.KafkaMessage {
 	Key: []byte("this is a key"),
 	Value: []byte("this is a message"),
+	Headers: []dto.KafkaHeader{
+		{Key: []byte("trace-id"), Value: []byte("abc")},
+	},
+	Timestamp: time.UnixMilli(1790000000123),
+	Partition: 3,
+	Offset: 1200,
+}
+
+var rawHeader = formatters.RawHeader{
+	Cluster: "localhost:9092",
+	Topic:   "test",
 }
 
 func TestRawFormatter(t *testing.T) {
@@ -21,7 +37,7 @@ func TestRawFormatter(t *testing.T) {
 		t.Fatalf("os.Pipe failed: %v", err)
 	}
 
-	formatter := formatters.NewRawFormatter()
+	formatter := formatters.NewRawFormatter(rawHeader)
 
 	// If we write a messsage to the formater and then read it we should
 	// get a message that is equal to the written
@@ -42,6 +58,77 @@ func TestRawFormatter(t *testing.T) {
 	if !bytes.Equal(actual.Value, expectedRawMessage.Value) {
 		t.Errorf("Expected value '%v' but got '%v'", expectedRawMessage.Value, actual.Value)
 	}
+
+	if !reflect.DeepEqual(actual.Headers, expectedRawMessage.Headers) {
+		t.Errorf("Expected headers '%v' but got '%v'", expectedRawMessage.Headers, actual.Headers)
+	}
+
+	if !actual.Timestamp.Equal(expectedRawMessage.Timestamp) {
+		t.Errorf("Expected timestamp '%v' but got '%v'", expectedRawMessage.Timestamp, actual.Timestamp)
+	}
+
+	if actual.Partition != expectedRawMessage.Partition || actual.Offset != expectedRawMessage.Offset {
+		t.Errorf("Expected partition %d offset %d but got partition %d offset %d",
+			expectedRawMessage.Partition, expectedRawMessage.Offset, actual.Partition, actual.Offset)
+	}
+}
+
+func TestRawFormatterWritesHeaderOnce(t *testing.T) {
+	var buffer bytes.Buffer
+
+	formatter := formatters.NewRawFormatter(rawHeader)
+
+	// Write two messages with the same writer and read them back
+	writer := formatter.NewWriter(&buffer)
+	for i := 0; i < 2; i++ {
+		if err := writer.Write(&expectedRawMessage); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+
+	reader := formatter.NewReader(&buffer)
+	for i := 0; i < 2; i++ {
+		actual, err := reader.Read()
+		if err != nil {
+			t.Fatalf("Read failed: %v", err)
+		}
+		if !bytes.Equal(actual.Value, expectedRawMessage.Value) {
+			t.Errorf("Expected value '%v' but got '%v'", expectedRawMessage.Value, actual.Value)
+		}
+	}
+
+	if _, err := reader.Read(); !errors.Is(err, io.EOF) {
+		t.Errorf("Expected io.EOF but got %v", err)
+	}
+}
+
+func TestRawFormatterReadLegacy(t *testing.T) {
+	// Get a message in the legacy raw format
+	var buffer bytes.Buffer
+	for _, data := range [][]byte{expectedRawMessage.Key, expectedRawMessage.Value} {
+		binary.Write(&buffer, binary.LittleEndian, uint32(len(data)))
+		buffer.Write(data)
+	}
+
+	formatter := formatters.NewRawFormatter(rawHeader)
+
+	reader := formatter.NewReader(&buffer)
+	actual, err := reader.Read()
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+
+	if !bytes.Equal(actual.Key, expectedRawMessage.Key) {
+		t.Errorf("Expected key '%v' but got '%v'", expectedRawMessage.Key, actual.Key)
+	}
+
+	if !bytes.Equal(actual.Value, expectedRawMessage.Value) {
+		t.Errorf("Expected value '%v' but got '%v'", expectedRawMessage.Value, actual.Value)
+	}
+
+	if _, err := reader.Read(); !errors.Is(err, io.EOF) {
+		t.Errorf("Expected io.EOF but got %v", err)
+	}
 }
 
 func TestRawFormatterWriteError(t *testing.T) {
@@ -50,7 +137,7 @@ func TestRawFormatterWriteError(t *testing.T) {
 		t.Fatalf("os.Pipe failed: %v", err)
 	}
 
-	formatter := formatters.NewRawFormatter()
+	formatter := formatters.NewRawFormatter(rawHeader)
 
 	// Write should fail when trying to write to a closed writer
 	writer.Close()
@@ -62,40 +149,126 @@ func TestRawFormatterWriteError(t *testing.T) {
 
 func TestRawFormatterReadError(t *testing.T) {
 	// Get a message in raw format
-	reader, writer, err := os.Pipe()
+	var buffer bytes.Buffer
+
+	formatter := formatters.NewRawFormatter(rawHeader)
+
+	err := formatter.NewWriter(&buffer).Write(&expectedRawMessage)
 	if err != nil {
-		t.Fatalf("os.Pipe failed: %v", err)
+		t.Fatalf("Write failed: %v", err)
 	}
 
-	formatter := formatters.NewRawFormatter()
+	raw := buffer.Bytes()
 
-	err = formatter.NewWriter(writer).Write(&expectedRawMessage)
+	// Any truncation of the file must fail
+	for tc := 1; tc < len(raw); tc++ {
+		t.Run(fmt.Sprintf("%d", tc), func(t *testing.T) {
+			reader := bytes.NewReader(raw[:tc])
+			_, err = formatter.NewReader(reader).Read()
+			if err == nil {
+				t.Fatal("Read should have failed")
+			}
+		})
+	}
+}
+
+func TestRawFormatterReadChecksumError(t *testing.T) {
+	// Get a message in raw format
+	var buffer bytes.Buffer
+
+	formatter := formatters.NewRawFormatter(rawHeader)
+
+	err := formatter.NewWriter(&buffer).Write(&expectedRawMessage)
 	if err != nil {
 		t.Fatalf("Write failed: %v", err)
 	}
 
-	buff := make([]byte, 1024)
-	n, err := reader.Read(buff) 
+	// Corrupt the last byte of the value
+	raw := buffer.Bytes()
+	index := bytes.LastIndex(raw, expectedRawMessage.Value) + len(expectedRawMessage.Value) - 1
+	raw[index]++
+
+	_, err = formatter.NewReader(bytes.NewReader(raw)).Read()
+	if !errors.Is(err, formatters.ErrRawChecksum) {
+		t.Fatalf("Expected checksum error but got %v", err)
+	}
+}
+
+func TestRawFormatterReadUnsupportedVersion(t *testing.T) {
+	formatter := formatters.NewRawFormatter(rawHeader)
+
+	raw := []byte{0x89, 'K', 'C', 'R', 3}
+	_, err := formatter.NewReader(bytes.NewReader(raw)).Read()
+	if err == nil {
+		t.Fatal("Read should have failed")
+	}
+}
+
+func TestRawFormatterWritesHeaderWithoutMessages(t *testing.T) {
+	var buffer bytes.Buffer
+
+	formatter := formatters.NewRawFormatter(rawHeader)
+
+	// The header is written even if no message is
+	formatter.NewWriter(&buffer)
+	if !bytes.HasPrefix(buffer.Bytes(), []byte{0x89, 'K', 'C', 'R', 2}) {
+		t.Fatalf("Expected the v2 header but got '%v'", buffer.Bytes())
+	}
+
+	if _, err := formatter.NewReader(&buffer).Read(); !errors.Is(err, io.EOF) {
+		t.Errorf("Expected io.EOF but got %v", err)
+	}
+}
+
+func TestRawFormatterNil(t *testing.T) {
+	var buffer bytes.Buffer
+
+	formatter := formatters.NewRawFormatter(rawHeader)
+
+	// Nil keys and values are read back as nil and empty ones as empty
+	message := &dto.KafkaMessage{
+		Value: []byte{},
+		Headers: []dto.KafkaHeader{
+			{Key: []byte("nil"), Value: nil},
+			{Key: []byte("empty"), Value: []byte{}},
+		},
+	}
+	if err := formatter.NewWriter(&buffer).Write(message); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	actual, err := formatter.NewReader(&buffer).Read()
 	if err != nil {
-		t.Fatalf("Read from pipe failed: %v", err)
+		t.Fatalf("Read failed: %v", err)
+	}
+	if actual.Key != nil {
+		t.Errorf("Expected a nil key but got '%v'", actual.Key)
+	}
+	if actual.Value == nil || len(actual.Value) != 0 {
+		t.Errorf("Expected an empty value but got '%#v'", actual.Value)
 	}
+	if len(actual.Headers) != 2 || actual.Headers[0].Value != nil || actual.Headers[1].Value == nil {
+		t.Errorf("Expected a nil and an empty header value but got '%#v'", actual.Headers)
+	}
+}
+
+func TestRawFormatterReadCorruptedLength(t *testing.T) {
+	var buffer bytes.Buffer
 
-	raw := buff[:n]
+	formatter := formatters.NewRawFormatter(rawHeader)
 
-	testCases := []int{
-		3,
-		4 + len(expectedRawMessage.Key) - 1,
-		4 + len(expectedRawMessage.Key) + 3,
-		4 + len(expectedRawMessage.Key) + 4 + len(expectedRawMessage.Value) - 1,
+	writer := formatter.NewWriter(&buffer)
+	headerLength := buffer.Len()
+	if err := writer.Write(&expectedRawMessage); err != nil {
+		t.Fatalf("Write failed: %v", err)
 	}
 
-	for _, tc := range testCases {
-		t.Run(fmt.Sprintf("%d", tc), func(t *testing.T) {
-			reader := bytes.NewReader(raw[:tc])
-			_, err = formatter.NewReader(reader).Read()
-			if err == nil {
-				t.Fatal("Read should have failed")
-			}		
-		})
+	// Corrupt the length of the record, which is not allocated
+	raw := buffer.Bytes()
+	binary.LittleEndian.PutUint32(raw[headerLength:], 0x7fffffff)
+
+	_, err := formatter.NewReader(bytes.NewReader(raw)).Read()
+	if !errors.Is(err, formatters.ErrRawChecksum) {
+		t.Fatalf("Expected checksum error but got %v", err)
 	}
 }
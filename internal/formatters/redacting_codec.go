@@ -0,0 +1,40 @@
+/*
+Copyright © 2023 VECI Group Tech S.L.
+This file is part of kafka-client.
+*/
+
+package formatters
+
+import "github.com/bluekiri/kafka-client/internal/redact"
+
+// NewRedactingCodec returns a Codec that represents the data like the given
+// codec but with the fields of the representation, which must be JSON,
+// redacted by the redactor. Parsing is not affected. The given codec is
+// returned if the redactor is nil.
+func NewRedactingCodec(codec Codec, redactor *redact.Redactor) Codec {
+	if redactor == nil {
+		return codec
+	}
+	return &redactingCodec{codec, redactor}
+}
+
+type redactingCodec struct {
+	codec    Codec
+	redactor *redact.Redactor
+}
+
+func (codec *redactingCodec) Format(data []byte) ([]byte, error) {
+	text, err := codec.codec.Format(data)
+	if err != nil {
+		return nil, err
+	}
+	return codec.redactor.RedactJSON(text)
+}
+
+func (codec *redactingCodec) Parse(text []byte) ([]byte, error) {
+	return codec.codec.Parse(text)
+}
+
+func (codec *redactingCodec) IsJSON() bool {
+	return true
+}
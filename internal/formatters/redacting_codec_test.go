@@ -0,0 +1,38 @@
+package formatters_test
+
+import (
+	"testing"
+
+	"github.com/bluekiri/kafka-client/internal/formatters"
+	"github.com/bluekiri/kafka-client/internal/redact"
+)
+
+func TestRedactingCodec(t *testing.T) {
+	redactor, err := redact.NewRedactor(nil, redact.Field{Path: "card.number", Policy: redact.Mask})
+	if err != nil {
+		t.Fatalf("NewRedactor failed: %v", err)
+	}
+	codec := formatters.NewRedactingCodec(formatters.TextCodec, redactor)
+
+	text, err := codec.Format([]byte(`{"card":{"number":"4111111111111111","expiry":"12/30"}}`))
+	if err != nil {
+		t.Fatalf("Format failed: %v", err)
+	}
+	if expected := `{"card":{"expiry":"12/30","number":"****"}}`; string(text) != expected {
+		t.Errorf("Expected '%s' but got '%s'", expected, text)
+	}
+
+	// Values that can't be redacted must not be written
+	if _, err := codec.Format([]byte("4111111111111111 is not JSON")); err == nil {
+		t.Error("Format should have failed")
+	}
+
+	// Parsing is not affected
+	if data, err := codec.Parse([]byte(`{"card":{"number":"4111"}}`)); err != nil || string(data) != `{"card":{"number":"4111"}}` {
+		t.Errorf("Unexpected Parse result '%s' and error %v", data, err)
+	}
+
+	if codec := formatters.NewRedactingCodec(formatters.HexCodec, nil); codec != formatters.HexCodec {
+		t.Error("Expected the codec without redactor")
+	}
+}
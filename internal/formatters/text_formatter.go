@@ -13,21 +13,37 @@ import (
 	"github.com/bluekiri/kafka-client/internal/dto"
 )
 
-func NewTextFormatter() Formatter {
-	return &textFactory{}
+// TextOptions configures the text formatter.
+type TextOptions struct {
+	KeyOptions
+
+	// ValueCodec represents the value of the messages. Defaults to TextCodec.
+	ValueCodec Codec
+}
+
+// NewTextFormatter returns a Formatter that writes the value of every message,
+// optionally preceded by its key, in a line.
+func NewTextFormatter(options TextOptions) Formatter {
+	if options.ValueCodec == nil {
+		options.ValueCodec = TextCodec
+	}
+	return &textFactory{options}
 }
 
-type textFactory struct{}
+type textFactory struct {
+	options TextOptions
+}
 
 func (factory *textFactory) NewReader(reader io.Reader) Reader {
-	return &textReader{bufio.NewReader(reader)}
+	return &textReader{factory, bufio.NewReader(reader)}
 }
 
 func (factory *textFactory) NewWriter(writer io.Writer) Writer {
-	return &textWriter{writer}
+	return &textWriter{factory, writer}
 }
 
 type textReader struct {
+	*textFactory
 	reader *bufio.Reader
 }
 
@@ -36,17 +52,35 @@ func (reader *textReader) Read() (*dto.KafkaMessage, error) {
 	if err != nil {
 		return nil, err
 	}
+	key, text, err := reader.options.cutKey(line[:len(line)-1])
+	if err != nil {
+		return nil, err
+	}
+	value, err := reader.options.ValueCodec.Parse(text)
+	if err != nil {
+		return nil, err
+	}
 	message := &dto.KafkaMessage{
-		Value: line[:len(line)-1],
+		Key:   key,
+		Value: value,
 	}
 	return message, nil
 }
 
 type textWriter struct {
+	*textFactory
 	writer io.Writer
 }
 
 func (writer *textWriter) Write(message *dto.KafkaMessage) error {
-	_, err := fmt.Fprintln(writer.writer, string(message.Value))
+	key, err := writer.options.prefix(message.Key)
+	if err != nil {
+		return err
+	}
+	value, err := writer.options.ValueCodec.Format(message.Value)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(writer.writer, string(key)+string(value))
 	return err
 }
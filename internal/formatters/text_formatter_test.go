@@ -21,7 +21,7 @@ func TestTextFormatterWrite(t *testing.T) {
 		t.Fatalf("os.Pipe failed: %v", err)
 	}
 
-	formatter := formatters.NewTextFormatter()
+	formatter := formatters.NewTextFormatter(formatters.TextOptions{})
 
 	// TextFormatter Writer only writes the value followed by newline
 	err = formatter.NewWriter(writer).Write(&expectedTextMessage)
@@ -50,7 +50,7 @@ func TestTextFormatterWriteError(t *testing.T) {
 		t.Fatalf("os.Pipe failed: %v", err)
 	}
 
-	formatter := formatters.NewTextFormatter()
+	formatter := formatters.NewTextFormatter(formatters.TextOptions{})
 
 	// TextFormatter Writer should fail when trying to write to a closed writer
 	writer.Close()
@@ -66,7 +66,7 @@ func TestTextFormatterRead(t *testing.T) {
 		t.Fatalf("os.Pipe failed: %v", err)
 	}
 
-	formatter := formatters.NewTextFormatter()
+	formatter := formatters.NewTextFormatter(formatters.TextOptions{})
 
 	// Write the value + new line
 	_, err = fmt.Fprintln(writer, string(expectedTextMessage.Value))
@@ -95,7 +95,7 @@ func TestTextFormatterReadError(t *testing.T) {
 		t.Fatalf("os.Pipe failed: %v", err)
 	}
 
-	formatter := formatters.NewTextFormatter()
+	formatter := formatters.NewTextFormatter(formatters.TextOptions{})
 
 	// Write just the value (without new line)
 	_, err = fmt.Fprint(writer, string(expectedTextMessage.Value))
@@ -110,3 +110,117 @@ func TestTextFormatterReadError(t *testing.T) {
 		t.Fatal("Read should have failed")
 	}
 }
+
+func TestTextFormatterValueCodec(t *testing.T) {
+	var buffer bytes.Buffer
+
+	formatter := formatters.NewTextFormatter(formatters.TextOptions{ValueCodec: formatters.HexCodec})
+
+	// The value is written and read using the codec
+	err := formatter.NewWriter(&buffer).Write(&expectedTextMessage)
+	if err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	expected := "746869732069732061206d657373616765\n"
+	if actual := buffer.String(); actual != expected {
+		t.Errorf("Expected '%s' but got '%s'", expected, actual)
+	}
+
+	actual, err := formatter.NewReader(&buffer).Read()
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+
+	if !bytes.Equal(actual.Value, expectedTextMessage.Value) {
+		t.Errorf("Expected value '%v' but got '%v'", expectedTextMessage.Value, actual.Value)
+	}
+}
+
+func TestTextFormatterKeySeparator(t *testing.T) {
+	var buffer bytes.Buffer
+
+	formatter := formatters.NewTextFormatter(formatters.TextOptions{
+		KeyOptions: formatters.KeyOptions{KeySeparator: "\t"},
+	})
+
+	// The key is written before the value
+	writer := formatter.NewWriter(&buffer)
+	if err := writer.Write(&expectedTextMessage); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if err := writer.Write(&dto.KafkaMessage{Value: expectedTextMessage.Value}); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	expected := "this is a key\tthis is a message\n\tthis is a message\n"
+	if actual := buffer.String(); actual != expected {
+		t.Errorf("Expected '%s' but got '%s'", expected, actual)
+	}
+
+	// Lines without separator have a null key too
+	buffer.WriteString("this is a message\n")
+
+	reader := formatter.NewReader(&buffer)
+	for _, expectedKey := range [][]byte{expectedTextMessage.Key, nil, nil} {
+		actual, err := reader.Read()
+		if err != nil {
+			t.Fatalf("Read failed: %v", err)
+		}
+		if !bytes.Equal(actual.Key, expectedKey) || (expectedKey == nil && actual.Key != nil) {
+			t.Errorf("Expected key '%v' but got '%v'", expectedKey, actual.Key)
+		}
+		if !bytes.Equal(actual.Value, expectedTextMessage.Value) {
+			t.Errorf("Expected value '%v' but got '%v'", expectedTextMessage.Value, actual.Value)
+		}
+	}
+}
+
+func TestTextFormatterKeyWithSeparator(t *testing.T) {
+	var buffer bytes.Buffer
+
+	formatter := formatters.NewTextFormatter(formatters.TextOptions{
+		KeyOptions: formatters.KeyOptions{KeySeparator: ":"},
+	})
+
+	// Keys containing the separator would be read back split at it
+	err := formatter.NewWriter(&buffer).Write(&dto.KafkaMessage{Key: []byte("tenant:42"), Value: expectedTextMessage.Value})
+	if err == nil {
+		t.Fatal("Write should have failed")
+	}
+	if buffer.Len() != 0 {
+		t.Errorf("Expected nothing written but got '%s'", buffer.String())
+	}
+}
+
+func TestTextFormatterKeyCodec(t *testing.T) {
+	var buffer bytes.Buffer
+
+	formatter := formatters.NewTextFormatter(formatters.TextOptions{
+		KeyOptions: formatters.KeyOptions{KeySeparator: ":", KeyCodec: formatters.HexCodec},
+	})
+
+	// The key is written and read using the codec
+	if err := formatter.NewWriter(&buffer).Write(&expectedTextMessage); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	expected := fmt.Sprintf("%x:%s\n", expectedTextMessage.Key, expectedTextMessage.Value)
+	if actual := buffer.String(); actual != expected {
+		t.Errorf("Expected '%s' but got '%s'", expected, actual)
+	}
+
+	actual, err := formatter.NewReader(&buffer).Read()
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if !bytes.Equal(actual.Key, expectedTextMessage.Key) {
+		t.Errorf("Expected key '%v' but got '%v'", expectedTextMessage.Key, actual.Key)
+	}
+
+	// Keys that can't be parsed by the codec must fail
+	_, err = formatter.NewReader(bytes.NewReader([]byte("not hex:this is a message\n"))).Read()
+	if err == nil {
+		t.Fatal("Read should have failed")
+	}
+}
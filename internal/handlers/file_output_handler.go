@@ -41,6 +41,9 @@ func (handler *fileOutputHandler) Run() error {
 		if err != nil {
 			return err
 		}
+
+		// Notify the message source the message was written
+		message.Done()
 	}
 	return nil
 }
@@ -0,0 +1,44 @@
+package handlers_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/bluekiri/kafka-client/internal/dto"
+	"github.com/bluekiri/kafka-client/internal/formatters"
+	"github.com/bluekiri/kafka-client/internal/handlers"
+)
+
+func TestFileOutputHandlerDone(t *testing.T) {
+	input := make(chan *dto.KafkaMessage, 1)
+	var output bytes.Buffer
+
+	handler, err := handlers.NewFileOutputHandler(input, formatters.NewTextFormatter(formatters.TextOptions{}).NewWriter(&output))
+	if err != nil {
+		t.Fatalf("NewFileOutputHandler failed: %v", err)
+	}
+
+	go func() {
+		for range handler.Progress() {
+			// Ignore the progress
+		}
+	}()
+
+	// The message must be notified as done once written
+	done := false
+	input <- &dto.KafkaMessage{
+		Value:  []byte("this is a message"),
+		OnDone: func() { done = true },
+	}
+	close(input)
+
+	if err := handler.Run(); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if !done {
+		t.Error("the message was not notified as done")
+	}
+	if actual := output.String(); actual != "this is a message\n" {
+		t.Errorf("expected 'this is a message' to be written but got '%s'", actual)
+	}
+}
@@ -0,0 +1,79 @@
+/*
+Copyright © 2023 VECI Group Tech S.L.
+This file is part of kafka-client.
+*/
+
+package handlers
+
+import (
+	"context"
+
+	"github.com/bluekiri/kafka-client/internal/dto"
+	"github.com/bluekiri/kafka-client/internal/filters"
+)
+
+// NewFilterHandler returns an InputHandler forwarding the messages of the
+// input channel that match the filter. Every message is forwarded if the
+// filter is nil.
+//
+// The messages that don't match the filter are marked as done, so they are
+// committed like the handled ones. The handler stops when the evaluation of
+// the filter fails, without marking the message as done, so it isn't
+// committed.
+func NewFilterHandler(input <-chan *dto.KafkaMessage, filter filters.Filter) (InputHandler, error) {
+	handler := &filterHandler{
+		inputHandler: &inputHandler{
+			messages: make(chan *dto.KafkaMessage),
+			progress: make(chan error),
+		},
+		input:  input,
+		filter: filter,
+	}
+
+	return handler, nil
+}
+
+type filterHandler struct {
+	*inputHandler
+	input  <-chan *dto.KafkaMessage
+	filter filters.Filter
+}
+
+func (handler *filterHandler) Start(ctx context.Context) func() error {
+	handler.ctx = ctx
+	return handler.run
+}
+
+func (handler *filterHandler) run() error {
+	defer handler.close()
+
+	for {
+		var message *dto.KafkaMessage
+		var ok bool
+		select {
+		case message, ok = <-handler.input:
+			if !ok {
+				return handler.ctx.Err()
+			}
+		case <-handler.ctx.Done():
+			return handler.ctx.Err()
+		}
+
+		if handler.filter != nil {
+			matched, err := handler.filter.Match(message)
+			if err != nil {
+				return messageError(message, err)
+			}
+			if !matched {
+				message.Done()
+				continue
+			}
+		}
+
+		select {
+		case handler.messages <- message:
+		case <-handler.ctx.Done():
+			return handler.ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package handlers_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bluekiri/kafka-client/internal/dto"
+	"github.com/bluekiri/kafka-client/internal/filters"
+	"github.com/bluekiri/kafka-client/internal/handlers"
+)
+
+// evenOffsetFilter matches the messages with even offsets and fails for
+// negative offsets.
+type evenOffsetFilter struct{}
+
+func (evenOffsetFilter) Match(message *dto.KafkaMessage) (bool, error) {
+	if message.Offset < 0 {
+		return false, errors.New("negative offset")
+	}
+	return message.Offset%2 == 0, nil
+}
+
+// sendMessages returns a channel with messages at the given offsets, closed
+// after them, and a function returning how many of them are done.
+func sendMessages(offsets ...int64) (<-chan *dto.KafkaMessage, func() int) {
+	input := make(chan *dto.KafkaMessage, len(offsets))
+	done := 0
+	for _, offset := range offsets {
+		input <- &dto.KafkaMessage{
+			Value:  []byte(fmt.Sprint(offset)),
+			Offset: offset,
+			OnDone: func() { done++ },
+		}
+	}
+	close(input)
+	return input, func() int { return done }
+}
+
+// runStage runs the handler and returns the forwarded messages, the number of
+// reported errors and the error returned by the handler.
+func runStage(t *testing.T, handler handlers.InputHandler) ([]*dto.KafkaMessage, int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	errs := make(chan int, 1)
+	go func() {
+		count := 0
+		for err := range handler.Progress() {
+			if err != nil {
+				count++
+			}
+		}
+		errs <- count
+	}()
+
+	result := make(chan error, 1)
+	go func() {
+		result <- handler.Start(ctx)()
+	}()
+
+	var forwarded []*dto.KafkaMessage
+	for message := range handler.Messages() {
+		forwarded = append(forwarded, message)
+	}
+	err := <-result
+	return forwarded, <-errs, err
+}
+
+func TestFilterHandler(t *testing.T) {
+	testCases := map[string]struct {
+		filter   filters.Filter
+		expected int
+	}{
+		"filter":    {evenOffsetFilter{}, 2},
+		"no filter": {nil, 4},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			input, done := sendMessages(0, 1, 2, 3)
+			handler, err := handlers.NewFilterHandler(input, tc.filter)
+			if err != nil {
+				t.Fatalf("NewFilterHandler failed: %v", err)
+			}
+
+			forwarded, errors, err := runStage(t, handler)
+			if err != nil {
+				t.Fatalf("the handler returned the error %v", err)
+			}
+			if len(forwarded) != tc.expected || errors != 0 {
+				t.Errorf("expected %d messages and no errors but got %d and %d", tc.expected, len(forwarded), errors)
+			}
+			// The messages not forwarded must be done
+			if done() != 4-tc.expected {
+				t.Errorf("expected %d done messages but got %d", 4-tc.expected, done())
+			}
+		})
+	}
+}
+
+func TestFilterHandlerError(t *testing.T) {
+	input, done := sendMessages(0, 1, -1, 2)
+	handler, err := handlers.NewFilterHandler(input, evenOffsetFilter{})
+	if err != nil {
+		t.Fatalf("NewFilterHandler failed: %v", err)
+	}
+
+	// The handler stops at the failed message, which must not be done
+	forwarded, _, err := runStage(t, handler)
+	if err == nil {
+		t.Fatal("the handler should have failed")
+	}
+	if !strings.Contains(err.Error(), "partition 0 offset -1") {
+		t.Errorf("expected the error to locate the failed message but got %v", err)
+	}
+	if len(forwarded) != 1 || string(forwarded[0].Value) != "0" {
+		t.Errorf("unexpected forwarded messages %v", forwarded)
+	}
+	if done() != 1 {
+		t.Errorf("expected 1 done message but got %d", done())
+	}
+}
@@ -7,6 +7,7 @@ package handlers
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/bluekiri/kafka-client/internal/dto"
 )
@@ -53,3 +54,9 @@ func (handler *outputHandler) Progress() ProgressSource {
 func (handler *outputHandler) close() {
 	close(handler.progress)
 }
+
+// messageError returns the error handling the message, locating the message
+// by the partition and offset it was consumed from.
+func messageError(message *dto.KafkaMessage, err error) error {
+	return fmt.Errorf("message at partition %d offset %d: %w", message.Partition, message.Offset, err)
+}
@@ -0,0 +1,159 @@
+/*
+Copyright © 2023 VECI Group Tech S.L.
+This file is part of kafka-client.
+*/
+
+package handlers
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/bluekiri/kafka-client/internal/dto"
+
+	"github.com/IBM/sarama"
+)
+
+// NewKafkaGroupInputHandler returns an InputHandler that consumes the topic as
+// a member of the given consumer group. The offset of every message is marked
+// to be committed once an output handler notifies the message is done.
+//
+// The initial offset of the partitions without a committed offset is taken from
+// the client configuration, so only the MaxMessages and WaitDone options are
+// used.
+func NewKafkaGroupInputHandler(client sarama.Client, topic string, group string, options KafkaInputOptions) (InputHandler, error) {
+	if len(options.Partitions) > 0 || len(options.Start.ByPartition) > 0 || options.UntilEnd || options.End != nil {
+		return nil, errors.New("kafka: partitions, start offsets, end offsets and until end are not supported by consumer groups")
+	}
+
+	consumerGroup, err := sarama.NewConsumerGroupFromClient(group, client)
+	if err != nil {
+		return nil, err
+	}
+
+	handler := &kafkaGroupInputHandler{
+		inputHandler: &inputHandler{
+			messages: make(chan *dto.KafkaMessage),
+			progress: make(chan error),
+		},
+		consumerGroup: consumerGroup,
+		topic:         topic,
+		options:       options,
+	}
+
+	return handler, nil
+}
+
+type kafkaGroupInputHandler struct {
+	*inputHandler
+	consumerGroup sarama.ConsumerGroup
+	topic         string
+	options       KafkaInputOptions
+	sender        *limitedSender
+
+	// pending counts the messages of the current session not done yet
+	pending sync.WaitGroup
+}
+
+func (handler *kafkaGroupInputHandler) Start(ctx context.Context) func() error {
+	handler.ctx = ctx
+	return handler.run
+}
+
+func (handler *kafkaGroupInputHandler) run() error {
+	defer handler.close()
+
+	// The consumption is stopped when the handler context is done or when the
+	// maximum number of messages is reached
+	ctx, stop := context.WithCancel(handler.ctx)
+	defer stop()
+	handler.sender = newLimitedSender(handler.messages, handler.options.MaxMessages, stop)
+
+	// Notify errors from the consumer group
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for err := range handler.consumerGroup.Errors() {
+			handler.progress <- err
+		}
+	}()
+
+	// When done, leave the group committing the marked offsets and wait until
+	// the error notifying goroutine is done
+	defer func() {
+		handler.consumerGroup.Close()
+		wg.Wait()
+	}()
+
+	// Consume returns every time the group is rebalanced, so join the group
+	// again until the context is done
+	for ctx.Err() == nil {
+		err := handler.consumerGroup.Consume(ctx, []string{handler.topic}, handler)
+		if errors.Is(err, sarama.ErrClosedConsumerGroup) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return handler.ctx.Err()
+}
+
+// Setup is run at the beginning of a new session, before ConsumeClaim.
+func (handler *kafkaGroupInputHandler) Setup(sarama.ConsumerGroupSession) error {
+	return nil
+}
+
+// Cleanup is run at the end of a session, once all ConsumeClaim goroutines have exited.
+func (handler *kafkaGroupInputHandler) Cleanup(sarama.ConsumerGroupSession) error {
+	if !handler.options.WaitDone {
+		return nil
+	}
+
+	// Wait until the messages of the session are done, unless stopped
+	done := make(chan struct{})
+	go func() {
+		handler.pending.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-handler.ctx.Done():
+	}
+	return nil
+}
+
+// ConsumeClaim reads the messages of a claimed partition and sends them
+// downstream until the session ends.
+func (handler *kafkaGroupInputHandler) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	for {
+		select {
+		case consumerMessage, ok := <-claim.Messages():
+			if !ok {
+				return nil
+			}
+			message := newKafkaMessage(consumerMessage)
+			message.OnDone = func() {
+				// If the partition was revoked in the meantime, the mark is ignored
+				session.MarkMessage(consumerMessage, "")
+				if handler.options.WaitDone {
+					handler.pending.Done()
+				}
+			}
+			if handler.options.WaitDone {
+				handler.pending.Add(1)
+			}
+			if !handler.sender.send(session.Context(), message) {
+				if handler.options.WaitDone {
+					handler.pending.Done()
+				}
+				return nil
+			}
+		case <-session.Context().Done():
+			return nil
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package handlers_test
+
+import (
+	"testing"
+
+	"github.com/bluekiri/kafka-client/internal/handlers"
+	"github.com/bluekiri/kafka-client/internal/kafkautils"
+)
+
+func TestKafkaGroupInputHandlerUnsupportedOptions(t *testing.T) {
+	testCases := map[string]handlers.KafkaInputOptions{
+		"partitions": {Partitions: []int32{0}},
+		"positions": {
+			Start: kafkautils.Positions{
+				ByPartition: map[int32]kafkautils.Position{0: kafkautils.Earliest},
+			},
+		},
+		"until end": {UntilEnd: true},
+		"end positions": {
+			End: &kafkautils.Positions{Default: kafkautils.Latest},
+		},
+	}
+
+	for name, options := range testCases {
+		t.Run(name, func(t *testing.T) {
+			_, err := handlers.NewKafkaGroupInputHandler(newTestClient(t), testTopic, "test-group", options)
+			if err == nil {
+				t.Fatal("NewKafkaGroupInputHandler should have failed")
+			}
+		})
+	}
+}
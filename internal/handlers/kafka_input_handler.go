@@ -6,16 +6,53 @@ This file is part of kafka-client.
 package handlers
 
 import (
+	"cmp"
 	"context"
+	"fmt"
+	"slices"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/bluekiri/kafka-client/internal/dto"
+	"github.com/bluekiri/kafka-client/internal/kafkautils"
 
 	"github.com/IBM/sarama"
 	"golang.org/x/sync/errgroup"
 )
 
-func NewKafkaInputHandler(client sarama.Client, topic string) (InputHandler, error) {
+// KafkaInputOptions configures which messages are consumed by the Kafka input
+// handler.
+type KafkaInputOptions struct {
+	// Start holds the position where the consumption of every partition starts.
+	Start kafkautils.Positions
+
+	// Partitions restricts the consumption to the given partitions. If empty,
+	// every partition of the topic is consumed.
+	Partitions []int32
+
+	// MaxMessages is the number of messages after which the consumption stops.
+	// Zero means no limit.
+	MaxMessages int64
+
+	// UntilEnd stops the consumption of every partition once it reaches the
+	// high watermark the partition had when the consumption started.
+	UntilEnd bool
+
+	// End, if not nil, holds the position before which the consumption of
+	// every partition stops. The consumption stops at the high watermark the
+	// partition had when the consumption started if it comes first.
+	End *kafkautils.Positions
+
+	// WaitDone makes consumer groups wait, when the partitions are rebalanced,
+	// until every message consumed before the rebalance is done, so the next
+	// consumers of the partitions start at the offsets committed for those
+	// messages. It is meant for output handlers committing the offsets
+	// themselves, as messages that are never done block the rebalance.
+	WaitDone bool
+}
+
+func NewKafkaInputHandler(client sarama.Client, topic string, options KafkaInputOptions) (InputHandler, error) {
 	consumer, err := sarama.NewConsumerFromClient(client)
 	if err != nil {
 		return nil, err
@@ -27,14 +64,38 @@ func NewKafkaInputHandler(client sarama.Client, topic string) (InputHandler, err
 		return nil, err
 	}
 
+	// Restrict the partitions to the requested ones
+	if len(options.Partitions) > 0 {
+		for _, partition := range options.Partitions {
+			if !slices.Contains(partitions, partition) {
+				return nil, fmt.Errorf("kafka: partition %d does not exist in topic %s", partition, topic)
+			}
+		}
+		partitions = options.Partitions
+	}
+	for partition := range options.Start.ByPartition {
+		if !slices.Contains(partitions, partition) {
+			return nil, fmt.Errorf("kafka: start position given for partition %d which is not consumed", partition)
+		}
+	}
+	if options.End != nil {
+		for partition := range options.End.ByPartition {
+			if !slices.Contains(partitions, partition) {
+				return nil, fmt.Errorf("kafka: end position given for partition %d which is not consumed", partition)
+			}
+		}
+	}
+
 	handler := &kafkaInputHandler{
 		inputHandler: &inputHandler{
 			messages: make(chan *dto.KafkaMessage, len(partitions)),
 			progress: make(chan error, len(partitions)),
 		},
+		client:     client,
 		consumer:   consumer,
 		topic:      topic,
 		partitions: partitions,
+		options:    options,
 	}
 
 	return handler, nil
@@ -42,9 +103,11 @@ func NewKafkaInputHandler(client sarama.Client, topic string) (InputHandler, err
 
 type kafkaInputHandler struct {
 	*inputHandler
+	client     sarama.Client
 	consumer   sarama.Consumer
 	topic      string
 	partitions []int32
+	options    KafkaInputOptions
 }
 
 func (handler *kafkaInputHandler) Start(ctx context.Context) func() error {
@@ -56,47 +119,305 @@ func (handler *kafkaInputHandler) run() error {
 	defer handler.close()
 	defer handler.consumer.Close()
 
-	g, ctx := errgroup.WithContext(handler.ctx)
+	// The partition consumers are stopped when the handler context is done or
+	// when the maximum number of messages is reached
+	ctx, stop := context.WithCancel(handler.ctx)
+	defer stop()
+	sender := newLimitedSender(handler.messages, handler.options.MaxMessages, stop)
+
+	g, ctx := errgroup.WithContext(ctx)
 
 	// For every partition, start a consumer goroutine
 	for _, partition := range handler.partitions {
 		consumePartition := partition
 		g.Go(func() error {
-			partitionConsumer, err := handler.consumer.ConsumePartition(handler.topic, consumePartition, sarama.OffsetNewest)
+			return handler.consumePartition(ctx, sender, consumePartition)
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		return err
+	}
+	return handler.ctx.Err()
+}
+
+func (handler *kafkaInputHandler) consumePartition(ctx context.Context, sender *limitedSender, partition int32) error {
+	offset, err := handler.options.Start.For(partition).Resolve(handler.client, handler.topic, partition)
+	if err != nil {
+		return err
+	}
+
+	// Resolve the end of the partition if we must stop there, and the offset
+	// of the next message to consume
+	end, next := int64(-1), int64(-1)
+	if handler.options.UntilEnd || handler.options.End != nil {
+		if end, err = handler.endOffset(partition); err != nil {
+			return err
+		}
+		if next, err = handler.firstOffset(partition, offset, end); err != nil {
+			return err
+		}
+		// Nothing to consume from this partition
+		if next >= end {
+			return nil
+		}
+	}
+
+	partitionConsumer, err := handler.consumer.ConsumePartition(handler.topic, partition, offset)
+	if err != nil {
+		return err
+	}
+
+	// Notify errors from partition consumer
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for err := range partitionConsumer.Errors() {
+			handler.progress <- err
+		}
+	}()
+
+	// When done, close the partition consumer, drain the messages already
+	// fetched and wait until the error notifying goroutine is done
+	defer func() {
+		partitionConsumer.AsyncClose()
+		for range partitionConsumer.Messages() {
+			// Discard the messages fetched after stopping
+		}
+		wg.Wait()
+	}()
+
+	// The offsets before the end may have no message to consume, like the
+	// markers of the transactions, which are never delivered by the consumer.
+	// So, if we must stop at the end, check every time no message is received
+	// for a while whether the partition still has messages before the end.
+	var idle <-chan time.Time
+	if end >= 0 {
+		ticker := time.NewTicker(handler.client.Config().Consumer.MaxWaitTime)
+		defer ticker.Stop()
+		idle = ticker.C
+	}
+	received := false
+
+	// Read messages from partition consumer and send them downstream
+	for {
+		select {
+		case consumerMessage := <-partitionConsumer.Messages():
+			received = true
+			if end >= 0 && consumerMessage.Offset >= end {
+				return nil
+			}
+			if !sender.send(ctx, newKafkaMessage(consumerMessage)) {
+				return nil
+			}
+			next = consumerMessage.Offset + 1
+			if end >= 0 && next >= end {
+				return nil
+			}
+		case <-idle:
+			if received {
+				received = false
+				continue
+			}
+			found, err := handler.hasMessages(partition, next, end)
 			if err != nil {
 				return err
 			}
+			if !found {
+				return nil
+			}
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// hasMessages returns whether the partition has a message the consumer
+// delivers from the given offset and before the end offset, fetching the
+// records in between. Control records, like the markers of the transactions,
+// and, when reading committed messages, the records of aborted transactions
+// are never delivered.
+func (handler *kafkaInputHandler) hasMessages(partition int32, offset int64, end int64) (bool, error) {
+	broker, err := handler.client.Leader(handler.topic, partition)
+	if err != nil {
+		return false, err
+	}
+
+	config := handler.client.Config()
+	for offset < end {
+		response, err := broker.Fetch(newFetchRequest(config, handler.topic, partition, offset))
+		if err != nil {
+			return false, err
+		}
+		block := response.GetBlock(handler.topic, partition)
+		if block == nil {
+			return false, sarama.ErrIncompleteResponse
+		}
+		if block.Err != sarama.ErrNoError {
+			return false, block.Err
+		}
+
+		// The records of an aborted transaction start at its first offset and
+		// end with the marker of the transaction
+		abortedTransactions := slices.Clone(block.AbortedTransactions)
+		slices.SortFunc(abortedTransactions, func(a, b *sarama.AbortedTransaction) int {
+			return cmp.Compare(a.FirstOffset, b.FirstOffset)
+		})
+		aborted := make(map[int64]bool)
 
-			var wg sync.WaitGroup
-			wg.Add(2)
-			// Read messages from partition consumer and send them downstream
-			go func() {
-				defer wg.Done()
-				for consumerMessage := range partitionConsumer.Messages() {
-					message := &dto.KafkaMessage{
-						Key:   consumerMessage.Key,
-						Value: consumerMessage.Value,
+		fetched := offset
+		for _, records := range block.RecordsSet {
+			if records.MsgSet != nil {
+				for _, message := range records.MsgSet.Messages {
+					if message.Offset >= offset && message.Offset < end {
+						return true, nil
 					}
-					handler.messages <- message
+					fetched = max(fetched, message.Offset+1)
 				}
-			}()
+			}
 
-			// Notify errors from partition consumer
-			go func() {
-				defer wg.Done()
-				for err := range partitionConsumer.Errors() {
-					handler.progress <- err
+			batch := records.RecordBatch
+			if batch == nil {
+				continue
+			}
+			fetched = max(fetched, batch.LastOffset()+1)
+			for len(abortedTransactions) > 0 && abortedTransactions[0].FirstOffset <= batch.LastOffset() {
+				aborted[abortedTransactions[0].ProducerID] = true
+				abortedTransactions = abortedTransactions[1:]
+			}
+			if batch.Control {
+				delete(aborted, batch.ProducerID)
+				continue
+			}
+			if batch.IsTransactional && aborted[batch.ProducerID] && config.Consumer.IsolationLevel == sarama.ReadCommitted {
+				continue
+			}
+			for _, record := range batch.Records {
+				if recordOffset := batch.FirstOffset + record.OffsetDelta; recordOffset >= offset && recordOffset < end {
+					return true, nil
 				}
-			}()
-
-			// Wait until the context is done, close the partition consumer
-			// and wait until the consuming goroutines are done
-			<-ctx.Done()
-			partitionConsumer.AsyncClose()
-			wg.Wait()
-			return ctx.Err()
+			}
+		}
+
+		// Nothing else to fetch
+		if fetched == offset {
+			return false, nil
+		}
+		offset = fetched
+	}
+	return false, nil
+}
+
+// newFetchRequest returns a request fetching the records of the partition
+// from the given offset, as the consumer of the given configuration does.
+func newFetchRequest(config *sarama.Config, topic string, partition int32, offset int64) *sarama.FetchRequest {
+	request := &sarama.FetchRequest{
+		MinBytes:    1,
+		MaxWaitTime: int32(config.Consumer.MaxWaitTime / time.Millisecond),
+	}
+	if config.Version.IsAtLeast(sarama.V0_10_1_0) {
+		request.Version = 3
+		request.MaxBytes = sarama.MaxResponseSize
+	}
+	if config.Version.IsAtLeast(sarama.V0_11_0_0) {
+		request.Version = 4
+		request.Isolation = config.Consumer.IsolationLevel
+	}
+	request.AddBlock(topic, partition, offset, config.Consumer.Fetch.Default, -1)
+	return request
+}
+
+// newKafkaMessage returns the KafkaMessage of a consumed message.
+func newKafkaMessage(consumerMessage *sarama.ConsumerMessage) *dto.KafkaMessage {
+	message := &dto.KafkaMessage{
+		Key:       consumerMessage.Key,
+		Value:     consumerMessage.Value,
+		Timestamp: consumerMessage.Timestamp,
+		Partition: consumerMessage.Partition,
+		Offset:    consumerMessage.Offset,
+	}
+	for _, header := range consumerMessage.Headers {
+		message.Headers = append(message.Headers, dto.KafkaHeader{
+			Key:   header.Key,
+			Value: header.Value,
 		})
 	}
+	return message
+}
+
+// endOffset returns the offset before which the consumption of the partition
+// stops: the high watermark of the partition or, if it comes first, the end
+// position of the partition.
+func (handler *kafkaInputHandler) endOffset(partition int32) (int64, error) {
+	highWatermark, err := handler.client.GetOffset(handler.topic, partition, sarama.OffsetNewest)
+	if err != nil || handler.options.End == nil {
+		return highWatermark, err
+	}
+
+	end, err := handler.options.End.For(partition).Resolve(handler.client, handler.topic, partition)
+	if err != nil {
+		return 0, err
+	}
+	switch end {
+	case sarama.OffsetOldest:
+		return handler.client.GetOffset(handler.topic, partition, sarama.OffsetOldest)
+	case sarama.OffsetNewest:
+		// Also returned for times later than the last message
+		return highWatermark, nil
+	default:
+		return min(end, highWatermark), nil
+	}
+}
+
+// firstOffset returns the absolute offset of the first message to consume.
+func (handler *kafkaInputHandler) firstOffset(partition int32, offset int64, end int64) (int64, error) {
+	switch offset {
+	case sarama.OffsetNewest:
+		return end, nil
+	case sarama.OffsetOldest:
+		return handler.client.GetOffset(handler.topic, partition, sarama.OffsetOldest)
+	default:
+		return offset, nil
+	}
+}
+
+// limitedSender sends messages downstream and stops the consumption once the
+// maximum number of messages is reached.
+type limitedSender struct {
+	messages    chan<- *dto.KafkaMessage
+	maxMessages int64
+	sent        atomic.Int64
+	stop        context.CancelFunc
+}
+
+func newLimitedSender(messages chan<- *dto.KafkaMessage, maxMessages int64, stop context.CancelFunc) *limitedSender {
+	return &limitedSender{
+		messages:    messages,
+		maxMessages: maxMessages,
+		stop:        stop,
+	}
+}
 
-	return g.Wait()
+// send sends the message downstream unless the context is done or the maximum
+// number of messages was already sent. It returns whether the consumption must
+// continue.
+func (sender *limitedSender) send(ctx context.Context, message *dto.KafkaMessage) bool {
+	if sender.maxMessages > 0 {
+		sent := sender.sent.Add(1)
+		if sent > sender.maxMessages {
+			sender.stop()
+			return false
+		}
+		if sent == sender.maxMessages {
+			defer sender.stop()
+		}
+	}
+
+	select {
+	case sender.messages <- message:
+		return true
+	case <-ctx.Done():
+		return false
+	}
 }
@@ -0,0 +1,248 @@
+package handlers_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/bluekiri/kafka-client/internal/handlers"
+	"github.com/bluekiri/kafka-client/internal/kafkautils"
+
+	"github.com/IBM/sarama"
+)
+
+const testTopic = "test"
+
+// newTestClient returns a client connected to a mock broker leading a topic
+// with two partitions with three messages each.
+func newTestClient(t *testing.T) sarama.Client {
+	return newTestClientWithOffsets(t, 0, 1, 2)
+}
+
+// newTestClientWithOffsets returns a client connected to a mock broker with
+// messages at the given offsets of every partition, whose high watermark is 3.
+func newTestClientWithOffsets(t *testing.T, offsets ...int64) sarama.Client {
+	broker := sarama.NewMockBroker(t, 1)
+	t.Cleanup(broker.Close)
+
+	fetchResponse := sarama.NewMockFetchResponse(t, 1)
+	offsetResponse := sarama.NewMockOffsetResponse(t)
+	metadataResponse := sarama.NewMockMetadataResponse(t).
+		SetBroker(broker.Addr(), broker.BrokerID())
+	for partition := int32(0); partition < 2; partition++ {
+		metadataResponse.SetLeader(testTopic, partition, broker.BrokerID())
+		offsetResponse.
+			SetOffset(testTopic, partition, sarama.OffsetOldest, 0).
+			SetOffset(testTopic, partition, sarama.OffsetNewest, 3)
+		for _, offset := range offsets {
+			value := fmt.Sprintf("partition %d offset %d", partition, offset)
+			fetchResponse.SetMessage(testTopic, partition, offset, sarama.StringEncoder(value))
+		}
+		fetchResponse.SetHighWaterMark(testTopic, partition, 3)
+	}
+
+	broker.SetHandlerByMap(map[string]sarama.MockResponse{
+		"MetadataRequest": metadataResponse,
+		"OffsetRequest":   offsetResponse,
+		"FetchRequest":    fetchResponse,
+	})
+
+	client, err := sarama.NewClient([]string{broker.Addr()}, sarama.NewConfig())
+	if err != nil {
+		t.Fatalf("sarama.NewClient failed: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+// consumeAll runs the handler and returns the number of consumed messages
+// and the error returned by the handler.
+func consumeAll(t *testing.T, handler handlers.InputHandler) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	go func() {
+		for range handler.Progress() {
+			// Ignore the progress
+		}
+	}()
+
+	result := make(chan error, 1)
+	go func() {
+		result <- handler.Start(ctx)()
+	}()
+
+	consumed := 0
+	for range handler.Messages() {
+		consumed++
+	}
+
+	err := <-result
+	if ctx.Err() != nil {
+		t.Fatal("the handler did not stop by itself")
+	}
+	return consumed, err
+}
+
+func TestKafkaInputHandlerUntilEnd(t *testing.T) {
+	handler, err := handlers.NewKafkaInputHandler(newTestClient(t), testTopic, handlers.KafkaInputOptions{
+		Start:    kafkautils.Positions{Default: kafkautils.Earliest},
+		UntilEnd: true,
+	})
+	if err != nil {
+		t.Fatalf("NewKafkaInputHandler failed: %v", err)
+	}
+
+	consumed, err := consumeAll(t, handler)
+	if err != nil {
+		t.Fatalf("the handler returned the error %v", err)
+	}
+	if consumed != 6 {
+		t.Errorf("expected 6 messages but got %d", consumed)
+	}
+}
+
+func TestKafkaInputHandlerUntilEndGap(t *testing.T) {
+	// The last offset before the end has no message, like the marker of a
+	// transaction
+	handler, err := handlers.NewKafkaInputHandler(newTestClientWithOffsets(t, 0, 1), testTopic, handlers.KafkaInputOptions{
+		Start:    kafkautils.Positions{Default: kafkautils.Earliest},
+		UntilEnd: true,
+	})
+	if err != nil {
+		t.Fatalf("NewKafkaInputHandler failed: %v", err)
+	}
+
+	consumed, err := consumeAll(t, handler)
+	if err != nil {
+		t.Fatalf("the handler returned the error %v", err)
+	}
+	if consumed != 4 {
+		t.Errorf("expected 4 messages but got %d", consumed)
+	}
+}
+
+func TestKafkaInputHandlerUntilEndFromLatest(t *testing.T) {
+	handler, err := handlers.NewKafkaInputHandler(newTestClient(t), testTopic, handlers.KafkaInputOptions{
+		Start:    kafkautils.Positions{Default: kafkautils.Latest},
+		UntilEnd: true,
+	})
+	if err != nil {
+		t.Fatalf("NewKafkaInputHandler failed: %v", err)
+	}
+
+	consumed, err := consumeAll(t, handler)
+	if err != nil {
+		t.Fatalf("the handler returned the error %v", err)
+	}
+	if consumed != 0 {
+		t.Errorf("expected no messages but got %d", consumed)
+	}
+}
+
+func TestKafkaInputHandlerMaxMessages(t *testing.T) {
+	handler, err := handlers.NewKafkaInputHandler(newTestClient(t), testTopic, handlers.KafkaInputOptions{
+		Start:       kafkautils.Positions{Default: kafkautils.Earliest},
+		MaxMessages: 4,
+	})
+	if err != nil {
+		t.Fatalf("NewKafkaInputHandler failed: %v", err)
+	}
+
+	consumed, err := consumeAll(t, handler)
+	if err != nil {
+		t.Fatalf("the handler returned the error %v", err)
+	}
+	if consumed != 4 {
+		t.Errorf("expected 4 messages but got %d", consumed)
+	}
+}
+
+func TestKafkaInputHandlerPartitions(t *testing.T) {
+	handler, err := handlers.NewKafkaInputHandler(newTestClient(t), testTopic, handlers.KafkaInputOptions{
+		Start: kafkautils.Positions{
+			Default:     kafkautils.Earliest,
+			ByPartition: map[int32]kafkautils.Position{1: kafkautils.AtOffset(1)},
+		},
+		Partitions: []int32{1},
+		UntilEnd:   true,
+	})
+	if err != nil {
+		t.Fatalf("NewKafkaInputHandler failed: %v", err)
+	}
+
+	consumed, err := consumeAll(t, handler)
+	if err != nil {
+		t.Fatalf("the handler returned the error %v", err)
+	}
+	if consumed != 2 {
+		t.Errorf("expected 2 messages but got %d", consumed)
+	}
+}
+
+func TestKafkaInputHandlerEnd(t *testing.T) {
+	testCases := map[string]struct {
+		end      kafkautils.Positions
+		expected int
+	}{
+		"offset":        {kafkautils.Positions{Default: kafkautils.AtOffset(2)}, 4},
+		"after the end": {kafkautils.Positions{Default: kafkautils.AtOffset(10)}, 6},
+		"latest":        {kafkautils.Positions{Default: kafkautils.Latest}, 6},
+		"earliest":      {kafkautils.Positions{Default: kafkautils.Earliest}, 0},
+		"by partition": {
+			kafkautils.Positions{
+				Default:     kafkautils.Latest,
+				ByPartition: map[int32]kafkautils.Position{1: kafkautils.AtOffset(1)},
+			},
+			4,
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			handler, err := handlers.NewKafkaInputHandler(newTestClient(t), testTopic, handlers.KafkaInputOptions{
+				Start: kafkautils.Positions{Default: kafkautils.Earliest},
+				End:   &testCase.end,
+			})
+			if err != nil {
+				t.Fatalf("NewKafkaInputHandler failed: %v", err)
+			}
+
+			consumed, err := consumeAll(t, handler)
+			if err != nil {
+				t.Fatalf("the handler returned the error %v", err)
+			}
+			if consumed != testCase.expected {
+				t.Errorf("expected %d messages but got %d", testCase.expected, consumed)
+			}
+		})
+	}
+}
+
+func TestKafkaInputHandlerUnknownPartition(t *testing.T) {
+	testCases := map[string]handlers.KafkaInputOptions{
+		"partitions": {Partitions: []int32{0, 2}},
+		"positions": {
+			Start: kafkautils.Positions{
+				ByPartition: map[int32]kafkautils.Position{0: kafkautils.Earliest},
+			},
+			Partitions: []int32{1},
+		},
+		"end positions": {
+			End: &kafkautils.Positions{
+				ByPartition: map[int32]kafkautils.Position{0: kafkautils.Latest},
+			},
+			Partitions: []int32{1},
+		},
+	}
+
+	for name, options := range testCases {
+		t.Run(name, func(t *testing.T) {
+			_, err := handlers.NewKafkaInputHandler(newTestClient(t), testTopic, options)
+			if err == nil {
+				t.Fatal("NewKafkaInputHandler should have failed")
+			}
+		})
+	}
+}
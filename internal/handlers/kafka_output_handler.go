@@ -6,6 +6,9 @@ This file is part of kafka-client.
 package handlers
 
 import (
+	"fmt"
+	"slices"
+	"sync"
 	"time"
 
 	"github.com/bluekiri/kafka-client/internal/dto"
@@ -13,7 +16,31 @@ import (
 	"github.com/IBM/sarama"
 )
 
-func NewKafkaOutputHandler(input <-chan *dto.KafkaMessage, pacer <-chan time.Time, client sarama.Client, topic string) (OutputHandler, error) {
+// KafkaOutputOptions configures how the Kafka output handler produces the
+// messages.
+type KafkaOutputOptions struct {
+	// KeepTimestamp produces the messages with their original timestamp
+	// instead of the time they are produced.
+	KeepTimestamp bool
+
+	// Partition, if not nil, is the partition where every message is
+	// produced, which requires the manual partitioner. Otherwise messages are
+	// produced to the partition chosen by the partitioner of the client, which
+	// is their original partition for the manual partitioner.
+	Partition *int32
+}
+
+func NewKafkaOutputHandler(input <-chan *dto.KafkaMessage, pacer <-chan time.Time, client sarama.Client, topic string, options KafkaOutputOptions) (OutputHandler, error) {
+	if options.Partition != nil {
+		partitions, err := client.Partitions(topic)
+		if err != nil {
+			return nil, err
+		}
+		if !slices.Contains(partitions, *options.Partition) {
+			return nil, fmt.Errorf("kafka: partition %d does not exist in topic %s", *options.Partition, topic)
+		}
+	}
+
 	producer, err := sarama.NewAsyncProducerFromClient(client)
 	if err != nil {
 		return nil, err
@@ -27,6 +54,8 @@ func NewKafkaOutputHandler(input <-chan *dto.KafkaMessage, pacer <-chan time.Tim
 		pacer:    pacer,
 		producer: producer,
 		topic:    topic,
+		options:  options,
+		pending:  newPendingMessages(),
 	}
 
 	return handler, nil
@@ -37,6 +66,8 @@ type kafkaOutputHandler struct {
 	pacer    <-chan time.Time
 	producer sarama.AsyncProducer
 	topic    string
+	options  KafkaOutputOptions
+	pending  *pendingMessages
 }
 
 func (handler *kafkaOutputHandler) Run() error {
@@ -51,39 +82,135 @@ func (handler *kafkaOutputHandler) produce() {
 
 	// Read next message from the input channel
 	for message := range handler.input {
-		producerMessage := &sarama.ProducerMessage{
-			Topic: handler.topic,
-			Value: sarama.ByteEncoder(message.Value),
-		}
-		// If we have no key, don't set the key
-		if len(message.Key) > 0 {
-			producerMessage.Key = sarama.ByteEncoder(message.Key)
-		}
+		producerMessage := handler.newProducerMessage(message)
 
 		// Wait for the timer (or cancelation)
 		<-handler.pacer
 
-		// Produce the message to Kafka
+		// Produce the message to Kafka, keeping track of it until it is done
+		producerMessage.Metadata = handler.pending.add(message)
 		handler.producer.Input() <- producerMessage
 	}
 }
 
+// newProducerMessage returns the message to produce for the given message.
+func (handler *kafkaOutputHandler) newProducerMessage(message *dto.KafkaMessage) *sarama.ProducerMessage {
+	producerMessage := &sarama.ProducerMessage{
+		Topic:     handler.topic,
+		Value:     sarama.ByteEncoder(message.Value),
+		Partition: message.Partition,
+		Metadata:  message,
+	}
+	if handler.options.Partition != nil {
+		producerMessage.Partition = *handler.options.Partition
+	}
+	// If we have no key, don't set the key, so null keys are produced as null
+	// and empty keys as empty
+	if message.Key != nil {
+		producerMessage.Key = sarama.ByteEncoder(message.Key)
+	}
+	for _, header := range message.Headers {
+		producerMessage.Headers = append(producerMessage.Headers, sarama.RecordHeader{
+			Key:   header.Key,
+			Value: header.Value,
+		})
+	}
+	if handler.options.KeepTimestamp {
+		producerMessage.Timestamp = message.Timestamp
+	}
+	return producerMessage
+}
+
 func (handler *kafkaOutputHandler) notifyProgress() {
 	successes, errors := handler.producer.Successes(), handler.producer.Errors()
 	for successes != nil || errors != nil {
 		select {
-		case _, ok := <-successes:
+		case success, ok := <-successes:
 			if !ok {
 				successes = nil
 				continue
 			}
+			// Notify the message source the message was produced
+			handler.pending.produced(success.Metadata.(*pendingMessage))
 			handler.progress <- nil
 		case err, ok := <-errors:
 			if !ok {
 				errors = nil
 				continue
 			}
+			handler.pending.failed(err.Msg.Metadata.(*pendingMessage))
 			handler.progress <- err
 		}
 	}
 }
+
+// pendingMessages keeps, by partition, the messages being produced in the
+// order they were sent to the producer. Messages are done in that order, once
+// the messages of their partition sent before are produced, and no message of
+// a partition is done after one of them failed. Otherwise the offset of the
+// failed message would be committed along with the next ones and, as offsets
+// only move forward, the failed message would never be consumed again.
+type pendingMessages struct {
+	mutex    sync.Mutex
+	messages map[int32][]*pendingMessage
+	failures map[int32]bool
+}
+
+type pendingMessage struct {
+	message  *dto.KafkaMessage
+	produced bool
+}
+
+func newPendingMessages() *pendingMessages {
+	return &pendingMessages{
+		messages: make(map[int32][]*pendingMessage),
+		failures: make(map[int32]bool),
+	}
+}
+
+// add returns the pending message for the given message, which is not kept if
+// a message of its partition already failed, so it is never done.
+func (pending *pendingMessages) add(message *dto.KafkaMessage) *pendingMessage {
+	pending.mutex.Lock()
+	defer pending.mutex.Unlock()
+
+	pendingMessage := &pendingMessage{message: message}
+	if !pending.failures[message.Partition] {
+		pending.messages[message.Partition] = append(pending.messages[message.Partition], pendingMessage)
+	}
+	return pendingMessage
+}
+
+// produced marks the given message as produced and notifies the message
+// sources of the messages of its partition that are done.
+func (pending *pendingMessages) produced(message *pendingMessage) {
+	pending.mutex.Lock()
+	message.produced = true
+	partition := message.message.Partition
+	messages := pending.messages[partition]
+	var done []*dto.KafkaMessage
+	for len(messages) > 0 && messages[0].produced {
+		done = append(done, messages[0].message)
+		messages = messages[1:]
+	}
+	pending.messages[partition] = messages
+	pending.mutex.Unlock()
+
+	for _, message := range done {
+		message.Done()
+	}
+}
+
+// failed drops the given message and the ones of its partition sent after
+// it, so none of them is ever done.
+func (pending *pendingMessages) failed(message *pendingMessage) {
+	pending.mutex.Lock()
+	defer pending.mutex.Unlock()
+
+	partition := message.message.Partition
+	pending.failures[partition] = true
+	messages := pending.messages[partition]
+	if index := slices.Index(messages, message); index >= 0 {
+		pending.messages[partition] = messages[:index]
+	}
+}
@@ -0,0 +1,101 @@
+package handlers_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bluekiri/kafka-client/internal/dto"
+	"github.com/bluekiri/kafka-client/internal/handlers"
+
+	"github.com/IBM/sarama"
+)
+
+// newProducerTestClient returns a client connected to a mock broker that
+// answers the produce requests with the given responses, in order.
+func newProducerTestClient(t *testing.T, responses ...any) sarama.Client {
+	broker := sarama.NewMockBroker(t, 1)
+	t.Cleanup(broker.Close)
+
+	metadataResponse := sarama.NewMockMetadataResponse(t).
+		SetBroker(broker.Addr(), broker.BrokerID())
+	for partition := int32(0); partition < 2; partition++ {
+		metadataResponse.SetLeader(testTopic, partition, broker.BrokerID())
+	}
+
+	broker.SetHandlerByMap(map[string]sarama.MockResponse{
+		"MetadataRequest": metadataResponse,
+		"ProduceRequest":  sarama.NewMockSequence(responses...),
+	})
+
+	config := sarama.NewConfig()
+	config.Producer.Partitioner = sarama.NewManualPartitioner
+	config.Producer.Return.Successes = true
+	config.Producer.Retry.Max = 0
+	client, err := sarama.NewClient([]string{broker.Addr()}, config)
+	if err != nil {
+		t.Fatalf("sarama.NewClient failed: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func TestKafkaOutputHandlerFailedMessage(t *testing.T) {
+	client := newProducerTestClient(t,
+		sarama.NewMockProduceResponse(t).SetError(testTopic, 0, sarama.ErrNotEnoughReplicas),
+		sarama.NewMockProduceResponse(t),
+	)
+
+	// The first message fails and the next ones of the partition, even if
+	// produced, are not done
+	done := make(map[int32][]int64)
+	newMessage := func(partition int32, offset int64) *dto.KafkaMessage {
+		return &dto.KafkaMessage{
+			Value:     []byte("message"),
+			Partition: partition,
+			Offset:    offset,
+			OnDone: func() {
+				done[partition] = append(done[partition], offset)
+			},
+		}
+	}
+	messages := []*dto.KafkaMessage{newMessage(0, 0), newMessage(0, 1), newMessage(1, 0)}
+	input := make(chan *dto.KafkaMessage, len(messages))
+	for _, message := range messages {
+		input <- message
+	}
+	close(input)
+
+	pacer := make(chan time.Time)
+	handler, err := handlers.NewKafkaOutputHandler(input, pacer, client, testTopic, handlers.KafkaOutputOptions{})
+	if err != nil {
+		t.Fatalf("NewKafkaOutputHandler failed: %v", err)
+	}
+	result := make(chan error)
+	go func() {
+		result <- handler.Run()
+	}()
+
+	// Produce the messages one at a time, so the first one fails before the
+	// next ones are produced
+	var results []error
+	for range messages {
+		pacer <- time.Now()
+		results = append(results, <-handler.Progress())
+	}
+	for err := range handler.Progress() {
+		t.Errorf("unexpected progress %v", err)
+	}
+	if err := <-result; err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	if results[0] == nil || results[1] != nil || results[2] != nil {
+		t.Fatalf("expected the first message to fail but got %v", results)
+	}
+	if len(done[0]) != 0 {
+		t.Errorf("expected no message of partition 0 done but got the offsets %v", done[0])
+	}
+	if len(done[1]) != 1 {
+		t.Errorf("expected the message of partition 1 done but got the offsets %v", done[1])
+	}
+}
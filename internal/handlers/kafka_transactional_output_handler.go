@@ -0,0 +1,180 @@
+/*
+Copyright © 2023 VECI Group Tech S.L.
+This file is part of kafka-client.
+*/
+
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/bluekiri/kafka-client/internal/dto"
+
+	"github.com/IBM/sarama"
+)
+
+// KafkaTransactionOptions configures the transactions of the transactional
+// Kafka output handler.
+type KafkaTransactionOptions struct {
+	// Group is the consumer group whose offsets are committed by the
+	// transactions and Topic the topic the messages are consumed from.
+	Group string
+	Topic string
+
+	// Interval is the time after which the transaction holding the messages
+	// produced since the previous one is committed.
+	Interval time.Duration
+}
+
+// NewKafkaTransactionalOutputHandler returns an OutputHandler that produces
+// the messages, consumed from the topic of the options by the consumer group
+// of the options, in transactions committing the offsets of the consumed
+// messages atomically with the produced messages. The client must be
+// configured with a transactional ID.
+//
+// The messages are done once their transaction is committed. The handler
+// stops when a transaction fails, so the consumption can be resumed from the
+// offsets committed by the last successful transaction.
+func NewKafkaTransactionalOutputHandler(input <-chan *dto.KafkaMessage, pacer <-chan time.Time, client sarama.Client, topic string, options KafkaOutputOptions, transaction KafkaTransactionOptions) (OutputHandler, error) {
+	if client.Config().Producer.Transaction.ID == "" {
+		return nil, errors.New("kafka: the transactional output requires a transactional ID")
+	}
+	if transaction.Group == "" || transaction.Topic == "" {
+		return nil, errors.New("kafka: the transactional output requires the consumer group and topic of the messages")
+	}
+	if transaction.Interval <= 0 {
+		return nil, fmt.Errorf("kafka: invalid transaction interval %v", transaction.Interval)
+	}
+
+	producer, err := sarama.NewAsyncProducerFromClient(client)
+	if err != nil {
+		return nil, err
+	}
+
+	handler := &kafkaTransactionalOutputHandler{
+		kafkaOutputHandler: &kafkaOutputHandler{
+			outputHandler: &outputHandler{
+				input:    input,
+				progress: make(chan error),
+			},
+			pacer:    pacer,
+			producer: producer,
+			topic:    topic,
+			options:  options,
+		},
+		transaction: transaction,
+	}
+
+	return handler, nil
+}
+
+type kafkaTransactionalOutputHandler struct {
+	*kafkaOutputHandler
+	transaction KafkaTransactionOptions
+}
+
+func (handler *kafkaTransactionalOutputHandler) Run() error {
+	defer handler.close()
+
+	// Notify the production errors, which make the transaction fail, and
+	// discard the successes, as messages are done once committed
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for err := range handler.producer.Errors() {
+			handler.progress <- err
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for range handler.producer.Successes() {
+			// Discard the successes
+		}
+	}()
+
+	// When done, close the producer and wait until its channels are drained
+	defer func() {
+		handler.producer.AsyncClose()
+		wg.Wait()
+	}()
+
+	// Every transaction starts with the first message read after the previous
+	// one was committed
+	for message := range handler.input {
+		if err := handler.produceTransaction(message); err != nil {
+			// Abort the open transaction, otherwise it is aborted by the
+			// broker once it times out
+			if handler.producer.TxnStatus()&(sarama.ProducerTxnFlagInTransaction|sarama.ProducerTxnFlagAbortableError) != 0 {
+				handler.producer.AbortTxn()
+			}
+			return fmt.Errorf("kafka: transaction failed: %w", err)
+		}
+	}
+	return nil
+}
+
+// produceTransaction produces in a transaction the given message and the
+// messages read until the transaction interval elapses, committing the offsets
+// of the consumed messages.
+func (handler *kafkaTransactionalOutputHandler) produceTransaction(first *dto.KafkaMessage) error {
+	if err := handler.producer.BeginTxn(); err != nil {
+		return err
+	}
+
+	timer := time.NewTimer(handler.transaction.Interval)
+	defer timer.Stop()
+
+	// Produce the messages, keeping the next offset to consume of every
+	// partition
+	var messages []*dto.KafkaMessage
+	offsets := make(map[int32]int64)
+	produce := func(message *dto.KafkaMessage) {
+		<-handler.pacer
+		handler.producer.Input() <- handler.newProducerMessage(message)
+		messages = append(messages, message)
+		offsets[message.Partition] = message.Offset + 1
+	}
+
+	produce(first)
+	for interval := true; interval; {
+		select {
+		case message, ok := <-handler.input:
+			if !ok {
+				interval = false
+				continue
+			}
+			produce(message)
+		case <-timer.C:
+			interval = false
+		}
+	}
+
+	// Commit the offsets with the produced messages
+	partitionOffsets := make([]*sarama.PartitionOffsetMetadata, 0, len(offsets))
+	for partition, offset := range offsets {
+		partitionOffsets = append(partitionOffsets, &sarama.PartitionOffsetMetadata{
+			Partition: partition,
+			Offset:    offset,
+		})
+	}
+	err := handler.producer.AddOffsetsToTxn(map[string][]*sarama.PartitionOffsetMetadata{
+		handler.transaction.Topic: partitionOffsets,
+	}, handler.transaction.Group)
+	if err != nil {
+		return err
+	}
+	if err := handler.producer.CommitTxn(); err != nil {
+		return err
+	}
+
+	// Notify the message sources the messages were produced
+	for _, message := range messages {
+		message.Done()
+		handler.progress <- nil
+	}
+	return nil
+}
@@ -0,0 +1,202 @@
+package handlers_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/bluekiri/kafka-client/internal/dto"
+	"github.com/bluekiri/kafka-client/internal/handlers"
+
+	"github.com/IBM/sarama"
+)
+
+func TestKafkaTransactionalOutputHandlerInvalidOptions(t *testing.T) {
+	valid := handlers.KafkaTransactionOptions{
+		Group:    "test-group",
+		Topic:    testTopic,
+		Interval: 100 * time.Millisecond,
+	}
+	testCases := map[string]handlers.KafkaTransactionOptions{
+		// The test client has no transactional ID
+		"non transactional client": valid,
+		"missing group":            {Topic: valid.Topic, Interval: valid.Interval},
+		"missing topic":            {Group: valid.Group, Interval: valid.Interval},
+		"missing interval":         {Group: valid.Group, Topic: valid.Topic},
+	}
+
+	for name, transaction := range testCases {
+		t.Run(name, func(t *testing.T) {
+			input := make(chan *dto.KafkaMessage)
+			_, err := handlers.NewKafkaTransactionalOutputHandler(input, nil, newTestClient(t), testTopic, handlers.KafkaOutputOptions{}, transaction)
+			if err == nil {
+				t.Fatal("NewKafkaTransactionalOutputHandler should have failed")
+			}
+		})
+	}
+}
+
+// newTransactionalTestClient returns a transactional client connected to a
+// mock broker coordinating the transactions and the consumer group, which
+// fails to add the offsets to the transactions with addOffsetsErr.
+func newTransactionalTestClient(t *testing.T, addOffsetsErr sarama.KError) (sarama.Client, *sarama.MockBroker) {
+	broker := sarama.NewMockBroker(t, 1)
+	t.Cleanup(broker.Close)
+
+	metadataResponse := sarama.NewMockMetadataResponse(t).
+		SetController(broker.BrokerID()).
+		SetBroker(broker.Addr(), broker.BrokerID())
+	for partition := int32(0); partition < 2; partition++ {
+		metadataResponse.SetLeader(testTopic, partition, broker.BrokerID())
+	}
+
+	broker.SetHandlerByMap(map[string]sarama.MockResponse{
+		"MetadataRequest": metadataResponse,
+		"FindCoordinatorRequest": sarama.NewMockFindCoordinatorResponse(t).
+			SetCoordinator(sarama.CoordinatorTransaction, "test-transaction", broker).
+			SetCoordinator(sarama.CoordinatorGroup, "test-group", broker),
+		"InitProducerIDRequest": sarama.NewMockInitProducerIDResponse(t).SetProducerID(1),
+		"AddPartitionsToTxnRequest": sarama.NewMockWrapper(&sarama.AddPartitionsToTxnResponse{Version: 1, Errors: map[string][]*sarama.PartitionError{
+			testTopic: {{Partition: 0}, {Partition: 1}},
+		}}),
+		"ProduceRequest":         sarama.NewMockProduceResponse(t),
+		"AddOffsetsToTxnRequest": sarama.NewMockWrapper(&sarama.AddOffsetsToTxnResponse{Version: 1, Err: addOffsetsErr}),
+		"TxnOffsetCommitRequest": sarama.NewMockWrapper(&sarama.TxnOffsetCommitResponse{Version: 1, Topics: map[string][]*sarama.PartitionError{}}),
+		"EndTxnRequest":          sarama.NewMockWrapper(&sarama.EndTxnResponse{Version: 1}),
+	})
+
+	config := sarama.NewConfig()
+	config.Version = sarama.V2_0_0_0
+	config.Producer.Idempotent = true
+	config.Producer.RequiredAcks = sarama.WaitForAll
+	config.Producer.Return.Successes = true
+	config.Producer.Transaction.ID = "test-transaction"
+	config.Producer.Transaction.Retry.Backoff = time.Millisecond
+	config.Net.MaxOpenRequests = 1
+	client, err := sarama.NewClient([]string{broker.Addr()}, config)
+	if err != nil {
+		t.Fatalf("sarama.NewClient failed: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client, broker
+}
+
+// runTransactions runs a transactional output handler producing the given
+// messages and returns the number of notified successes and the error
+// returned by the handler.
+func runTransactions(t *testing.T, client sarama.Client, messages []*dto.KafkaMessage) (int, error) {
+	input := make(chan *dto.KafkaMessage, len(messages))
+	for _, message := range messages {
+		input <- message
+	}
+	close(input)
+
+	// The pacer never delays the messages
+	pacer := make(chan time.Time)
+	close(pacer)
+
+	handler, err := handlers.NewKafkaTransactionalOutputHandler(input, pacer, client, testTopic, handlers.KafkaOutputOptions{}, handlers.KafkaTransactionOptions{
+		Group:    "test-group",
+		Topic:    testTopic,
+		Interval: time.Second,
+	})
+	if err != nil {
+		t.Fatalf("NewKafkaTransactionalOutputHandler failed: %v", err)
+	}
+
+	successes := make(chan int)
+	go func() {
+		count := 0
+		for err := range handler.Progress() {
+			if err == nil {
+				count++
+			}
+		}
+		successes <- count
+	}()
+
+	err = handler.Run()
+	return <-successes, err
+}
+
+// endTxnRequests returns the results, true if committed, of the transactions
+// ended by the broker.
+func endTxnRequests(broker *sarama.MockBroker) []bool {
+	var results []bool
+	for _, exchange := range broker.History() {
+		if request, ok := exchange.Request.(*sarama.EndTxnRequest); ok {
+			results = append(results, request.TransactionResult)
+		}
+	}
+	return results
+}
+
+func TestKafkaTransactionalOutputHandler(t *testing.T) {
+	client, broker := newTransactionalTestClient(t, sarama.ErrNoError)
+
+	// The messages are done once their transaction is committed
+	var messages []*dto.KafkaMessage
+	var done int
+	for offset := int64(0); offset < 3; offset++ {
+		messages = append(messages, &dto.KafkaMessage{
+			Value:     []byte(fmt.Sprintf("message %d", offset)),
+			Partition: int32(offset % 2),
+			Offset:    offset,
+			OnDone: func() {
+				if results := endTxnRequests(broker); len(results) != 1 || !results[0] {
+					t.Errorf("message %d done before its transaction was committed", offset)
+				}
+				done++
+			},
+		})
+	}
+
+	successes, err := runTransactions(t, client, messages)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if successes != 3 || done != 3 {
+		t.Errorf("expected 3 successes and messages done but got %d and %d", successes, done)
+	}
+
+	// The offsets committed are the next ones to consume of every partition
+	var committed map[int32]int64
+	for _, exchange := range broker.History() {
+		switch request := exchange.Request.(type) {
+		case *sarama.AddOffsetsToTxnRequest:
+			if request.GroupID != "test-group" {
+				t.Errorf("expected the offsets of test-group but got %s", request.GroupID)
+			}
+		case *sarama.TxnOffsetCommitRequest:
+			committed = make(map[int32]int64)
+			for _, offset := range request.Topics[testTopic] {
+				committed[offset.Partition] = offset.Offset
+			}
+		}
+	}
+	if committed[0] != 3 || committed[1] != 2 {
+		t.Errorf("expected the offsets 3 and 2 to be committed but got %v", committed)
+	}
+}
+
+func TestKafkaTransactionalOutputHandlerAbort(t *testing.T) {
+	client, broker := newTransactionalTestClient(t, sarama.ErrGroupAuthorizationFailed)
+
+	messages := []*dto.KafkaMessage{{
+		Value: []byte("message"),
+		OnDone: func() {
+			t.Error("message done in an aborted transaction")
+		},
+	}}
+
+	successes, err := runTransactions(t, client, messages)
+	if err == nil {
+		t.Fatal("Run should have failed")
+	}
+	if successes != 0 {
+		t.Errorf("expected no successes but got %d", successes)
+	}
+	if results := endTxnRequests(broker); len(results) != 1 || results[0] {
+		t.Errorf("expected the transaction to be aborted but got the results %v", results)
+	}
+}
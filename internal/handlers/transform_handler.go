@@ -0,0 +1,73 @@
+/*
+Copyright © 2023 VECI Group Tech S.L.
+This file is part of kafka-client.
+*/
+
+package handlers
+
+import (
+	"context"
+
+	"github.com/bluekiri/kafka-client/internal/dto"
+	"github.com/bluekiri/kafka-client/internal/transforms"
+)
+
+// NewTransformHandler returns an InputHandler forwarding the messages of the
+// input channel once rewritten by the transform. Messages are forwarded as
+// they are if the transform is nil.
+//
+// The handler stops when the transformation of a message fails, without
+// forwarding the message, as it could hold data the transform should have
+// removed, nor marking it as done, so it isn't committed.
+func NewTransformHandler(input <-chan *dto.KafkaMessage, transform transforms.Transform) (InputHandler, error) {
+	handler := &transformHandler{
+		inputHandler: &inputHandler{
+			messages: make(chan *dto.KafkaMessage),
+			progress: make(chan error),
+		},
+		input:     input,
+		transform: transform,
+	}
+
+	return handler, nil
+}
+
+type transformHandler struct {
+	*inputHandler
+	input     <-chan *dto.KafkaMessage
+	transform transforms.Transform
+}
+
+func (handler *transformHandler) Start(ctx context.Context) func() error {
+	handler.ctx = ctx
+	return handler.run
+}
+
+func (handler *transformHandler) run() error {
+	defer handler.close()
+
+	for {
+		var message *dto.KafkaMessage
+		var ok bool
+		select {
+		case message, ok = <-handler.input:
+			if !ok {
+				return handler.ctx.Err()
+			}
+		case <-handler.ctx.Done():
+			return handler.ctx.Err()
+		}
+
+		if handler.transform != nil {
+			if err := handler.transform.Apply(message); err != nil {
+				return messageError(message, err)
+			}
+		}
+
+		select {
+		case handler.messages <- message:
+		case <-handler.ctx.Done():
+			return handler.ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package handlers_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/bluekiri/kafka-client/internal/dto"
+	"github.com/bluekiri/kafka-client/internal/handlers"
+)
+
+// suffixTransform appends a suffix to the values and fails for negative
+// offsets.
+type suffixTransform string
+
+func (suffix suffixTransform) Apply(message *dto.KafkaMessage) error {
+	if message.Offset < 0 {
+		return errors.New("negative offset")
+	}
+	message.Value = append(message.Value, suffix...)
+	return nil
+}
+
+func TestTransformHandler(t *testing.T) {
+	input, done := sendMessages(0, -1, 2)
+	handler, err := handlers.NewTransformHandler(input, suffixTransform("!"))
+	if err != nil {
+		t.Fatalf("NewTransformHandler failed: %v", err)
+	}
+
+	// The handler stops at the failed message, which must be neither
+	// forwarded nor done
+	forwarded, _, err := runStage(t, handler)
+	if err == nil {
+		t.Fatal("the handler should have failed")
+	}
+	if !strings.Contains(err.Error(), "partition 0 offset -1") {
+		t.Errorf("expected the error to locate the failed message but got %v", err)
+	}
+	if len(forwarded) != 1 || string(forwarded[0].Value) != "0!" {
+		t.Errorf("unexpected forwarded messages %v", forwarded)
+	}
+	if done() != 0 {
+		t.Errorf("expected no done messages but got %d", done())
+	}
+}
+
+func TestTransformHandlerWithoutTransform(t *testing.T) {
+	input, _ := sendMessages(0, 1)
+	handler, err := handlers.NewTransformHandler(input, nil)
+	if err != nil {
+		t.Fatalf("NewTransformHandler failed: %v", err)
+	}
+
+	forwarded, errors, err := runStage(t, handler)
+	if err != nil {
+		t.Fatalf("the handler returned the error %v", err)
+	}
+	if len(forwarded) != 2 || string(forwarded[1].Value) != "1" || errors != 0 {
+		t.Errorf("unexpected forwarded messages %v and %d errors", forwarded, errors)
+	}
+}
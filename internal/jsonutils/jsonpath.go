@@ -0,0 +1,186 @@
+/*
+Copyright © 2023 VECI Group Tech S.L.
+This file is part of kafka-client.
+*/
+
+package jsonutils
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// JSONPath selects nodes of decoded JSON values. It supports the following
+// subset of the JSONPath syntax:
+//
+//   - $: the root value, which every path starts with.
+//   - .name or ['name']: the field of an object with the given name.
+//   - [n]: the element of an array with the given index, counted from the
+//     end if negative.
+//   - .* or [*]: every field of an object or every element of an array.
+//   - ..name, ..* or ..[selector]: the recursive descent, applying the
+//     selector to the value and to all its descendants.
+type JSONPath struct {
+	expression string
+	steps      []pathStep
+}
+
+type pathStep struct {
+	recursive bool
+	wildcard  bool
+	name      *string
+	index     *int
+}
+
+// ParseJSONPath parses the JSONPath expression (e.g. $.order.lines[*].sku).
+func ParseJSONPath(expression string) (*JSONPath, error) {
+	invalid := func(reason string) (*JSONPath, error) {
+		return nil, fmt.Errorf("invalid JSONPath '%s': %s", expression, reason)
+	}
+
+	rest, found := strings.CutPrefix(strings.TrimSpace(expression), "$")
+	if !found {
+		return invalid("it must start with $")
+	}
+
+	path := &JSONPath{expression: expression}
+	for rest != "" {
+		var step pathStep
+		if after, found := strings.CutPrefix(rest, ".."); found {
+			step.recursive = true
+			rest = after
+		} else if after, found := strings.CutPrefix(rest, "."); found {
+			rest = after
+		} else if !strings.HasPrefix(rest, "[") {
+			return invalid(fmt.Sprintf("unexpected '%s'", rest))
+		}
+
+		switch {
+		case strings.HasPrefix(rest, "["):
+			selector, after, found := strings.Cut(rest[1:], "]")
+			if !found {
+				return invalid("missing ]")
+			}
+			if err := step.parseSelector(strings.TrimSpace(selector)); err != nil {
+				return invalid(err.Error())
+			}
+			rest = after
+		case strings.HasPrefix(rest, "*"):
+			step.wildcard = true
+			rest = rest[1:]
+		default:
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			if end == 0 {
+				return invalid("missing field name")
+			}
+			name := rest[:end]
+			step.name = &name
+			rest = rest[end:]
+		}
+		path.steps = append(path.steps, step)
+	}
+
+	return path, nil
+}
+
+// parseSelector parses the selector between brackets.
+func (step *pathStep) parseSelector(selector string) error {
+	if selector == "*" {
+		step.wildcard = true
+		return nil
+	}
+	if len(selector) >= 2 && (selector[0] == '\'' || selector[0] == '"') && selector[len(selector)-1] == selector[0] {
+		name := selector[1 : len(selector)-1]
+		step.name = &name
+		return nil
+	}
+	index, err := strconv.Atoi(selector)
+	if err != nil {
+		return fmt.Errorf("invalid selector [%s], expected *, an index or a quoted field name", selector)
+	}
+	step.index = &index
+	return nil
+}
+
+// Select returns the nodes of the decoded JSON value selected by the path.
+func (path *JSONPath) Select(value any) []any {
+	nodes := []any{value}
+	for _, step := range path.steps {
+		var selected []any
+		for _, node := range nodes {
+			if step.recursive {
+				walk(node, func(descendant any) {
+					selected = step.apply(descendant, selected)
+				})
+			} else {
+				selected = step.apply(node, selected)
+			}
+		}
+		nodes = selected
+	}
+	return nodes
+}
+
+func (path *JSONPath) String() string {
+	return path.expression
+}
+
+// apply appends the children of the node selected by the step.
+func (step *pathStep) apply(node any, selected []any) []any {
+	switch node := node.(type) {
+	case map[string]any:
+		if step.wildcard {
+			// Sort the fields so the selection is deterministic
+			names := make([]string, 0, len(node))
+			for name := range node {
+				names = append(names, name)
+			}
+			slices.Sort(names)
+			for _, name := range names {
+				selected = append(selected, node[name])
+			}
+		} else if step.name != nil {
+			if child, found := node[*step.name]; found {
+				selected = append(selected, child)
+			}
+		}
+	case []any:
+		if step.wildcard {
+			selected = append(selected, node...)
+		} else if step.index != nil {
+			index := *step.index
+			if index < 0 {
+				index += len(node)
+			}
+			if index >= 0 && index < len(node) {
+				selected = append(selected, node[index])
+			}
+		}
+	}
+	return selected
+}
+
+// walk calls visit with the node and all its descendants.
+func walk(node any, visit func(any)) {
+	visit(node)
+	switch node := node.(type) {
+	case map[string]any:
+		names := make([]string, 0, len(node))
+		for name := range node {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		for _, name := range names {
+			walk(node[name], visit)
+		}
+	case []any:
+		for _, element := range node {
+			walk(element, visit)
+		}
+	}
+}
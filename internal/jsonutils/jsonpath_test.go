@@ -0,0 +1,60 @@
+package jsonutils_test
+
+import (
+	"testing"
+
+	"github.com/bluekiri/kafka-client/internal/jsonutils"
+)
+
+func TestJSONPathSelect(t *testing.T) {
+	value, err := jsonutils.Decode([]byte(`{
+		"order": {"id": 1234, "lines": [{"sku": "A", "qty": 1}, {"sku": "B", "qty": 2}]},
+		"customer": {"name": "Ann", "address": {"city": "Palma"}},
+		"odd key": true
+	}`))
+	if err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+
+	testCases := map[string]string{
+		"$":                      `[{"customer":{"address":{"city":"Palma"},"name":"Ann"},"odd key":true,"order":{"id":1234,"lines":[{"qty":1,"sku":"A"},{"qty":2,"sku":"B"}]}}]`,
+		"$.order.id":             `[1234]`,
+		"$['order']['id']":       `[1234]`,
+		`$["odd key"]`:           `[true]`,
+		"$.order.lines[1].sku":   `["B"]`,
+		"$.order.lines[-1].sku":  `["B"]`,
+		"$.order.lines[2].sku":   `null`,
+		"$.order.lines[*].sku":   `["A","B"]`,
+		"$.order.lines.*.qty":    `[1,2]`,
+		"$.customer.*":           `[{"city":"Palma"},"Ann"]`,
+		"$..city":                `["Palma"]`,
+		"$..lines[0].sku":        `["A"]`,
+		"$..sku":                 `["A","B"]`,
+		"$.missing.id":           `null`,
+		"$.order.id.not_a_field": `null`,
+	}
+
+	for expression, expected := range testCases {
+		t.Run(expression, func(t *testing.T) {
+			path, err := jsonutils.ParseJSONPath(expression)
+			if err != nil {
+				t.Fatalf("ParseJSONPath failed: %v", err)
+			}
+			text, err := jsonutils.Encode(path.Select(value))
+			if err != nil {
+				t.Fatalf("Encode failed: %v", err)
+			}
+			if string(text) != expected {
+				t.Errorf("Expected '%s' but got '%s'", expected, text)
+			}
+		})
+	}
+}
+
+func TestParseJSONPathErrors(t *testing.T) {
+	for _, expression := range []string{"", "order.id", "$order", "$.", "$..", "$.a[1", "$.a[x]", "$.a[]"} {
+		if _, err := jsonutils.ParseJSONPath(expression); err == nil {
+			t.Errorf("ParseJSONPath should have failed for '%s'", expression)
+		}
+	}
+}
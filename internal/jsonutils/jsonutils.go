@@ -0,0 +1,75 @@
+/*
+Copyright © 2023 VECI Group Tech S.L.
+This file is part of kafka-client.
+*/
+
+package jsonutils
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Decode decodes the JSON text keeping numbers as json.Number, so they don't
+// lose precision when encoded again.
+func Decode(text []byte) (any, error) {
+	decoder := json.NewDecoder(bytes.NewReader(text))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	if _, err := decoder.Token(); !errors.Is(err, io.EOF) {
+		return nil, errors.New("unexpected data after the JSON value")
+	}
+	return value, nil
+}
+
+// Encode encodes the value as JSON text without escaping HTML characters.
+// Object keys are sorted.
+func Encode(value any) ([]byte, error) {
+	var buffer bytes.Buffer
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(value); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buffer.Bytes(), []byte("\n")), nil
+}
+
+// ParsePath splits a dot separated field path (e.g. customer.email).
+func ParsePath(field string) ([]string, error) {
+	path := strings.Split(field, ".")
+	for _, name := range path {
+		if name == "" {
+			return nil, fmt.Errorf("invalid field path '%s'", field)
+		}
+	}
+	return path, nil
+}
+
+// VisitField calls visit with every object of the decoded JSON value holding
+// the field at the path. Arrays found along the path are visited element by
+// element.
+func VisitField(value any, path []string, visit func(object map[string]any, name string)) {
+	switch value := value.(type) {
+	case []any:
+		for _, element := range value {
+			VisitField(element, path, visit)
+		}
+	case map[string]any:
+		if len(path) == 1 {
+			if _, found := value[path[0]]; found {
+				visit(value, path[0])
+			}
+			return
+		}
+		if child, found := value[path[0]]; found {
+			VisitField(child, path[1:], visit)
+		}
+	}
+}
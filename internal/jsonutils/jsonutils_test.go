@@ -0,0 +1,60 @@
+package jsonutils_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/bluekiri/kafka-client/internal/jsonutils"
+)
+
+func TestDecodeEncode(t *testing.T) {
+	// Numbers keep their precision, keys are sorted and HTML is not escaped
+	value, err := jsonutils.Decode([]byte(` {"b":12345678901234567890,"a":"<&>"} `))
+	if err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	text, err := jsonutils.Encode(value)
+	if err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+	if expected := `{"a":"<&>","b":12345678901234567890}`; string(text) != expected {
+		t.Errorf("Expected '%s' but got '%s'", expected, text)
+	}
+
+	for _, text := range []string{"", "{", `{"a":1} {"b":2}`, "12 is a number"} {
+		if _, err := jsonutils.Decode([]byte(text)); err == nil {
+			t.Errorf("Decode should have failed for '%s'", text)
+		}
+	}
+}
+
+func TestParsePath(t *testing.T) {
+	path, err := jsonutils.ParsePath("customer.address.zip")
+	if err != nil {
+		t.Fatalf("ParsePath failed: %v", err)
+	}
+	if expected := []string{"customer", "address", "zip"}; !slices.Equal(path, expected) {
+		t.Errorf("Expected %v but got %v", expected, path)
+	}
+
+	for _, field := range []string{"", ".a", "a.", "a..b"} {
+		if _, err := jsonutils.ParsePath(field); err == nil {
+			t.Errorf("ParsePath should have failed for '%s'", field)
+		}
+	}
+}
+
+func TestVisitField(t *testing.T) {
+	value, err := jsonutils.Decode([]byte(`{"items":[{"sku":"A"},{"sku":"B"},{"other":1},[{"sku":"C"}]],"sku":"D"}`))
+	if err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+
+	var visited []string
+	jsonutils.VisitField(value, []string{"items", "sku"}, func(object map[string]any, name string) {
+		visited = append(visited, object[name].(string))
+	})
+	if expected := []string{"A", "B", "C"}; !slices.Equal(visited, expected) {
+		t.Errorf("Expected %v but got %v", expected, visited)
+	}
+}
@@ -0,0 +1,36 @@
+/*
+Copyright © 2023 VECI Group Tech S.L.
+This file is part of kafka-client.
+*/
+
+package kafkautils
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/IBM/sarama"
+)
+
+// BalanceStrategies lists the names of the consumer group balance strategies
+// accepted by ParseBalanceStrategy.
+var BalanceStrategies = []string{"range", "roundrobin", "sticky"}
+
+// ParseBalanceStrategy returns the consumer group balance strategy with the
+// given name:
+//
+//   - range: consecutive partitions to every member.
+//   - roundrobin: every partition to the next member in turn.
+//   - sticky: balanced partitions, preserving the previous assignments.
+func ParseBalanceStrategy(name string) (sarama.BalanceStrategy, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "range":
+		return sarama.NewBalanceStrategyRange(), nil
+	case "roundrobin":
+		return sarama.NewBalanceStrategyRoundRobin(), nil
+	case "sticky":
+		return sarama.NewBalanceStrategySticky(), nil
+	default:
+		return nil, fmt.Errorf("invalid balance strategy '%s', expected %s", name, strings.Join(BalanceStrategies, ", "))
+	}
+}
@@ -0,0 +1,33 @@
+package kafkautils_test
+
+import (
+	"testing"
+
+	"github.com/bluekiri/kafka-client/internal/kafkautils"
+
+	"github.com/IBM/sarama"
+)
+
+func TestParseBalanceStrategy(t *testing.T) {
+	testCases := map[string]string{
+		"range":      sarama.RangeBalanceStrategyName,
+		"RoundRobin": sarama.RoundRobinBalanceStrategyName,
+		" sticky ":   sarama.StickyBalanceStrategyName,
+	}
+
+	for name, expected := range testCases {
+		t.Run(name, func(t *testing.T) {
+			strategy, err := kafkautils.ParseBalanceStrategy(name)
+			if err != nil {
+				t.Fatalf("ParseBalanceStrategy failed: %v", err)
+			}
+			if strategy.Name() != expected {
+				t.Errorf("expected %s but got %s", expected, strategy.Name())
+			}
+		})
+	}
+
+	if _, err := kafkautils.ParseBalanceStrategy("cooperative-sticky"); err == nil {
+		t.Error("ParseBalanceStrategy should have failed")
+	}
+}
@@ -0,0 +1,125 @@
+/*
+Copyright © 2023 VECI Group Tech S.L.
+This file is part of kafka-client.
+*/
+
+package kafkautils
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"strings"
+
+	"github.com/IBM/sarama"
+)
+
+// Partitioners lists the names of the partitioners accepted by
+// ParsePartitioner.
+var Partitioners = []string{"murmur2", "fnv", "random", "roundrobin", "manual"}
+
+// ParsePartitioner returns the constructor of the partitioner with the given
+// name:
+//
+//   - murmur2: the murmur2 hash of the key, like the default partitioner of
+//     the Java producer.
+//   - fnv: the FNV-1a hash of the key, the default partitioner of sarama.
+//   - random: a random partition.
+//   - roundrobin: every partition in turn.
+//   - manual: the partition set in the messages.
+//
+// The hash partitioners choose a random partition for messages without key.
+func ParsePartitioner(name string) (sarama.PartitionerConstructor, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "murmur2":
+		return NewMurmur2Partitioner, nil
+	case "fnv":
+		return sarama.NewHashPartitioner, nil
+	case "random":
+		return sarama.NewRandomPartitioner, nil
+	case "roundrobin":
+		return sarama.NewRoundRobinPartitioner, nil
+	case "manual":
+		return sarama.NewManualPartitioner, nil
+	default:
+		return nil, fmt.Errorf("invalid partitioner '%s', expected %s", name, strings.Join(Partitioners, ", "))
+	}
+}
+
+// NewMurmur2Partitioner returns a Partitioner choosing the partition of the
+// messages like the default partitioner of the Java producer, that is the
+// positive murmur2 hash of the key modulo the number of partitions.
+func NewMurmur2Partitioner(topic string) sarama.Partitioner {
+	return sarama.NewCustomPartitioner(
+		sarama.WithCustomHashFunction(newMurmur2),
+		sarama.WithAbsFirst(),
+	)(topic)
+}
+
+// murmur2 is the 32 bit murmur2 hash used by the Java producer. As murmur2
+// is not a streaming hash, the written data is buffered until summed.
+type murmur2 struct {
+	data []byte
+}
+
+func newMurmur2() hash.Hash32 {
+	return &murmur2{}
+}
+
+func (h *murmur2) Write(data []byte) (int, error) {
+	h.data = append(h.data, data...)
+	return len(data), nil
+}
+
+func (h *murmur2) Sum(b []byte) []byte {
+	return binary.BigEndian.AppendUint32(b, h.Sum32())
+}
+
+func (h *murmur2) Reset() {
+	h.data = h.data[:0]
+}
+
+func (h *murmur2) Size() int {
+	return 4
+}
+
+func (h *murmur2) BlockSize() int {
+	return 4
+}
+
+func (h *murmur2) Sum32() uint32 {
+	const (
+		seed = 0x9747b28c
+		m    = 0x5bd1e995
+		r    = 24
+	)
+
+	data := h.data
+	hash := uint32(seed) ^ uint32(len(data))
+
+	for ; len(data) >= 4; data = data[4:] {
+		k := binary.LittleEndian.Uint32(data)
+		k *= m
+		k ^= k >> r
+		k *= m
+		hash *= m
+		hash ^= k
+	}
+
+	switch len(data) {
+	case 3:
+		hash ^= uint32(data[2]) << 16
+		fallthrough
+	case 2:
+		hash ^= uint32(data[1]) << 8
+		fallthrough
+	case 1:
+		hash ^= uint32(data[0])
+		hash *= m
+	}
+
+	hash ^= hash >> 13
+	hash *= m
+	hash ^= hash >> 15
+	return hash
+}
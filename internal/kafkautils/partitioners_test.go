@@ -0,0 +1,56 @@
+package kafkautils_test
+
+import (
+	"testing"
+
+	"github.com/bluekiri/kafka-client/internal/kafkautils"
+
+	"github.com/IBM/sarama"
+)
+
+func TestMurmur2Partitioner(t *testing.T) {
+	// The murmur2 hashes computed by the Java client (Utils.murmur2)
+	testCases := map[string]int32{
+		"21":                         -973932308,
+		"foobar":                     -790332482,
+		"a-little-bit-long-string":   -985981536,
+		"a-little-bit-longer-string": -1486304829,
+		"lkjh234lh9fiuh90y23oiuhsafujhadof229phr9h19h89h8": -58897971,
+		"abc": 479470107,
+	}
+
+	partitioner := kafkautils.NewMurmur2Partitioner("test")
+	for key, javaHash := range testCases {
+		t.Run(key, func(t *testing.T) {
+			for _, numPartitions := range []int32{1, 6, 12, 1<<31 - 1} {
+				// The Java producer partition (Utils.toPositive(hash) % numPartitions)
+				expected := (javaHash & 0x7fffffff) % numPartitions
+				partition, err := partitioner.Partition(&sarama.ProducerMessage{Key: sarama.StringEncoder(key)}, numPartitions)
+				if err != nil {
+					t.Fatalf("Partition failed: %v", err)
+				}
+				if partition != expected {
+					t.Errorf("expected partition %d of %d but got %d", expected, numPartitions, partition)
+				}
+			}
+		})
+	}
+}
+
+func TestParsePartitioner(t *testing.T) {
+	for _, name := range append(kafkautils.Partitioners, "RoundRobin") {
+		t.Run(name, func(t *testing.T) {
+			constructor, err := kafkautils.ParsePartitioner(name)
+			if err != nil {
+				t.Fatalf("ParsePartitioner failed: %v", err)
+			}
+			if constructor("test") == nil {
+				t.Error("the constructor returned no partitioner")
+			}
+		})
+	}
+
+	if _, err := kafkautils.ParsePartitioner("crc32"); err == nil {
+		t.Error("ParsePartitioner should have failed")
+	}
+}
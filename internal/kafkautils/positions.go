@@ -0,0 +1,202 @@
+/*
+Copyright © 2023 VECI Group Tech S.L.
+This file is part of kafka-client.
+*/
+
+package kafkautils
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+type positionKind int
+
+const (
+	positionLatest positionKind = iota
+	positionEarliest
+	positionOffset
+	positionTime
+)
+
+// Position indicates where the consumption of a partition starts. The zero
+// value of Position is the latest position, so only new messages are consumed.
+type Position struct {
+	kind      positionKind
+	offset    int64
+	timestamp time.Time
+}
+
+var (
+	// Earliest is the position of the oldest message available in a partition.
+	Earliest = Position{kind: positionEarliest}
+
+	// Latest is the position of the next message produced to a partition.
+	Latest = Position{kind: positionLatest}
+)
+
+// AtOffset returns the Position of the message with the given offset.
+func AtOffset(offset int64) Position {
+	return Position{kind: positionOffset, offset: offset}
+}
+
+// AtTime returns the Position of the first message whose timestamp is equal
+// or later than the given time.
+func AtTime(timestamp time.Time) Position {
+	return Position{kind: positionTime, timestamp: timestamp}
+}
+
+// ParsePosition parses a position given as "earliest", "latest" or an offset.
+func ParsePosition(s string) (Position, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "earliest", "oldest", "beginning":
+		return Earliest, nil
+	case "latest", "newest", "end":
+		return Latest, nil
+	}
+
+	offset, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+	if err != nil || offset < 0 {
+		return Position{}, fmt.Errorf("invalid position '%s', expected earliest, latest or an offset", s)
+	}
+	return AtOffset(offset), nil
+}
+
+// Resolve returns the offset from which the given partition must be consumed.
+// Time positions are resolved asking the partition leader for the offset of
+// the first message at or after the timestamp.
+func (position Position) Resolve(client sarama.Client, topic string, partition int32) (int64, error) {
+	switch position.kind {
+	case positionEarliest:
+		return sarama.OffsetOldest, nil
+	case positionOffset:
+		return position.offset, nil
+	case positionTime:
+		return client.GetOffset(topic, partition, position.timestamp.UnixMilli())
+	default:
+		return sarama.OffsetNewest, nil
+	}
+}
+
+func (position Position) String() string {
+	switch position.kind {
+	case positionEarliest:
+		return "earliest"
+	case positionOffset:
+		return strconv.FormatInt(position.offset, 10)
+	case positionTime:
+		return position.timestamp.Format(time.RFC3339)
+	default:
+		return "latest"
+	}
+}
+
+// Positions holds the position where the consumption of every partition
+// starts. Partitions without a specific position start at Default.
+type Positions struct {
+	Default     Position
+	ByPartition map[int32]Position
+}
+
+// For returns the start position of the given partition.
+func (positions Positions) For(partition int32) Position {
+	if position, ok := positions.ByPartition[partition]; ok {
+		return position
+	}
+	return positions.Default
+}
+
+// ParsePositions parses a comma separated list of positions. Every element of
+// the list is either a position, which becomes the default position, or a
+// partition and a position separated by a colon (e.g. "3:1200,7:earliest").
+func ParsePositions(s string) (Positions, error) {
+	positions := Positions{ByPartition: make(map[int32]Position)}
+	hasDefault := false
+
+	for _, element := range strings.Split(s, ",") {
+		partition, position, found := strings.Cut(element, ":")
+		if !found {
+			if hasDefault {
+				return Positions{}, fmt.Errorf("invalid positions '%s', only one default position is allowed", s)
+			}
+			parsed, err := ParsePosition(element)
+			if err != nil {
+				return Positions{}, err
+			}
+			positions.Default = parsed
+			hasDefault = true
+			continue
+		}
+
+		id, err := parsePartition(partition)
+		if err != nil {
+			return Positions{}, err
+		}
+		if _, ok := positions.ByPartition[id]; ok {
+			return Positions{}, fmt.Errorf("invalid positions '%s', partition %d is repeated", s, id)
+		}
+		parsed, err := ParsePosition(position)
+		if err != nil {
+			return Positions{}, err
+		}
+		positions.ByPartition[id] = parsed
+	}
+
+	return positions, nil
+}
+
+func (positions Positions) String() string {
+	elements := []string{positions.Default.String()}
+	for _, partition := range sortedKeys(positions.ByPartition) {
+		elements = append(elements, fmt.Sprintf("%d:%v", partition, positions.ByPartition[partition]))
+	}
+	return strings.Join(elements, ",")
+}
+
+// ParsePartitions parses a comma separated list of partitions and partition
+// ranges (e.g. "0,3,7-9"). The returned partitions are sorted and unique.
+func ParsePartitions(s string) ([]int32, error) {
+	selected := make(map[int32]struct{})
+	for _, element := range strings.Split(s, ",") {
+		from, to, isRange := strings.Cut(element, "-")
+		first, err := parsePartition(from)
+		if err != nil {
+			return nil, err
+		}
+		last := first
+		if isRange {
+			if last, err = parsePartition(to); err != nil {
+				return nil, err
+			}
+			if last < first {
+				return nil, fmt.Errorf("invalid partition range '%s'", element)
+			}
+		}
+		for partition := first; partition <= last; partition++ {
+			selected[partition] = struct{}{}
+		}
+	}
+	return sortedKeys(selected), nil
+}
+
+func parsePartition(s string) (int32, error) {
+	partition, err := strconv.ParseInt(strings.TrimSpace(s), 10, 32)
+	if err != nil || partition < 0 {
+		return 0, fmt.Errorf("invalid partition '%s'", s)
+	}
+	return int32(partition), nil
+}
+
+func sortedKeys[V any](m map[int32]V) []int32 {
+	keys := make([]int32, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
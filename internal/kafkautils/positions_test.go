@@ -0,0 +1,165 @@
+package kafkautils_test
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/bluekiri/kafka-client/internal/kafkautils"
+
+	"github.com/IBM/sarama"
+)
+
+func TestParsePosition(t *testing.T) {
+	testCases := map[string]string{
+		"earliest":  "earliest",
+		"beginning": "earliest",
+		"LATEST":    "latest",
+		"end":       "latest",
+		"0":         "0",
+		" 1200 ":    "1200",
+	}
+
+	for input, expected := range testCases {
+		t.Run(input, func(t *testing.T) {
+			position, err := kafkautils.ParsePosition(input)
+			if err != nil {
+				t.Fatalf("ParsePosition failed: %v", err)
+			}
+			if actual := position.String(); actual != expected {
+				t.Errorf("expected '%s' but got '%s'", expected, actual)
+			}
+		})
+	}
+}
+
+func TestParsePositionError(t *testing.T) {
+	for _, input := range []string{"", "first", "-1", "1.5"} {
+		t.Run(input, func(t *testing.T) {
+			if _, err := kafkautils.ParsePosition(input); err == nil {
+				t.Fatal("ParsePosition should have failed")
+			}
+		})
+	}
+}
+
+func TestPositionResolve(t *testing.T) {
+	const topic = "test"
+	timestamp := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+
+	broker := sarama.NewMockBroker(t, 1)
+	defer broker.Close()
+
+	broker.SetHandlerByMap(map[string]sarama.MockResponse{
+		"MetadataRequest": sarama.NewMockMetadataResponse(t).
+			SetBroker(broker.Addr(), broker.BrokerID()).
+			SetLeader(topic, 0, broker.BrokerID()),
+		"OffsetRequest": sarama.NewMockOffsetResponse(t).
+			SetOffset(topic, 0, timestamp.UnixMilli(), 42),
+	})
+
+	client, err := sarama.NewClient([]string{broker.Addr()}, sarama.NewConfig())
+	if err != nil {
+		t.Fatalf("sarama.NewClient failed: %v", err)
+	}
+	defer client.Close()
+
+	testCases := map[string]struct {
+		position kafkautils.Position
+		expected int64
+	}{
+		"zero":     {kafkautils.Position{}, sarama.OffsetNewest},
+		"earliest": {kafkautils.Earliest, sarama.OffsetOldest},
+		"latest":   {kafkautils.Latest, sarama.OffsetNewest},
+		"offset":   {kafkautils.AtOffset(1200), 1200},
+		"time":     {kafkautils.AtTime(timestamp), 42},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			actual, err := tc.position.Resolve(client, topic, 0)
+			if err != nil {
+				t.Fatalf("Resolve failed: %v", err)
+			}
+			if actual != tc.expected {
+				t.Errorf("expected offset %d but got %d", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestParsePositions(t *testing.T) {
+	testCases := map[string]string{
+		"earliest":            "earliest",
+		"3:1200":              "latest,3:1200",
+		"7:earliest,3:1200":   "latest,3:1200,7:earliest",
+		"earliest,3:1200,0:5": "earliest,0:5,3:1200",
+	}
+
+	for input, expected := range testCases {
+		t.Run(input, func(t *testing.T) {
+			positions, err := kafkautils.ParsePositions(input)
+			if err != nil {
+				t.Fatalf("ParsePositions failed: %v", err)
+			}
+			if actual := positions.String(); actual != expected {
+				t.Errorf("expected '%s' but got '%s'", expected, actual)
+			}
+		})
+	}
+}
+
+func TestParsePositionsError(t *testing.T) {
+	for _, input := range []string{"", "earliest,latest", "3:1,3:2", "a:1", "-1:1", "1:b"} {
+		t.Run(input, func(t *testing.T) {
+			if _, err := kafkautils.ParsePositions(input); err == nil {
+				t.Fatal("ParsePositions should have failed")
+			}
+		})
+	}
+}
+
+func TestPositionsFor(t *testing.T) {
+	positions := kafkautils.Positions{
+		Default:     kafkautils.Earliest,
+		ByPartition: map[int32]kafkautils.Position{3: kafkautils.AtOffset(1200)},
+	}
+
+	if actual := positions.For(3).String(); actual != "1200" {
+		t.Errorf("expected partition 3 to start at 1200 but got %s", actual)
+	}
+	if actual := positions.For(1).String(); actual != "earliest" {
+		t.Errorf("expected partition 1 to start at earliest but got %s", actual)
+	}
+}
+
+func TestParsePartitions(t *testing.T) {
+	testCases := map[string][]int32{
+		"0":          {0},
+		"0,3,7-9":    {0, 3, 7, 8, 9},
+		"9,7-8,8,0 ": {0, 7, 8, 9},
+		"2-2":        {2},
+	}
+
+	for input, expected := range testCases {
+		t.Run(input, func(t *testing.T) {
+			actual, err := kafkautils.ParsePartitions(input)
+			if err != nil {
+				t.Fatalf("ParsePartitions failed: %v", err)
+			}
+			if !slices.Equal(actual, expected) {
+				t.Errorf("expected %v but got %v", expected, actual)
+			}
+		})
+	}
+}
+
+func TestParsePartitionsError(t *testing.T) {
+	for _, input := range []string{"", "a", "1,", "3-1", "1-", "-1"} {
+		t.Run(input, func(t *testing.T) {
+			if _, err := kafkautils.ParsePartitions(input); err == nil {
+				t.Fatal("ParsePartitions should have failed")
+			}
+		})
+	}
+}
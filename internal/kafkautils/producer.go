@@ -0,0 +1,45 @@
+/*
+Copyright © 2023 VECI Group Tech S.L.
+This file is part of kafka-client.
+*/
+
+package kafkautils
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/IBM/sarama"
+)
+
+// Compressions lists the names of the compression codecs accepted by
+// ParseCompression.
+var Compressions = []string{"none", "gzip", "snappy", "lz4", "zstd"}
+
+// ParseCompression returns the compression codec with the given name.
+func ParseCompression(name string) (sarama.CompressionCodec, error) {
+	var codec sarama.CompressionCodec
+	if err := codec.UnmarshalText([]byte(strings.ToLower(strings.TrimSpace(name)))); err != nil {
+		return sarama.CompressionNone, fmt.Errorf("invalid compression '%s', expected %s", name, strings.Join(Compressions, ", "))
+	}
+	return codec, nil
+}
+
+// Acks lists the acknowledgement levels accepted by ParseAcks.
+var Acks = []string{"all", "1", "0"}
+
+// ParseAcks returns the acknowledgement level given by its name, like the
+// acks setting of the Java producer: all (or -1) waits for all the in-sync
+// replicas, 1 waits for the leader only and 0 doesn't wait at all.
+func ParseAcks(name string) (sarama.RequiredAcks, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "all", "-1":
+		return sarama.WaitForAll, nil
+	case "1":
+		return sarama.WaitForLocal, nil
+	case "0":
+		return sarama.NoResponse, nil
+	default:
+		return sarama.WaitForLocal, fmt.Errorf("invalid acks '%s', expected %s", name, strings.Join(Acks, ", "))
+	}
+}
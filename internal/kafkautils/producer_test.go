@@ -0,0 +1,60 @@
+package kafkautils_test
+
+import (
+	"testing"
+
+	"github.com/bluekiri/kafka-client/internal/kafkautils"
+
+	"github.com/IBM/sarama"
+)
+
+func TestParseCompression(t *testing.T) {
+	testCases := map[string]sarama.CompressionCodec{
+		"none":   sarama.CompressionNone,
+		"gzip":   sarama.CompressionGZIP,
+		"snappy": sarama.CompressionSnappy,
+		"LZ4":    sarama.CompressionLZ4,
+		" zstd ": sarama.CompressionZSTD,
+	}
+
+	for name, expected := range testCases {
+		t.Run(name, func(t *testing.T) {
+			codec, err := kafkautils.ParseCompression(name)
+			if err != nil {
+				t.Fatalf("ParseCompression failed: %v", err)
+			}
+			if codec != expected {
+				t.Errorf("expected %v but got %v", expected, codec)
+			}
+		})
+	}
+
+	if _, err := kafkautils.ParseCompression("brotli"); err == nil {
+		t.Error("ParseCompression should have failed")
+	}
+}
+
+func TestParseAcks(t *testing.T) {
+	testCases := map[string]sarama.RequiredAcks{
+		"all": sarama.WaitForAll,
+		"-1":  sarama.WaitForAll,
+		"1":   sarama.WaitForLocal,
+		"0":   sarama.NoResponse,
+	}
+
+	for name, expected := range testCases {
+		t.Run(name, func(t *testing.T) {
+			acks, err := kafkautils.ParseAcks(name)
+			if err != nil {
+				t.Fatalf("ParseAcks failed: %v", err)
+			}
+			if acks != expected {
+				t.Errorf("expected %v but got %v", expected, acks)
+			}
+		})
+	}
+
+	if _, err := kafkautils.ParseAcks("2"); err == nil {
+		t.Error("ParseAcks should have failed")
+	}
+}
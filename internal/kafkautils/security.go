@@ -0,0 +1,187 @@
+/*
+Copyright © 2023 VECI Group Tech S.L.
+This file is part of kafka-client.
+*/
+
+package kafkautils
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/IBM/sarama"
+	"github.com/xdg-go/scram"
+)
+
+// TLSOptions are the options of the TLS connections to the brokers.
+type TLSOptions struct {
+	// CAFile is the PEM file of the certificate authorities verifying the
+	// brokers. The system certificate authorities are used if empty.
+	CAFile string
+
+	// CertFile and KeyFile are the PEM files of the client certificate and
+	// its private key, given to the brokers requiring client authentication.
+	CertFile string
+	KeyFile  string
+
+	// InsecureSkipVerify disables the verification of the certificates of
+	// the brokers.
+	InsecureSkipVerify bool
+}
+
+// NewTLSConfig returns the TLS configuration given by the options.
+func NewTLSConfig(options TLSOptions) (*tls.Config, error) {
+	config := &tls.Config{
+		InsecureSkipVerify: options.InsecureSkipVerify,
+	}
+
+	if options.CAFile != "" {
+		pem, err := os.ReadFile(options.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		config.RootCAs = x509.NewCertPool()
+		if !config.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", options.CAFile)
+		}
+	}
+
+	if options.CertFile != "" || options.KeyFile != "" {
+		if options.CertFile == "" || options.KeyFile == "" {
+			return nil, errors.New("the client certificate requires both the certificate and the key files")
+		}
+		certificate, err := tls.LoadX509KeyPair(options.CertFile, options.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		config.Certificates = []tls.Certificate{certificate}
+	}
+
+	return config, nil
+}
+
+// SASLMechanisms lists the SASL mechanisms accepted by ConfigureSASL.
+var SASLMechanisms = []string{
+	sarama.SASLTypePlaintext,
+	sarama.SASLTypeSCRAMSHA256,
+	sarama.SASLTypeSCRAMSHA512,
+	sarama.SASLTypeOAuth,
+}
+
+// SASLOptions are the options of the SASL authentication.
+type SASLOptions struct {
+	// Mechanism is one of SASLMechanisms.
+	Mechanism string
+
+	// Username and Password are the credentials of the PLAIN and SCRAM
+	// mechanisms.
+	Username string
+	Password string
+
+	// TokenFile is the file holding the token of the OAUTHBEARER mechanism
+	// and TokenCommand the shell command printing it. Either is read every
+	// time a connection is authenticated, so the token can be refreshed.
+	TokenFile    string
+	TokenCommand string
+}
+
+// ConfigureSASL enables the SASL authentication given by the options in the
+// sarama configuration.
+func ConfigureSASL(config *sarama.Config, options SASLOptions) error {
+	mechanism := strings.ToUpper(strings.TrimSpace(options.Mechanism))
+
+	switch mechanism {
+	case sarama.SASLTypePlaintext, sarama.SASLTypeSCRAMSHA256, sarama.SASLTypeSCRAMSHA512:
+		if options.Username == "" {
+			return fmt.Errorf("the SASL mechanism %s requires a username", mechanism)
+		}
+		config.Net.SASL.User = options.Username
+		config.Net.SASL.Password = options.Password
+	case sarama.SASLTypeOAuth:
+		if (options.TokenFile == "") == (options.TokenCommand == "") {
+			return fmt.Errorf("the SASL mechanism %s requires either a token file or a token command", mechanism)
+		}
+		config.Net.SASL.TokenProvider = &tokenProvider{file: options.TokenFile, command: options.TokenCommand}
+	default:
+		return fmt.Errorf("invalid SASL mechanism '%s', expected %s", options.Mechanism, strings.Join(SASLMechanisms, ", "))
+	}
+
+	config.Net.SASL.Enable = true
+	config.Net.SASL.Mechanism = sarama.SASLMechanism(mechanism)
+	config.Net.SASL.Handshake = true
+	switch mechanism {
+	case sarama.SASLTypeSCRAMSHA256:
+		config.Net.SASL.SCRAMClientGeneratorFunc = newSCRAMClientGenerator(scram.SHA256)
+	case sarama.SASLTypeSCRAMSHA512:
+		config.Net.SASL.SCRAMClientGeneratorFunc = newSCRAMClientGenerator(scram.SHA512)
+	}
+	return nil
+}
+
+// newSCRAMClientGenerator returns a generator of SCRAM clients using the
+// given hash function.
+func newSCRAMClientGenerator(hash scram.HashGeneratorFcn) func() sarama.SCRAMClient {
+	return func() sarama.SCRAMClient {
+		return &scramClient{hash: hash}
+	}
+}
+
+// scramClient is a sarama.SCRAMClient holding a SCRAM conversation.
+type scramClient struct {
+	hash         scram.HashGeneratorFcn
+	conversation *scram.ClientConversation
+}
+
+func (client *scramClient) Begin(userName, password, authzID string) error {
+	user, err := client.hash.NewClient(userName, password, authzID)
+	if err != nil {
+		return err
+	}
+	client.conversation = user.NewConversation()
+	return nil
+}
+
+func (client *scramClient) Step(challenge string) (string, error) {
+	return client.conversation.Step(challenge)
+}
+
+func (client *scramClient) Done() bool {
+	return client.conversation.Done()
+}
+
+// tokenProvider is a sarama.AccessTokenProvider reading the token from a file
+// or from the output of a shell command.
+type tokenProvider struct {
+	file    string
+	command string
+}
+
+func (provider *tokenProvider) Token() (*sarama.AccessToken, error) {
+	var token []byte
+	var err error
+	if provider.file != "" {
+		token, err = os.ReadFile(provider.file)
+	} else {
+		var stderr bytes.Buffer
+		command := exec.Command("sh", "-c", provider.command)
+		command.Stderr = &stderr
+		if token, err = command.Output(); err != nil {
+			return nil, fmt.Errorf("token command failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	trimmed := strings.TrimSpace(string(token))
+	if trimmed == "" {
+		return nil, errors.New("empty OAUTHBEARER token")
+	}
+	return &sarama.AccessToken{Token: trimmed}, nil
+}
@@ -0,0 +1,244 @@
+package kafkautils_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bluekiri/kafka-client/internal/kafkautils"
+
+	"github.com/IBM/sarama"
+	"github.com/xdg-go/scram"
+)
+
+// writeCertificate writes a self-signed certificate and its key to PEM files
+// and returns their paths.
+func writeCertificate(t *testing.T) (string, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "kafka-client"},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("CreateCertificate failed: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("MarshalECPrivateKey failed: %v", err)
+	}
+
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return certFile, keyFile
+}
+
+func TestNewTLSConfig(t *testing.T) {
+	certFile, keyFile := writeCertificate(t)
+
+	config, err := kafkautils.NewTLSConfig(kafkautils.TLSOptions{
+		CAFile:             certFile,
+		CertFile:           certFile,
+		KeyFile:            keyFile,
+		InsecureSkipVerify: true,
+	})
+	if err != nil {
+		t.Fatalf("NewTLSConfig failed: %v", err)
+	}
+	if config.RootCAs == nil {
+		t.Error("expected the certificate authorities to be set")
+	}
+	if len(config.Certificates) != 1 {
+		t.Errorf("expected 1 client certificate but got %d", len(config.Certificates))
+	}
+	if !config.InsecureSkipVerify {
+		t.Error("expected InsecureSkipVerify to be set")
+	}
+
+	config, err = kafkautils.NewTLSConfig(kafkautils.TLSOptions{})
+	if err != nil {
+		t.Fatalf("NewTLSConfig failed: %v", err)
+	}
+	if config.RootCAs != nil || len(config.Certificates) != 0 {
+		t.Error("expected the default TLS configuration")
+	}
+}
+
+func TestNewTLSConfigInvalidOptions(t *testing.T) {
+	certFile, keyFile := writeCertificate(t)
+
+	testCases := map[string]kafkautils.TLSOptions{
+		"missing CA file":  {CAFile: filepath.Join(t.TempDir(), "missing.pem")},
+		"invalid CA file":  {CAFile: keyFile},
+		"missing key file": {CertFile: certFile},
+		"missing cert":     {KeyFile: keyFile},
+		"mismatched files": {CertFile: keyFile, KeyFile: certFile},
+	}
+
+	for name, options := range testCases {
+		t.Run(name, func(t *testing.T) {
+			if _, err := kafkautils.NewTLSConfig(options); err == nil {
+				t.Fatal("NewTLSConfig should have failed")
+			}
+		})
+	}
+}
+
+func TestConfigureSASL(t *testing.T) {
+	testCases := map[string]kafkautils.SASLOptions{
+		"PLAIN":         {Mechanism: "PLAIN", Username: "user", Password: "secret"},
+		"SCRAM-SHA-256": {Mechanism: "scram-sha-256", Username: "user", Password: "secret"},
+		"SCRAM-SHA-512": {Mechanism: "SCRAM-SHA-512", Username: "user", Password: "secret"},
+		"OAUTHBEARER":   {Mechanism: "OAUTHBEARER", TokenCommand: "echo token"},
+	}
+
+	for name, options := range testCases {
+		t.Run(name, func(t *testing.T) {
+			config := sarama.NewConfig()
+			if err := kafkautils.ConfigureSASL(config, options); err != nil {
+				t.Fatalf("ConfigureSASL failed: %v", err)
+			}
+			if !config.Net.SASL.Enable {
+				t.Error("expected SASL to be enabled")
+			}
+			if string(config.Net.SASL.Mechanism) != name {
+				t.Errorf("expected mechanism %s but got %s", name, config.Net.SASL.Mechanism)
+			}
+			if err := config.Validate(); err != nil {
+				t.Errorf("invalid configuration: %v", err)
+			}
+		})
+	}
+}
+
+func TestConfigureSASLInvalidOptions(t *testing.T) {
+	testCases := map[string]kafkautils.SASLOptions{
+		"unknown mechanism":  {Mechanism: "GSSAPI", Username: "user"},
+		"missing username":   {Mechanism: "SCRAM-SHA-512", Password: "secret"},
+		"missing token":      {Mechanism: "OAUTHBEARER"},
+		"file and command":   {Mechanism: "OAUTHBEARER", TokenFile: "token", TokenCommand: "echo token"},
+		"no mechanism given": {Username: "user", Password: "secret"},
+	}
+
+	for name, options := range testCases {
+		t.Run(name, func(t *testing.T) {
+			if err := kafkautils.ConfigureSASL(sarama.NewConfig(), options); err == nil {
+				t.Fatal("ConfigureSASL should have failed")
+			}
+		})
+	}
+}
+
+func TestSCRAMClient(t *testing.T) {
+	testCases := map[string]scram.HashGeneratorFcn{
+		"SCRAM-SHA-256": scram.SHA256,
+		"SCRAM-SHA-512": scram.SHA512,
+	}
+
+	for mechanism, hash := range testCases {
+		t.Run(mechanism, func(t *testing.T) {
+			config := sarama.NewConfig()
+			if err := kafkautils.ConfigureSASL(config, kafkautils.SASLOptions{Mechanism: mechanism, Username: "user", Password: "secret"}); err != nil {
+				t.Fatalf("ConfigureSASL failed: %v", err)
+			}
+
+			// The server knows the credentials of the user
+			credentials, err := hash.NewClient("user", "secret", "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			stored := credentials.GetStoredCredentials(scram.KeyFactors{Salt: "salt", Iters: 4096})
+			server, err := hash.NewServer(func(string) (scram.StoredCredentials, error) {
+				return stored, nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			serverConversation := server.NewConversation()
+
+			client := config.Net.SASL.SCRAMClientGeneratorFunc()
+			if err := client.Begin("user", "secret", ""); err != nil {
+				t.Fatalf("Begin failed: %v", err)
+			}
+			challenge := ""
+			for !client.Done() {
+				response, err := client.Step(challenge)
+				if err != nil {
+					t.Fatalf("Step failed: %v", err)
+				}
+				if client.Done() {
+					break
+				}
+				if challenge, err = serverConversation.Step(response); err != nil {
+					t.Fatalf("server Step failed: %v", err)
+				}
+			}
+			if !serverConversation.Valid() {
+				t.Error("expected the server to authenticate the client")
+			}
+		})
+	}
+}
+
+func TestTokenProvider(t *testing.T) {
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(tokenFile, []byte("file-token\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := map[string]struct {
+		options  kafkautils.SASLOptions
+		expected string
+	}{
+		"file":    {options: kafkautils.SASLOptions{Mechanism: "OAUTHBEARER", TokenFile: tokenFile}, expected: "file-token"},
+		"command": {options: kafkautils.SASLOptions{Mechanism: "OAUTHBEARER", TokenCommand: "echo command-token"}, expected: "command-token"},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			config := sarama.NewConfig()
+			if err := kafkautils.ConfigureSASL(config, testCase.options); err != nil {
+				t.Fatalf("ConfigureSASL failed: %v", err)
+			}
+			token, err := config.Net.SASL.TokenProvider.Token()
+			if err != nil {
+				t.Fatalf("Token failed: %v", err)
+			}
+			if token.Token != testCase.expected {
+				t.Errorf("expected token %s but got %s", testCase.expected, token.Token)
+			}
+		})
+	}
+
+	config := sarama.NewConfig()
+	if err := kafkautils.ConfigureSASL(config, kafkautils.SASLOptions{Mechanism: "OAUTHBEARER", TokenCommand: "exit 1"}); err != nil {
+		t.Fatalf("ConfigureSASL failed: %v", err)
+	}
+	if _, err := config.Net.SASL.TokenProvider.Token(); err == nil {
+		t.Error("Token should have failed")
+	}
+}
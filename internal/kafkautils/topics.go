@@ -0,0 +1,166 @@
+/*
+Copyright © 2023 VECI Group Tech S.L.
+This file is part of kafka-client.
+*/
+
+package kafkautils
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/IBM/sarama"
+)
+
+// TopicSummary is the summary of a topic listed by ListTopics.
+type TopicSummary struct {
+	Name              string `json:"name"`
+	Partitions        int    `json:"partitions"`
+	ReplicationFactor int    `json:"replicationFactor"`
+	Internal          bool   `json:"internal,omitempty"`
+}
+
+// ListTopics returns the summaries of the topics of the cluster sorted by
+// name. Internal topics are only included if requested.
+func ListTopics(admin sarama.ClusterAdmin, internal bool) ([]TopicSummary, error) {
+	metadata, err := admin.DescribeTopics(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	topics := make([]TopicSummary, 0, len(metadata))
+	for _, topic := range metadata {
+		if topic.Err != sarama.ErrNoError {
+			return nil, fmt.Errorf("topic %s: %w", topic.Name, topic.Err)
+		}
+		if topic.IsInternal && !internal {
+			continue
+		}
+		summary := TopicSummary{
+			Name:       topic.Name,
+			Partitions: len(topic.Partitions),
+			Internal:   topic.IsInternal,
+		}
+		if len(topic.Partitions) > 0 {
+			summary.ReplicationFactor = len(topic.Partitions[0].Replicas)
+		}
+		topics = append(topics, summary)
+	}
+
+	slices.SortFunc(topics, func(a, b TopicSummary) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return topics, nil
+}
+
+// TopicDescription is the description of a topic returned by DescribeTopic.
+type TopicDescription struct {
+	Name       string                 `json:"name"`
+	Internal   bool                   `json:"internal,omitempty"`
+	Partitions []PartitionDescription `json:"partitions"`
+	Configs    []ConfigDescription    `json:"configs"`
+}
+
+// PartitionDescription is the description of a partition of a topic, holding
+// its replicas and its oldest and newest offsets, the low and high watermarks.
+type PartitionDescription struct {
+	Partition int32   `json:"partition"`
+	Leader    int32   `json:"leader"`
+	Replicas  []int32 `json:"replicas"`
+	ISR       []int32 `json:"isr"`
+	Oldest    int64   `json:"oldest"`
+	Newest    int64   `json:"newest"`
+}
+
+// ConfigDescription is a configuration entry of a topic. The values of the
+// sensitive entries are hidden.
+type ConfigDescription struct {
+	Name      string `json:"name"`
+	Value     string `json:"value"`
+	Source    string `json:"source"`
+	Sensitive bool   `json:"sensitive,omitempty"`
+}
+
+// DescribeTopic returns the description of the topic, whose watermarks are
+// fetched by the client. Only the configuration entries set for the topic or
+// the brokers are included, unless all of them are requested.
+func DescribeTopic(admin sarama.ClusterAdmin, client sarama.Client, topic string, allConfigs bool) (*TopicDescription, error) {
+	metadata, err := admin.DescribeTopics([]string{topic})
+	if err != nil {
+		return nil, err
+	}
+	if len(metadata) != 1 {
+		return nil, fmt.Errorf("topic %s: %w", topic, sarama.ErrUnknownTopicOrPartition)
+	}
+	if metadata[0].Err != sarama.ErrNoError {
+		return nil, fmt.Errorf("topic %s: %w", topic, metadata[0].Err)
+	}
+
+	description := &TopicDescription{
+		Name:       topic,
+		Internal:   metadata[0].IsInternal,
+		Partitions: make([]PartitionDescription, 0, len(metadata[0].Partitions)),
+		Configs:    []ConfigDescription{},
+	}
+
+	for _, partition := range metadata[0].Partitions {
+		oldest, err := client.GetOffset(topic, partition.ID, sarama.OffsetOldest)
+		if err != nil {
+			return nil, err
+		}
+		newest, err := client.GetOffset(topic, partition.ID, sarama.OffsetNewest)
+		if err != nil {
+			return nil, err
+		}
+		description.Partitions = append(description.Partitions, PartitionDescription{
+			Partition: partition.ID,
+			Leader:    partition.Leader,
+			Replicas:  partition.Replicas,
+			ISR:       partition.Isr,
+			Oldest:    oldest,
+			Newest:    newest,
+		})
+	}
+	slices.SortFunc(description.Partitions, func(a, b PartitionDescription) int {
+		return int(a.Partition - b.Partition)
+	})
+
+	entries, err := admin.DescribeConfig(sarama.ConfigResource{Type: sarama.TopicResource, Name: topic})
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if entry.Default && !allConfigs {
+			continue
+		}
+		config := ConfigDescription{
+			Name:      entry.Name,
+			Value:     entry.Value,
+			Source:    entry.Source.String(),
+			Sensitive: entry.Sensitive,
+		}
+		if entry.Sensitive {
+			config.Value = "****"
+		}
+		description.Configs = append(description.Configs, config)
+	}
+	slices.SortFunc(description.Configs, func(a, b ConfigDescription) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return description, nil
+}
+
+// ParseConfigEntries returns the configuration entries given as key=value.
+func ParseConfigEntries(entries []string) (map[string]*string, error) {
+	configs := make(map[string]*string, len(entries))
+	for _, entry := range entries {
+		key, value, found := strings.Cut(entry, "=")
+		if !found || strings.TrimSpace(key) == "" {
+			return nil, fmt.Errorf("invalid configuration entry '%s', expected key=value", entry)
+		}
+		configs[strings.TrimSpace(key)] = &value
+	}
+	return configs, nil
+}
@@ -0,0 +1,136 @@
+package kafkautils_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/bluekiri/kafka-client/internal/kafkautils"
+
+	"github.com/IBM/sarama"
+)
+
+// newTestAdmin returns a cluster admin of a mock cluster holding the topics
+// orders, with 2 partitions holding messages from offset 5 to 10, and
+// payments, with 1 partition.
+func newTestAdmin(t *testing.T) (sarama.ClusterAdmin, sarama.Client) {
+	broker := sarama.NewMockBroker(t, 1)
+	t.Cleanup(broker.Close)
+
+	metadataResponse := sarama.NewMockMetadataResponse(t).
+		SetController(broker.BrokerID()).
+		SetBroker(broker.Addr(), broker.BrokerID()).
+		SetLeader("orders", 0, broker.BrokerID()).
+		SetLeader("orders", 1, broker.BrokerID()).
+		SetLeader("payments", 0, broker.BrokerID())
+	offsetResponse := sarama.NewMockOffsetResponse(t)
+	for partition := int32(0); partition < 2; partition++ {
+		offsetResponse.
+			SetOffset("orders", partition, sarama.OffsetOldest, 5).
+			SetOffset("orders", partition, sarama.OffsetNewest, 10)
+	}
+
+	broker.SetHandlerByMap(map[string]sarama.MockResponse{
+		"MetadataRequest":        metadataResponse,
+		"OffsetRequest":          offsetResponse,
+		"DescribeConfigsRequest": sarama.NewMockDescribeConfigsResponse(t),
+	})
+
+	config := sarama.NewConfig()
+	config.Version = sarama.V2_1_0_0
+	client, err := sarama.NewClient([]string{broker.Addr()}, config)
+	if err != nil {
+		t.Fatalf("sarama.NewClient failed: %v", err)
+	}
+	admin, err := sarama.NewClusterAdminFromClient(client)
+	if err != nil {
+		t.Fatalf("sarama.NewClusterAdminFromClient failed: %v", err)
+	}
+	t.Cleanup(func() { admin.Close() })
+	return admin, client
+}
+
+func TestListTopics(t *testing.T) {
+	admin, _ := newTestAdmin(t)
+
+	topics, err := kafkautils.ListTopics(admin, false)
+	if err != nil {
+		t.Fatalf("ListTopics failed: %v", err)
+	}
+	// The mock cluster has a single broker holding every replica
+	expected := []kafkautils.TopicSummary{
+		{Name: "orders", Partitions: 2, ReplicationFactor: 1},
+		{Name: "payments", Partitions: 1, ReplicationFactor: 1},
+	}
+	if !slices.Equal(topics, expected) {
+		t.Errorf("expected topics %v but got %v", expected, topics)
+	}
+}
+
+func TestDescribeTopic(t *testing.T) {
+	admin, client := newTestAdmin(t)
+
+	description, err := kafkautils.DescribeTopic(admin, client, "orders", false)
+	if err != nil {
+		t.Fatalf("DescribeTopic failed: %v", err)
+	}
+	if len(description.Partitions) != 2 {
+		t.Fatalf("expected 2 partitions but got %d", len(description.Partitions))
+	}
+	for i, partition := range description.Partitions {
+		if partition.Partition != int32(i) {
+			t.Errorf("expected partition %d but got %d", i, partition.Partition)
+		}
+		if partition.Oldest != 5 || partition.Newest != 10 {
+			t.Errorf("partition %d: expected offsets 5 to 10 but got %d to %d", i, partition.Oldest, partition.Newest)
+		}
+	}
+
+	// The default entries are skipped and the sensitive ones hidden
+	configs := map[string]string{}
+	for _, config := range description.Configs {
+		configs[config.Name] = config.Value
+	}
+	if _, ok := configs["max.message.bytes"]; ok {
+		t.Error("expected the default max.message.bytes to be skipped")
+	}
+	if configs["retention.ms"] != "5000" {
+		t.Errorf("expected retention.ms 5000 but got %s", configs["retention.ms"])
+	}
+	if configs["password"] != "****" {
+		t.Errorf("expected the password to be hidden but got %s", configs["password"])
+	}
+
+	description, err = kafkautils.DescribeTopic(admin, client, "orders", true)
+	if err != nil {
+		t.Fatalf("DescribeTopic failed: %v", err)
+	}
+	if len(description.Configs) != 3 {
+		t.Errorf("expected 3 configs but got %d", len(description.Configs))
+	}
+}
+
+func TestParseConfigEntries(t *testing.T) {
+	configs, err := kafkautils.ParseConfigEntries([]string{"retention.ms=86400000", "cleanup.policy=compact,delete", "message.format="})
+	if err != nil {
+		t.Fatalf("ParseConfigEntries failed: %v", err)
+	}
+	expected := map[string]string{
+		"retention.ms":   "86400000",
+		"cleanup.policy": "compact,delete",
+		"message.format": "",
+	}
+	if len(configs) != len(expected) {
+		t.Fatalf("expected %d entries but got %d", len(expected), len(configs))
+	}
+	for key, value := range expected {
+		if configs[key] == nil || *configs[key] != value {
+			t.Errorf("expected %s=%s", key, value)
+		}
+	}
+
+	for _, entry := range []string{"retention.ms", "=value"} {
+		if _, err := kafkautils.ParseConfigEntries([]string{entry}); err == nil {
+			t.Errorf("ParseConfigEntries(%s) should have failed", entry)
+		}
+	}
+}
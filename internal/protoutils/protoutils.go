@@ -2,21 +2,58 @@ package protoutils
 
 import (
 	"context"
+	"fmt"
+	"os"
 
 	"github.com/bufbuild/protocompile"
 	"github.com/bufbuild/protocompile/linker"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
 	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
 )
 
-func ProtoMessageTypes(ctx context.Context, protoFiles []string, importPaths []string) ([]string, error) {
-	resolver, err := compileProtoFiles(ctx, protoFiles, importPaths)
+// Sources are the sources of the protobuf message types: either compiled
+// descriptor sets, as produced by protoc -o or buf build, or .proto source
+// files whose imports are resolved using the import paths.
+type Sources struct {
+	ProtoFiles     []string
+	ImportPaths    []string
+	DescriptorSets []string
+}
+
+func ProtoMessageTypes(ctx context.Context, sources Sources) ([]string, error) {
+	if len(sources.DescriptorSets) > 0 {
+		files, paths, err := loadDescriptorSets(sources.DescriptorSets)
+		if err != nil {
+			return nil, err
+		}
+
+		// Resolve the message descriptors of every file of the descriptor sets
+		messageTypes := make([]string, 0)
+		for _, path := range paths {
+			fileDescriptor, err := files.FindFileByPath(path)
+			if err != nil {
+				return nil, err
+			}
+			messages := fileDescriptor.Messages()
+			for i := 0; i < messages.Len(); i++ {
+				messageTypes = append(messageTypes, string(messages.Get(i).FullName()))
+			}
+		}
+		return messageTypes, nil
+	}
+
+	resolver, err := compileProtoFiles(ctx, sources.ProtoFiles, sources.ImportPaths)
 	if err != nil {
 		return nil, err
 	}
 
 	// Resolve all the message descriptors
 	messageTypes := make([]string, 0)
-	for _, protoFile := range protoFiles {
+	for _, protoFile := range sources.ProtoFiles {
 		fileDescriptor, err := resolver.FindFileByPath(protoFile)
 		if err != nil {
 			return nil, err
@@ -29,8 +66,26 @@ func ProtoMessageTypes(ctx context.Context, protoFiles []string, importPaths []s
 	return messageTypes, nil
 }
 
-func ResolveProtoMessageType(ctx context.Context, messageFullName string, protoFiles []string, importPaths []string) (protoreflect.MessageType, error) {
-	resolver, err := compileProtoFiles(ctx, protoFiles, importPaths)
+func ResolveProtoMessageType(ctx context.Context, messageFullName string, sources Sources) (protoreflect.MessageType, error) {
+	if len(sources.DescriptorSets) > 0 {
+		files, _, err := loadDescriptorSets(sources.DescriptorSets)
+		if err != nil {
+			return nil, err
+		}
+
+		// Resolve the message descriptor
+		descriptor, err := files.FindDescriptorByName(protoreflect.FullName(messageFullName))
+		if err != nil {
+			return nil, err
+		}
+		messageDescriptor, ok := descriptor.(protoreflect.MessageDescriptor)
+		if !ok {
+			return nil, fmt.Errorf("%s is not a message", messageFullName)
+		}
+		return dynamicpb.NewMessageType(messageDescriptor), nil
+	}
+
+	resolver, err := compileProtoFiles(ctx, sources.ProtoFiles, sources.ImportPaths)
 	if err != nil {
 		return nil, err
 	}
@@ -53,4 +108,56 @@ func compileProtoFiles(ctx context.Context, protoFiles []string, importPaths []s
 		return nil, err
 	}
 	return files.AsResolver(), nil
-}
\ No newline at end of file
+}
+
+// loadDescriptorSets reads the FileDescriptorSet files and returns the files
+// they describe and their paths. Imports missing from the sets, like the well
+// known types when the sets are built without --include_imports, are resolved
+// using the files linked into the binary.
+func loadDescriptorSets(descriptorSets []string) (*protoregistry.Files, []string, error) {
+	fileSet := &descriptorpb.FileDescriptorSet{}
+	names := make(map[string]bool)
+	for _, descriptorSet := range descriptorSets {
+		data, err := os.ReadFile(descriptorSet)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		set := &descriptorpb.FileDescriptorSet{}
+		if err := proto.Unmarshal(data, set); err != nil {
+			return nil, nil, fmt.Errorf("invalid descriptor set %s: %w", descriptorSet, err)
+		}
+
+		// The same file may be found in several sets
+		for _, file := range set.GetFile() {
+			if !names[file.GetName()] {
+				names[file.GetName()] = true
+				fileSet.File = append(fileSet.File, file)
+			}
+		}
+	}
+
+	paths := make([]string, 0, len(fileSet.File))
+	for _, file := range fileSet.File {
+		paths = append(paths, file.GetName())
+	}
+
+	// Add the missing imports known by the binary
+	for i := 0; i < len(fileSet.File); i++ {
+		for _, dependency := range fileSet.File[i].GetDependency() {
+			if names[dependency] {
+				continue
+			}
+			if file, err := protoregistry.GlobalFiles.FindFileByPath(dependency); err == nil {
+				names[dependency] = true
+				fileSet.File = append(fileSet.File, protodesc.ToFileDescriptorProto(file))
+			}
+		}
+	}
+
+	files, err := protodesc.NewFiles(fileSet)
+	if err != nil {
+		return nil, nil, err
+	}
+	return files, paths, nil
+}
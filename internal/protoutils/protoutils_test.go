@@ -0,0 +1,97 @@
+package protoutils_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/bluekiri/kafka-client/internal/protoutils"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+var protoSources = protoutils.Sources{
+	ProtoFiles:  []string{"test.proto"},
+	ImportPaths: []string{"testdata"},
+}
+
+// writeDescriptorSet writes the descriptor set of test.proto, without its
+// imports, and returns its path.
+func writeDescriptorSet(t *testing.T) string {
+	messageType, err := protoutils.ResolveProtoMessageType(context.Background(), "test.TestMessage", protoSources)
+	if err != nil {
+		t.Fatalf("ResolveProtoMessageType failed: %v", err)
+	}
+
+	set := &descriptorpb.FileDescriptorSet{
+		File: []*descriptorpb.FileDescriptorProto{
+			protodesc.ToFileDescriptorProto(messageType.Descriptor().ParentFile()),
+		},
+	}
+	data, err := proto.Marshal(set)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+
+	descriptorSet := filepath.Join(t.TempDir(), "test.pb")
+	if err := os.WriteFile(descriptorSet, data, 0o644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	return descriptorSet
+}
+
+func TestProtoMessageTypes(t *testing.T) {
+	testCases := map[string]func(t *testing.T) protoutils.Sources{
+		"proto files": func(t *testing.T) protoutils.Sources {
+			return protoSources
+		},
+		"descriptor sets": func(t *testing.T) protoutils.Sources {
+			return protoutils.Sources{DescriptorSets: []string{writeDescriptorSet(t)}}
+		},
+	}
+
+	for name, sources := range testCases {
+		t.Run(name, func(t *testing.T) {
+			messageTypes, err := protoutils.ProtoMessageTypes(context.Background(), sources(t))
+			if err != nil {
+				t.Fatalf("ProtoMessageTypes failed: %v", err)
+			}
+			if expected := []string{"test.TestMessage", "test.OtherMessage"}; !slices.Equal(messageTypes, expected) {
+				t.Errorf("Expected %v but got %v", expected, messageTypes)
+			}
+		})
+	}
+}
+
+func TestResolveProtoMessageTypeFromDescriptorSet(t *testing.T) {
+	descriptorSet := writeDescriptorSet(t)
+
+	// Listing the same file twice must not fail
+	sources := protoutils.Sources{DescriptorSets: []string{descriptorSet, descriptorSet}}
+	messageType, err := protoutils.ResolveProtoMessageType(context.Background(), "test.TestMessage.Nested", sources)
+	if err != nil {
+		t.Fatalf("ResolveProtoMessageType failed: %v", err)
+	}
+	if name := messageType.Descriptor().FullName(); name != "test.TestMessage.Nested" {
+		t.Errorf("Expected test.TestMessage.Nested but got %s", name)
+	}
+
+	if _, err := protoutils.ResolveProtoMessageType(context.Background(), "test.Unknown", sources); err == nil {
+		t.Error("ResolveProtoMessageType should have failed")
+	}
+}
+
+func TestResolveProtoMessageTypeInvalidDescriptorSet(t *testing.T) {
+	descriptorSet := filepath.Join(t.TempDir(), "invalid.pb")
+	if err := os.WriteFile(descriptorSet, []byte("invalid"), 0o644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	sources := protoutils.Sources{DescriptorSets: []string{descriptorSet}}
+	if _, err := protoutils.ResolveProtoMessageType(context.Background(), "test.TestMessage", sources); err == nil {
+		t.Error("ResolveProtoMessageType should have failed")
+	}
+}
@@ -0,0 +1,120 @@
+/*
+Copyright © 2023 VECI Group Tech S.L.
+This file is part of kafka-client.
+*/
+
+package protoutils
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bluekiri/kafka-client/internal/registry"
+
+	"github.com/bufbuild/protocompile"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// CompileRegistrySchema compiles a protobuf schema fetched from the schema
+// registry, fetching the schemas it references from the registry too.
+func CompileRegistrySchema(ctx context.Context, client *registry.Client, schema *registry.Schema) (protoreflect.FileDescriptor, error) {
+	if schema.Type() != registry.SchemaTypeProtobuf {
+		return nil, fmt.Errorf("schema %d is a %s schema", schema.ID, schema.Type())
+	}
+
+	// Get the sources of the schema and its references
+	name := fmt.Sprintf("registry/schema-%d.proto", schema.ID)
+	sources := map[string]string{name: schema.Schema}
+	if err := addReferences(ctx, client, schema.References, sources); err != nil {
+		return nil, err
+	}
+
+	// Compile the schema
+	compiler := protocompile.Compiler{
+		Resolver: protocompile.WithStandardImports(&protocompile.SourceResolver{
+			Accessor: protocompile.SourceAccessorFromMap(sources),
+		}),
+	}
+	files, err := compiler.Compile(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return files[0], nil
+}
+
+// addReferences adds the sources of the referenced schemas, and the schemas
+// they reference, to sources.
+func addReferences(ctx context.Context, client *registry.Client, references []registry.Reference, sources map[string]string) error {
+	for _, reference := range references {
+		if _, found := sources[reference.Name]; found {
+			continue
+		}
+
+		schema, err := client.SchemaByVersion(ctx, reference.Subject, reference.Version)
+		if err != nil {
+			return err
+		}
+		sources[reference.Name] = schema.Schema
+		if err := addReferences(ctx, client, schema.References, sources); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MessageByIndexes returns the message of the file found following the
+// message indexes of the schema registry wire format.
+func MessageByIndexes(file protoreflect.FileDescriptor, indexes []int) (protoreflect.MessageDescriptor, error) {
+	var message protoreflect.MessageDescriptor
+	messages := file.Messages()
+	for _, index := range indexes {
+		if index >= messages.Len() {
+			return nil, fmt.Errorf("message indexes %v not found in %s", indexes, file.Path())
+		}
+		message = messages.Get(index)
+		messages = message.Messages()
+	}
+	if message == nil {
+		return nil, fmt.Errorf("no message indexes given for %s", file.Path())
+	}
+	return message, nil
+}
+
+// MessageIndexes returns the message indexes of the schema registry wire
+// format of the message.
+func MessageIndexes(message protoreflect.MessageDescriptor) []int {
+	indexes := []int{message.Index()}
+	for parent, ok := message.Parent().(protoreflect.MessageDescriptor); ok; parent, ok = parent.Parent().(protoreflect.MessageDescriptor) {
+		indexes = append([]int{parent.Index()}, indexes...)
+	}
+	return indexes
+}
+
+// FindMessage returns the message of the file with the given full name, or
+// the first message of the file if no name is given.
+func FindMessage(file protoreflect.FileDescriptor, messageFullName string) (protoreflect.MessageDescriptor, error) {
+	if messageFullName == "" {
+		if file.Messages().Len() == 0 {
+			return nil, fmt.Errorf("no messages found in %s", file.Path())
+		}
+		return file.Messages().Get(0), nil
+	}
+
+	if message := findMessage(file.Messages(), protoreflect.FullName(messageFullName)); message != nil {
+		return message, nil
+	}
+	return nil, fmt.Errorf("message %s not found in %s", messageFullName, file.Path())
+}
+
+func findMessage(messages protoreflect.MessageDescriptors, name protoreflect.FullName) protoreflect.MessageDescriptor {
+	for i := 0; i < messages.Len(); i++ {
+		message := messages.Get(i)
+		if message.FullName() == name {
+			return message
+		}
+		if nested := findMessage(message.Messages(), name); nested != nil {
+			return nested
+		}
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+/*
+Copyright © 2023 VECI Group Tech S.L.
+This file is part of kafka-client.
+*/
+
+package redact
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/bluekiri/kafka-client/internal/jsonutils"
+)
+
+// Policy is how a field is redacted.
+type Policy string
+
+const (
+	// Mask replaces strings by MaskedString and other values by null.
+	Mask Policy = "mask"
+
+	// Hash replaces the values by the hexadecimal HMAC-SHA256 of the strings,
+	// or of the JSON text of other values, keyed by the hash key of the
+	// Redactor, so redacted values can still be correlated but not guessed
+	// without the key.
+	Hash Policy = "hash"
+
+	// Drop removes the fields.
+	Drop Policy = "drop"
+)
+
+// Policies are the supported policies.
+var Policies = []Policy{Mask, Hash, Drop}
+
+// MaskedString replaces the masked strings.
+const MaskedString = "****"
+
+// ParsePolicy returns the policy with the given name.
+func ParsePolicy(name string) (Policy, error) {
+	policy := Policy(name)
+	if !slices.Contains(Policies, policy) {
+		return "", fmt.Errorf("unknown redaction policy '%s', expected mask, hash or drop", name)
+	}
+	return policy, nil
+}
+
+// Field is a field to redact, given by its dot separated path (e.g.
+// customer.email), and its policy.
+type Field struct {
+	Path   string
+	Policy Policy
+}
+
+// ParseField parses a field given as path[:policy], using the default policy
+// if no policy is given.
+func ParseField(field string, defaultPolicy Policy) (Field, error) {
+	path, name, found := strings.Cut(field, ":")
+	if !found {
+		return Field{path, defaultPolicy}, nil
+	}
+	policy, err := ParsePolicy(name)
+	if err != nil {
+		return Field{}, err
+	}
+	return Field{path, policy}, nil
+}
+
+// Redactor redacts fields of JSON values. Paths going through arrays are
+// applied to every element of the arrays. Null values are not redacted.
+type Redactor struct {
+	fields  []field
+	hashKey []byte
+}
+
+type field struct {
+	path   []string
+	policy Policy
+}
+
+// ErrMissingHashKey is returned by NewRedactor if a field uses the hash
+// policy without a hash key.
+var ErrMissingHashKey = errors.New("redact: the hash policy requires a secret hash key")
+
+// NewRedactor returns a Redactor of the given fields. The hash key is the
+// secret key of the fields using the hash policy, which is required if any
+// field uses it.
+func NewRedactor(hashKey []byte, fields ...Field) (*Redactor, error) {
+	redactor := &Redactor{hashKey: hashKey}
+	for _, f := range fields {
+		path, err := jsonutils.ParsePath(f.Path)
+		if err != nil {
+			return nil, err
+		}
+		if !slices.Contains(Policies, f.Policy) {
+			return nil, fmt.Errorf("unknown redaction policy '%s' of field '%s'", f.Policy, f.Path)
+		}
+		if f.Policy == Hash && len(hashKey) == 0 {
+			return nil, ErrMissingHashKey
+		}
+		redactor.fields = append(redactor.fields, field{path, f.Policy})
+	}
+	return redactor, nil
+}
+
+// Redact redacts the fields of the decoded JSON value in place.
+func (redactor *Redactor) Redact(value any) error {
+	var err error
+	for _, f := range redactor.fields {
+		jsonutils.VisitField(value, f.path, func(object map[string]any, name string) {
+			if object[name] == nil {
+				return
+			}
+			switch f.policy {
+			case Mask:
+				if _, isString := object[name].(string); isString {
+					object[name] = MaskedString
+				} else {
+					object[name] = nil
+				}
+			case Hash:
+				var hashed string
+				if hashed, err = redactor.hash(object[name]); err == nil {
+					object[name] = hashed
+				}
+			case Drop:
+				delete(object, name)
+			}
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RedactJSON returns the JSON text with its fields redacted.
+func (redactor *Redactor) RedactJSON(text []byte) ([]byte, error) {
+	value, err := jsonutils.Decode(text)
+	if err != nil {
+		return nil, fmt.Errorf("redact: the value is not JSON: %w", err)
+	}
+	if err := redactor.Redact(value); err != nil {
+		return nil, fmt.Errorf("redact: %w", err)
+	}
+	return jsonutils.Encode(value)
+}
+
+func (redactor *Redactor) hash(value any) (string, error) {
+	text, isString := value.(string)
+	if !isString {
+		encoded, err := jsonutils.Encode(value)
+		if err != nil {
+			return "", err
+		}
+		text = string(encoded)
+	}
+	mac := hmac.New(sha256.New, redactor.hashKey)
+	mac.Write([]byte(text))
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
@@ -0,0 +1,110 @@
+package redact_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/bluekiri/kafka-client/internal/redact"
+)
+
+var testHashKey = []byte("secret")
+
+const testJSON = `{"id":12345678901234567890,"email":"a@b.com","card":{"number":"4111111111111111","cvv":123},"tokens":[{"value":"t1"},{"value":"t2"},{"other":null}],"note":null}`
+
+func TestRedactJSON(t *testing.T) {
+	testCases := map[string]struct {
+		fields   []redact.Field
+		expected string
+	}{
+		"mask": {
+			[]redact.Field{{Path: "email", Policy: redact.Mask}, {Path: "card.cvv", Policy: redact.Mask}, {Path: "note", Policy: redact.Mask}},
+			`{"card":{"cvv":null,"number":"4111111111111111"},"email":"****","id":12345678901234567890,"note":null,"tokens":[{"value":"t1"},{"value":"t2"},{"other":null}]}`,
+		},
+		"hash": {
+			[]redact.Field{{Path: "email", Policy: redact.Hash}, {Path: "card.cvv", Policy: redact.Hash}},
+			`{"card":{"cvv":"77de38e4b50e618a0ebb95db61e2f42697391659d82c064a5f81b9f48d85ccd5","number":"4111111111111111"},"email":"f2d15403cb47c2208bde2f9ae83e4decafe9e748ac524a447f682edbcafaa4c0","id":12345678901234567890,"note":null,"tokens":[{"value":"t1"},{"value":"t2"},{"other":null}]}`,
+		},
+		"drop": {
+			[]redact.Field{{Path: "card", Policy: redact.Drop}, {Path: "tokens.value", Policy: redact.Drop}, {Path: "missing.field", Policy: redact.Drop}},
+			`{"email":"a@b.com","id":12345678901234567890,"note":null,"tokens":[{},{},{"other":null}]}`,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			redactor, err := redact.NewRedactor(testHashKey, tc.fields...)
+			if err != nil {
+				t.Fatalf("NewRedactor failed: %v", err)
+			}
+			actual, err := redactor.RedactJSON([]byte(testJSON))
+			if err != nil {
+				t.Fatalf("RedactJSON failed: %v", err)
+			}
+			if string(actual) != tc.expected {
+				t.Errorf("Expected '%s' but got '%s'", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestRedactJSONNotJSON(t *testing.T) {
+	redactor, err := redact.NewRedactor(nil, redact.Field{Path: "email", Policy: redact.Mask})
+	if err != nil {
+		t.Fatalf("NewRedactor failed: %v", err)
+	}
+	for _, text := range []string{"", "a@b.com", `{"email":"a@b.com"} trailing`} {
+		if _, err := redactor.RedactJSON([]byte(text)); err == nil {
+			t.Errorf("RedactJSON should have failed for '%s'", text)
+		}
+	}
+}
+
+func TestParseField(t *testing.T) {
+	testCases := map[string]redact.Field{
+		"email":            {Path: "email", Policy: redact.Mask},
+		"card.number:hash": {Path: "card.number", Policy: redact.Hash},
+		"token:drop":       {Path: "token", Policy: redact.Drop},
+	}
+	for text, expected := range testCases {
+		field, err := redact.ParseField(text, redact.Mask)
+		if err != nil {
+			t.Fatalf("ParseField failed for '%s': %v", text, err)
+		}
+		if field != expected {
+			t.Errorf("Expected %+v but got %+v", expected, field)
+		}
+	}
+
+	if _, err := redact.ParseField("email:erase", redact.Mask); err == nil {
+		t.Error("ParseField should have failed for an unknown policy")
+	}
+	for _, field := range []redact.Field{{Path: "a..b", Policy: redact.Mask}, {Path: "a", Policy: "erase"}} {
+		if _, err := redact.NewRedactor(testHashKey, field); err == nil {
+			t.Errorf("NewRedactor should have failed for %+v", field)
+		}
+	}
+}
+
+func TestNewRedactorMissingHashKey(t *testing.T) {
+	_, err := redact.NewRedactor(nil, redact.Field{Path: "email", Policy: redact.Hash})
+	if !errors.Is(err, redact.ErrMissingHashKey) {
+		t.Errorf("Expected ErrMissingHashKey but got %v", err)
+	}
+
+	// The hash depends on the key
+	values := map[string]bool{}
+	for _, key := range []string{"secret", "other"} {
+		redactor, err := redact.NewRedactor([]byte(key), redact.Field{Path: "email", Policy: redact.Hash})
+		if err != nil {
+			t.Fatalf("NewRedactor failed: %v", err)
+		}
+		redacted, err := redactor.RedactJSON([]byte(`{"email":"a@b.com"}`))
+		if err != nil {
+			t.Fatalf("RedactJSON failed: %v", err)
+		}
+		values[string(redacted)] = true
+	}
+	if len(values) != 2 {
+		t.Error("Expected different hashes with different keys")
+	}
+}
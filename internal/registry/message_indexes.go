@@ -0,0 +1,60 @@
+/*
+Copyright © 2023 VECI Group Tech S.L.
+This file is part of kafka-client.
+*/
+
+package registry
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+/*
+Protobuf payloads in the wire format start with the message indexes, the path
+to the message type in the schema: the index of the message among the messages
+of the file followed by the index of every nested message. The indexes are
+written as zigzag varints, preceded by their count, except [0], the most
+common case, which is written as a single 0.
+*/
+
+// ErrInvalidMessageIndexes is returned when the message indexes can't be read.
+var ErrInvalidMessageIndexes = errors.New("schema registry: invalid message indexes")
+
+// SplitMessageIndexes returns the message indexes and the rest of the protobuf
+// payload.
+func SplitMessageIndexes(payload []byte) ([]int, []byte, error) {
+	count, n := binary.Varint(payload)
+	if n <= 0 || count < 0 || count > int64(len(payload)) {
+		return nil, nil, ErrInvalidMessageIndexes
+	}
+	payload = payload[n:]
+	if count == 0 {
+		return []int{0}, payload, nil
+	}
+
+	indexes := make([]int, count)
+	for i := range indexes {
+		index, n := binary.Varint(payload)
+		if n <= 0 || index < 0 {
+			return nil, nil, ErrInvalidMessageIndexes
+		}
+		indexes[i] = int(index)
+		payload = payload[n:]
+	}
+	return indexes, payload, nil
+}
+
+// AppendMessageIndexes appends the message indexes to dst and returns the
+// extended buffer.
+func AppendMessageIndexes(dst []byte, indexes []int) []byte {
+	if len(indexes) == 1 && indexes[0] == 0 {
+		return binary.AppendVarint(dst, 0)
+	}
+
+	dst = binary.AppendVarint(dst, int64(len(indexes)))
+	for _, index := range indexes {
+		dst = binary.AppendVarint(dst, int64(index))
+	}
+	return dst
+}
@@ -0,0 +1,159 @@
+/*
+Copyright © 2023 VECI Group Tech S.L.
+This file is part of kafka-client.
+*/
+
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Schema types as returned by the schema registry. Avro schemas are returned
+// without schema type.
+const (
+	SchemaTypeAvro     = "AVRO"
+	SchemaTypeProtobuf = "PROTOBUF"
+	SchemaTypeJSON     = "JSON"
+)
+
+// Schema is a schema registered in a schema registry.
+type Schema struct {
+	ID         int         `json:"id"`
+	Subject    string      `json:"subject,omitempty"`
+	Version    int         `json:"version,omitempty"`
+	SchemaType string      `json:"schemaType,omitempty"`
+	Schema     string      `json:"schema"`
+	References []Reference `json:"references,omitempty"`
+}
+
+// Type returns the schema type, defaulting to AVRO if not given.
+func (schema *Schema) Type() string {
+	if schema.SchemaType == "" {
+		return SchemaTypeAvro
+	}
+	return schema.SchemaType
+}
+
+// Reference is a reference from a schema to a schema registered under another
+// subject.
+type Reference struct {
+	Name    string `json:"name"`
+	Subject string `json:"subject"`
+	Version int    `json:"version"`
+}
+
+// Client is a client of a Confluent compatible schema registry. Schemas are
+// cached, so every schema is fetched only once.
+type Client struct {
+	url        string
+	httpClient *http.Client
+
+	mutex     sync.Mutex
+	byID      map[int]*Schema
+	bySubject map[string]*Schema
+}
+
+// NewClient returns a Client of the schema registry at the given URL. The
+// requests fail after the timeout, unless it is zero.
+func NewClient(registryURL string, timeout time.Duration) *Client {
+	return &Client{
+		url:        strings.TrimSuffix(registryURL, "/"),
+		httpClient: &http.Client{Timeout: timeout},
+		byID:       make(map[int]*Schema),
+		bySubject:  make(map[string]*Schema),
+	}
+}
+
+// SchemaByID returns the schema with the given ID.
+func (client *Client) SchemaByID(ctx context.Context, id int) (*Schema, error) {
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+
+	if schema, found := client.byID[id]; found {
+		return schema, nil
+	}
+
+	schema := &Schema{}
+	if err := client.get(ctx, fmt.Sprintf("/schemas/ids/%d", id), schema); err != nil {
+		return nil, err
+	}
+	schema.ID = id
+	client.byID[id] = schema
+	return schema, nil
+}
+
+// LatestSchema returns the latest version of the schema registered under the
+// given subject. The latest version is fetched only once, so versions
+// registered later are ignored.
+func (client *Client) LatestSchema(ctx context.Context, subject string) (*Schema, error) {
+	return client.subjectSchema(ctx, subject, "latest")
+}
+
+// SchemaByVersion returns the given version of the schema registered under the
+// given subject.
+func (client *Client) SchemaByVersion(ctx context.Context, subject string, version int) (*Schema, error) {
+	return client.subjectSchema(ctx, subject, fmt.Sprint(version))
+}
+
+func (client *Client) subjectSchema(ctx context.Context, subject string, version string) (*Schema, error) {
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+
+	key := subject + "/" + version
+	if schema, found := client.bySubject[key]; found {
+		return schema, nil
+	}
+
+	schema := &Schema{}
+	if err := client.get(ctx, fmt.Sprintf("/subjects/%s/versions/%s", url.PathEscape(subject), version), schema); err != nil {
+		return nil, err
+	}
+	client.bySubject[key] = schema
+	client.byID[schema.ID] = schema
+	return schema, nil
+}
+
+// get fetches the given path and decodes the JSON response into value.
+func (client *Client) get(ctx context.Context, path string, value any) error {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, client.url+path, nil)
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Accept", "application/vnd.schemaregistry.v1+json, application/json")
+
+	response, err := client.httpClient.Do(request)
+	if err != nil {
+		return fmt.Errorf("schema registry: %w", err)
+	}
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return fmt.Errorf("schema registry: %w", err)
+	}
+
+	// Errors are returned as {"error_code":40403,"message":"Schema not found"}
+	if response.StatusCode != http.StatusOK {
+		var registryError struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(body, &registryError) == nil && registryError.Message != "" {
+			return fmt.Errorf("schema registry: GET %s: %s", path, registryError.Message)
+		}
+		return fmt.Errorf("schema registry: GET %s: %s", path, response.Status)
+	}
+
+	if err := json.Unmarshal(body, value); err != nil {
+		return fmt.Errorf("schema registry: GET %s: %w", path, err)
+	}
+	return nil
+}
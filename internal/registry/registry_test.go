@@ -0,0 +1,172 @@
+package registry_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/bluekiri/kafka-client/internal/registry"
+	"github.com/bluekiri/kafka-client/internal/registry/registrytest"
+)
+
+var testSchemas = []registry.Schema{
+	{ID: 1, Subject: "test-value", Version: 1, Schema: `"string"`},
+	{ID: 2, Subject: "test-value", Version: 2, Schema: `"bytes"`},
+	{ID: 3, Subject: "test-key", Version: 1, SchemaType: registry.SchemaTypeProtobuf, Schema: `syntax = "proto3";`},
+}
+
+func TestSchemaByID(t *testing.T) {
+	server := registrytest.NewServer(testSchemas...)
+	defer server.Close()
+
+	client := registry.NewClient(server.URL, 0)
+	for i := 0; i < 2; i++ {
+		schema, err := client.SchemaByID(context.Background(), 3)
+		if err != nil {
+			t.Fatalf("SchemaByID failed: %v", err)
+		}
+		if schema.ID != 3 || schema.Type() != registry.SchemaTypeProtobuf || schema.Schema != testSchemas[2].Schema {
+			t.Errorf("Unexpected schema %+v", schema)
+		}
+	}
+
+	// Schemas must be cached
+	if requests := server.Requests(); requests != 1 {
+		t.Errorf("Expected 1 request but got %d", requests)
+	}
+}
+
+func TestLatestSchema(t *testing.T) {
+	server := registrytest.NewServer(testSchemas...)
+	defer server.Close()
+
+	client := registry.NewClient(server.URL+"/", 0)
+	schema, err := client.LatestSchema(context.Background(), "test-value")
+	if err != nil {
+		t.Fatalf("LatestSchema failed: %v", err)
+	}
+	if schema.ID != 2 || schema.Type() != registry.SchemaTypeAvro {
+		t.Errorf("Unexpected schema %+v", schema)
+	}
+
+	// The schema must be cached by ID too
+	if _, err := client.SchemaByID(context.Background(), 2); err != nil {
+		t.Fatalf("SchemaByID failed: %v", err)
+	}
+	if requests := server.Requests(); requests != 1 {
+		t.Errorf("Expected 1 request but got %d", requests)
+	}
+}
+
+func TestSchemaByVersion(t *testing.T) {
+	server := registrytest.NewServer(testSchemas...)
+	defer server.Close()
+
+	client := registry.NewClient(server.URL, 0)
+	schema, err := client.SchemaByVersion(context.Background(), "test-value", 1)
+	if err != nil {
+		t.Fatalf("SchemaByVersion failed: %v", err)
+	}
+	if schema.ID != 1 {
+		t.Errorf("Unexpected schema %+v", schema)
+	}
+}
+
+func TestSchemaNotFound(t *testing.T) {
+	server := registrytest.NewServer(testSchemas...)
+	defer server.Close()
+
+	client := registry.NewClient(server.URL, 0)
+	if _, err := client.SchemaByID(context.Background(), 4); err == nil {
+		t.Error("SchemaByID should have failed")
+	}
+	if _, err := client.LatestSchema(context.Background(), "unknown-value"); err == nil {
+		t.Error("LatestSchema should have failed")
+	}
+}
+
+func TestUnresponsiveRegistry(t *testing.T) {
+	unblock := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-unblock:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(unblock)
+
+	// Requests fail after the timeout
+	client := registry.NewClient(server.URL, 10*time.Millisecond)
+	if _, err := client.SchemaByID(context.Background(), 1); err == nil {
+		t.Error("SchemaByID should have failed")
+	}
+
+	// Requests are canceled with the context
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	client = registry.NewClient(server.URL, 0)
+	if _, err := client.SchemaByID(ctx, 1); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled but got %v", err)
+	}
+}
+
+func TestWireFormat(t *testing.T) {
+	data := append(registry.AppendWireFormat(nil, 258), "payload"...)
+	if expected := []byte{0, 0, 0, 1, 2, 'p'}; !bytes.HasPrefix(data, expected) {
+		t.Fatalf("Expected prefix %v but got %v", expected, data)
+	}
+
+	id, payload, err := registry.SplitWireFormat(data)
+	if err != nil {
+		t.Fatalf("SplitWireFormat failed: %v", err)
+	}
+	if id != 258 || string(payload) != "payload" {
+		t.Errorf("Expected schema 258 and payload 'payload' but got %d and '%s'", id, payload)
+	}
+
+	for _, data := range [][]byte{nil, {0, 0, 0}, {1, 0, 0, 0, 1}} {
+		if _, _, err := registry.SplitWireFormat(data); !errors.Is(err, registry.ErrNotWireFormat) {
+			t.Errorf("Expected ErrNotWireFormat for %v but got %v", data, err)
+		}
+	}
+}
+
+func TestMessageIndexes(t *testing.T) {
+	testCases := map[string]struct {
+		indexes []int
+		encoded []byte
+	}{
+		"first":  {[]int{0}, []byte{0}},
+		"second": {[]int{1}, []byte{2, 2}},
+		"nested": {[]int{1, 0, 3}, []byte{6, 2, 0, 6}},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			payload := append(registry.AppendMessageIndexes(nil, tc.indexes), "payload"...)
+			if !bytes.HasPrefix(payload, tc.encoded) {
+				t.Fatalf("Expected prefix %v but got %v", tc.encoded, payload)
+			}
+
+			indexes, rest, err := registry.SplitMessageIndexes(payload)
+			if err != nil {
+				t.Fatalf("SplitMessageIndexes failed: %v", err)
+			}
+			if !slices.Equal(indexes, tc.indexes) || string(rest) != "payload" {
+				t.Errorf("Expected %v and 'payload' but got %v and '%s'", tc.indexes, indexes, rest)
+			}
+		})
+	}
+
+	for _, payload := range [][]byte{nil, {1}, {4, 2}, {0x80}} {
+		if _, _, err := registry.SplitMessageIndexes(payload); !errors.Is(err, registry.ErrInvalidMessageIndexes) {
+			t.Errorf("Expected ErrInvalidMessageIndexes for %v but got %v", payload, err)
+		}
+	}
+}
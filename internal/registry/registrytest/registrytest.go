@@ -0,0 +1,86 @@
+/*
+Copyright © 2023 VECI Group Tech S.L.
+This file is part of kafka-client.
+*/
+
+// Package registrytest provides a fake schema registry for tests.
+package registrytest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/bluekiri/kafka-client/internal/registry"
+)
+
+// Server is a fake schema registry serving the given schemas. Every schema
+// must have an ID, a subject and a version.
+type Server struct {
+	*httptest.Server
+	schemas  []registry.Schema
+	requests atomic.Int64
+}
+
+// NewServer starts and returns a fake schema registry. The caller should call
+// Close when finished, to shut it down.
+func NewServer(schemas ...registry.Schema) *Server {
+	server := &Server{schemas: schemas}
+	server.Server = httptest.NewServer(http.HandlerFunc(server.serve))
+	return server
+}
+
+// Requests returns the number of requests served.
+func (server *Server) Requests() int64 {
+	return server.requests.Load()
+}
+
+func (server *Server) serve(writer http.ResponseWriter, request *http.Request) {
+	server.requests.Add(1)
+
+	var id, subject, version string
+	if _, err := fmt.Sscanf(request.URL.Path, "/schemas/ids/%s", &id); err == nil {
+		for _, schema := range server.schemas {
+			if strconv.Itoa(schema.ID) == id {
+				// Schemas by ID are returned without ID, subject and version
+				server.reply(writer, registry.Schema{
+					SchemaType: schema.SchemaType,
+					Schema:     schema.Schema,
+					References: schema.References,
+				})
+				return
+			}
+		}
+	} else if path, found := strings.CutPrefix(request.URL.Path, "/subjects/"); found {
+		subject, version, _ = strings.Cut(path, "/versions/")
+		var latest *registry.Schema
+		for i, schema := range server.schemas {
+			if schema.Subject != subject {
+				continue
+			}
+			if strconv.Itoa(schema.Version) == version {
+				server.reply(writer, schema)
+				return
+			}
+			if latest == nil || schema.Version > latest.Version {
+				latest = &server.schemas[i]
+			}
+		}
+		if latest != nil && version == "latest" {
+			server.reply(writer, *latest)
+			return
+		}
+	}
+
+	writer.WriteHeader(http.StatusNotFound)
+	fmt.Fprint(writer, `{"error_code":40403,"message":"Schema not found"}`)
+}
+
+func (server *Server) reply(writer http.ResponseWriter, schema registry.Schema) {
+	writer.Header().Set("Content-Type", "application/vnd.schemaregistry.v1+json")
+	json.NewEncoder(writer).Encode(schema)
+}
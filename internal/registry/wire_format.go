@@ -0,0 +1,41 @@
+/*
+Copyright © 2023 VECI Group Tech S.L.
+This file is part of kafka-client.
+*/
+
+package registry
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+/*
+The Confluent wire format prefixes the serialized data with a header:
+
+	magic byte    uint8   0
+	schema ID     int32   big endian ID of the schema in the registry
+
+Protobuf payloads also start with the message indexes, see message_indexes.go.
+*/
+
+const magicByte byte = 0
+
+// ErrNotWireFormat is returned when the data is not in the wire format.
+var ErrNotWireFormat = errors.New("schema registry: data is not in the wire format")
+
+// SplitWireFormat returns the schema ID and the payload of data in the wire
+// format.
+func SplitWireFormat(data []byte) (int, []byte, error) {
+	if len(data) < 5 || data[0] != magicByte {
+		return 0, nil, ErrNotWireFormat
+	}
+	return int(int32(binary.BigEndian.Uint32(data[1:5]))), data[5:], nil
+}
+
+// AppendWireFormat appends the wire format header for the given schema ID to
+// dst and returns the extended buffer.
+func AppendWireFormat(dst []byte, id int) []byte {
+	dst = append(dst, magicByte)
+	return binary.BigEndian.AppendUint32(dst, uint32(id))
+}
@@ -0,0 +1,139 @@
+/*
+Copyright © 2023 VECI Group Tech S.L.
+This file is part of kafka-client.
+*/
+
+package transforms
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/bluekiri/kafka-client/internal/dto"
+	"github.com/bluekiri/kafka-client/internal/filters"
+	"github.com/bluekiri/kafka-client/internal/formatters"
+
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// Transform rewrites messages before they are written or produced.
+type Transform interface {
+	// Apply rewrites the message in place.
+	Apply(message *dto.KafkaMessage) error
+}
+
+// Chain returns a Transform applying the given transforms in order.
+func Chain(transforms ...Transform) Transform {
+	return chain(transforms)
+}
+
+type chain []Transform
+
+func (transforms chain) Apply(message *dto.KafkaMessage) error {
+	for _, transform := range transforms {
+		if err := transform.Apply(message); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AddHeader returns a Transform adding a header with the given key and value.
+// Existing headers with the same key are kept.
+func AddHeader(key string, value string) Transform {
+	return addHeader{[]byte(key), []byte(value)}
+}
+
+type addHeader dto.KafkaHeader
+
+func (header addHeader) Apply(message *dto.KafkaMessage) error {
+	message.Headers = append(message.Headers, dto.KafkaHeader(header))
+	return nil
+}
+
+// RemoveHeader returns a Transform removing the headers with the given key.
+func RemoveHeader(key string) Transform {
+	return removeHeader(key)
+}
+
+type removeHeader string
+
+func (key removeHeader) Apply(message *dto.KafkaMessage) error {
+	headers := message.Headers[:0]
+	for _, header := range message.Headers {
+		if string(header.Key) != string(key) {
+			headers = append(headers, header)
+		}
+	}
+	message.Headers = headers
+	return nil
+}
+
+// RenameHeader returns a Transform renaming the headers with the given key.
+func RenameHeader(from string, to string) Transform {
+	return renameHeader{[]byte(from), []byte(to)}
+}
+
+type renameHeader struct {
+	from []byte
+	to   []byte
+}
+
+func (rename renameHeader) Apply(message *dto.KafkaMessage) error {
+	for i := range message.Headers {
+		if bytes.Equal(message.Headers[i].Key, rename.from) {
+			message.Headers[i].Key = rename.to
+		}
+	}
+	return nil
+}
+
+// SetKey returns a Transform replacing the key of the messages by the result
+// of the expression. String and JSON results are encoded by the key codec,
+// bytes results are used as they are and null results remove the key.
+func SetKey(expression *filters.Expression, keyCodec formatters.Codec) Transform {
+	return &setKey{expression, keyCodec}
+}
+
+type setKey struct {
+	expression *filters.Expression
+	keyCodec   formatters.Codec
+}
+
+var jsonValueType = reflect.TypeOf(&structpb.Value{})
+
+func (transform *setKey) Apply(message *dto.KafkaMessage) error {
+	result, err := transform.expression.Eval(message)
+	if err != nil {
+		return fmt.Errorf("set-key: %w", err)
+	}
+
+	var text []byte
+	switch value := result.Value().(type) {
+	case structpb.NullValue:
+		message.Key = nil
+		return nil
+	case []byte:
+		message.Key = value
+		return nil
+	case string:
+		text = []byte(value)
+	default:
+		jsonValue, err := result.ConvertToNative(jsonValueType)
+		if err != nil {
+			return fmt.Errorf("set-key: %w", err)
+		}
+		if text, err = json.Marshal(jsonValue.(*structpb.Value).AsInterface()); err != nil {
+			return fmt.Errorf("set-key: %w", err)
+		}
+	}
+
+	key, err := transform.keyCodec.Parse(text)
+	if err != nil {
+		return fmt.Errorf("set-key: %w", err)
+	}
+	message.Key = key
+	return nil
+}
@@ -0,0 +1,159 @@
+package transforms_test
+
+import (
+	"testing"
+
+	"github.com/bluekiri/kafka-client/internal/dto"
+	"github.com/bluekiri/kafka-client/internal/filters"
+	"github.com/bluekiri/kafka-client/internal/formatters"
+	"github.com/bluekiri/kafka-client/internal/redact"
+	"github.com/bluekiri/kafka-client/internal/transforms"
+)
+
+// newTestRedactor returns a Redactor masking the given fields.
+func newTestRedactor(t *testing.T, paths ...string) *redact.Redactor {
+	fields := make([]redact.Field, 0, len(paths))
+	for _, path := range paths {
+		fields = append(fields, redact.Field{Path: path, Policy: redact.Mask})
+	}
+	redactor, err := redact.NewRedactor(nil, fields...)
+	if err != nil {
+		t.Fatalf("NewRedactor failed: %v", err)
+	}
+	return redactor
+}
+
+func newTestMessage() *dto.KafkaMessage {
+	return &dto.KafkaMessage{
+		Key:   []byte("key"),
+		Value: []byte(`{"id":12345678901234567890,"customer":{"email":"a@b.com","age":40},"items":[{"sku":"A","price":1.5},{"sku":"B"}]}`),
+		Headers: []dto.KafkaHeader{
+			{Key: []byte("tenant"), Value: []byte("x")},
+			{Key: []byte("trace-id"), Value: []byte("abc")},
+		},
+	}
+}
+
+func TestHeaders(t *testing.T) {
+	message := newTestMessage()
+	transform := transforms.Chain(
+		transforms.RemoveHeader("trace-id"),
+		transforms.RenameHeader("tenant", "source-tenant"),
+		transforms.AddHeader("env", "staging"),
+	)
+	if err := transform.Apply(message); err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+
+	expected := []string{"source-tenant=x", "env=staging"}
+	if len(message.Headers) != len(expected) {
+		t.Fatalf("Expected headers %v but got %d headers", expected, len(message.Headers))
+	}
+	for i, header := range message.Headers {
+		if actual := string(header.Key) + "=" + string(header.Value); actual != expected[i] {
+			t.Errorf("Expected header %s but got %s", expected[i], actual)
+		}
+	}
+}
+
+func TestSetKey(t *testing.T) {
+	testCases := map[string]struct {
+		expression string
+		codec      formatters.Codec
+		expected   []byte
+	}{
+		"string":  {`value.items[0].sku + "-" + headers["tenant"]`, formatters.TextCodec, []byte("A-x")},
+		"number":  {`value.customer.age`, formatters.TextCodec, []byte("40")},
+		"object":  {`value.customer`, formatters.TextCodec, []byte(`{"age":40,"email":"a@b.com"}`)},
+		"encoded": {`"0102"`, formatters.HexCodec, []byte{1, 2}},
+		"bytes":   {`b"raw"`, formatters.HexCodec, []byte("raw")},
+		"null":    {`null`, formatters.TextCodec, nil},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			expression, err := filters.NewExpression(tc.expression, formatters.TextCodec, formatters.TextCodec)
+			if err != nil {
+				t.Fatalf("NewExpression failed: %v", err)
+			}
+
+			message := newTestMessage()
+			if err := transforms.SetKey(expression, tc.codec).Apply(message); err != nil {
+				t.Fatalf("Apply failed: %v", err)
+			}
+			if string(message.Key) != string(tc.expected) || (message.Key == nil) != (tc.expected == nil) {
+				t.Errorf("Expected key '%s' but got '%s'", tc.expected, message.Key)
+			}
+		})
+	}
+}
+
+func TestValueTransform(t *testing.T) {
+	transform, err := transforms.NewValueTransform(transforms.ValueOptions{
+		Codec: formatters.TextCodec,
+		RenameFields: []transforms.FieldRename{
+			{From: "customer.email", To: "contact.email"},
+			{From: "items.sku", To: "items.product.code"},
+			{From: "missing", To: "other"},
+		},
+		Redactor: newTestRedactor(t, "contact.email", "customer.age", "items.price", "items.missing"),
+	})
+	if err != nil {
+		t.Fatalf("NewValueTransform failed: %v", err)
+	}
+
+	message := newTestMessage()
+	if err := transform.Apply(message); err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+
+	// Numbers must keep their precision
+	expected := `{"contact":{"email":"****"},"customer":{"age":null},"id":12345678901234567890,"items":[{"price":null,"product":{"code":"A"}},{"product":{"code":"B"}}]}`
+	if string(message.Value) != expected {
+		t.Errorf("Expected value '%s' but got '%s'", expected, message.Value)
+	}
+}
+
+func TestValueTransformCodecs(t *testing.T) {
+	transform, err := transforms.NewValueTransform(transforms.ValueOptions{
+		Codec:       formatters.Int64Codec,
+		OutputCodec: formatters.TextCodec,
+	})
+	if err != nil {
+		t.Fatalf("NewValueTransform failed: %v", err)
+	}
+
+	message := &dto.KafkaMessage{Value: []byte{0, 0, 0, 0, 0, 0, 4, 176}}
+	if err := transform.Apply(message); err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+	if string(message.Value) != "1200" {
+		t.Errorf("Expected value '1200' but got '%s'", message.Value)
+	}
+
+	// Null values are not transformed
+	message = &dto.KafkaMessage{}
+	if err := transform.Apply(message); err != nil || message.Value != nil {
+		t.Errorf("Expected null value but got '%s' and %v", message.Value, err)
+	}
+}
+
+func TestValueTransformErrors(t *testing.T) {
+	for _, field := range []string{"", "a..b", "a."} {
+		options := transforms.ValueOptions{
+			Codec:        formatters.TextCodec,
+			RenameFields: []transforms.FieldRename{{From: "a", To: field}},
+		}
+		if _, err := transforms.NewValueTransform(options); err == nil {
+			t.Errorf("NewValueTransform should have failed for '%s'", field)
+		}
+	}
+
+	transform, err := transforms.NewValueTransform(transforms.ValueOptions{Codec: formatters.TextCodec, Redactor: newTestRedactor(t, "email")})
+	if err != nil {
+		t.Fatalf("NewValueTransform failed: %v", err)
+	}
+	if err := transform.Apply(&dto.KafkaMessage{Value: []byte("not json")}); err == nil {
+		t.Error("Apply should have failed")
+	}
+}
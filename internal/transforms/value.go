@@ -0,0 +1,184 @@
+/*
+Copyright © 2023 VECI Group Tech S.L.
+This file is part of kafka-client.
+*/
+
+package transforms
+
+import (
+	"fmt"
+
+	"github.com/bluekiri/kafka-client/internal/dto"
+	"github.com/bluekiri/kafka-client/internal/formatters"
+	"github.com/bluekiri/kafka-client/internal/jsonutils"
+	"github.com/bluekiri/kafka-client/internal/redact"
+)
+
+// FieldRename moves the field at the From path to the To path.
+type FieldRename struct {
+	From string
+	To   string
+}
+
+// ValueOptions are the options of the value Transform.
+type ValueOptions struct {
+	// Codec decodes the values and OutputCodec encodes them once transformed.
+	// The values are encoded by Codec if OutputCodec is nil.
+	Codec       formatters.Codec
+	OutputCodec formatters.Codec
+
+	// RenameFields and Redactor are applied to the JSON representation of the
+	// values, in this order. Fields are given by dot separated paths (e.g.
+	// customer.email). Paths going through arrays are applied to every element
+	// of the arrays.
+	RenameFields []FieldRename
+	Redactor     *redact.Redactor
+}
+
+// NewValueTransform returns a Transform rewriting the values of the messages.
+// The values are decoded by the codec, their fields renamed and redacted, and
+// encoded by the output codec, which allows converting values between formats
+// (e.g. from a protobuf message type to another one with the same fields).
+// Null values are not transformed.
+func NewValueTransform(options ValueOptions) (Transform, error) {
+	transform := &valueTransform{
+		codec:       options.Codec,
+		outputCodec: options.OutputCodec,
+		redactor:    options.Redactor,
+	}
+	if transform.outputCodec == nil {
+		transform.outputCodec = transform.codec
+	}
+
+	for _, rename := range options.RenameFields {
+		from, err := jsonutils.ParsePath(rename.From)
+		if err != nil {
+			return nil, err
+		}
+		to, err := jsonutils.ParsePath(rename.To)
+		if err != nil {
+			return nil, err
+		}
+		transform.renames = append(transform.renames, fieldRename{from, to})
+	}
+
+	return transform, nil
+}
+
+type fieldRename struct {
+	from []string
+	to   []string
+}
+
+type valueTransform struct {
+	codec       formatters.Codec
+	outputCodec formatters.Codec
+	renames     []fieldRename
+	redactor    *redact.Redactor
+}
+
+func (transform *valueTransform) Apply(message *dto.KafkaMessage) error {
+	if message.Value == nil {
+		return nil
+	}
+
+	text, err := transform.codec.Format(message.Value)
+	if err != nil {
+		return fmt.Errorf("transform: %w", err)
+	}
+
+	if len(transform.renames) > 0 || transform.redactor != nil {
+		if text, err = transform.transformFields(text); err != nil {
+			return fmt.Errorf("transform: %w", err)
+		}
+	}
+
+	value, err := transform.outputCodec.Parse(text)
+	if err != nil {
+		return fmt.Errorf("transform: %w", err)
+	}
+	message.Value = value
+	return nil
+}
+
+// transformFields renames and redacts the fields of the JSON text.
+func (transform *valueTransform) transformFields(text []byte) ([]byte, error) {
+	document, err := jsonutils.Decode(text)
+	if err != nil {
+		return nil, fmt.Errorf("the value is not JSON: %w", err)
+	}
+
+	for _, rename := range transform.renames {
+		renameField(document, rename.from, rename.to)
+	}
+	if transform.redactor != nil {
+		if err := transform.redactor.Redact(document); err != nil {
+			return nil, err
+		}
+	}
+	return jsonutils.Encode(document)
+}
+
+// renameField moves the field at the from path to the to path. The common
+// parent of both paths is visited like by jsonutils.VisitField, so fields of
+// array elements are moved within each element, while the objects missing
+// along the rest of the to path are created.
+func renameField(document any, from []string, to []string) {
+	common := 0
+	for common < len(from)-1 && common < len(to)-1 && from[common] == to[common] {
+		common++
+	}
+
+	move := func(parent any) {
+		object, ok := parent.(map[string]any)
+		if !ok {
+			return
+		}
+		value, found := removeField(object, from[common:])
+		if found {
+			setField(object, to[common:], value)
+		}
+	}
+	if common == 0 {
+		move(document)
+		return
+	}
+	jsonutils.VisitField(document, from[:common], func(object map[string]any, name string) {
+		if elements, isArray := object[name].([]any); isArray {
+			for _, element := range elements {
+				move(element)
+			}
+			return
+		}
+		move(object[name])
+	})
+}
+
+// removeField removes and returns the field at the path of the object.
+func removeField(object map[string]any, path []string) (any, bool) {
+	for _, name := range path[:len(path)-1] {
+		child, ok := object[name].(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		object = child
+	}
+	name := path[len(path)-1]
+	value, found := object[name]
+	delete(object, name)
+	return value, found
+}
+
+// setField sets the field at the path of the object, creating the missing
+// objects along the path.
+func setField(object map[string]any, path []string, value any) {
+	for _, name := range path[:len(path)-1] {
+		child, ok := object[name].(map[string]any)
+		if !ok {
+			child = make(map[string]any)
+			object[name] = child
+		}
+		object = child
+	}
+	object[path[len(path)-1]] = value
+}